@@ -1,38 +1,53 @@
 package go_gin_pages
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
-	"sync"
+	"strings"
 	"tick_test/repository"
+	"tick_test/service"
 	"tick_test/types"
 	"tick_test/utils/errDefs"
 	"tick_test/utils/jwt"
-	"tick_test/utils/random"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
-type userTokenInfo struct {
-	Username string
-	Expiry   time.Time
-}
-
-var (
-	tokenStore      = make(map[string]userTokenInfo)
-	tokenStoreMutex sync.RWMutex
-)
-
 type accountHandler struct {
-	repo repository.AccountRepository
+	repo         repository.AccountRepository
+	mode         types.AuthMode
+	tokens       *service.TokenService
+	sessions     *service.SessionService
+	revocations  *service.RevocationStore
+	throttle     *service.LoginThrottle
+	twoFactor    *service.TwoFactorService
+	apiKeys      *service.ApiKeyService
+	authz        *service.Authorizer
+	resets       *service.PasswordResetService
+	suspensions  *service.SuspensionStore
+	personalData *service.PersonalDataService
+	logins       *service.LoginHistory
+	oauth        *service.OAuthService
+	registration *service.RegistrationService
 }
 
 func NewAccountHandler(accountRepo repository.AccountRepository) *accountHandler {
-	return &accountHandler{repo: accountRepo}
+	ah := &accountHandler{
+		repo:   accountRepo,
+		mode:   types.JwtAuthMode,
+		tokens: service.NewTokenService(accountRepo),
+	}
+	// the role repository is set and the grants are loaded in Prepare
+	ah.authz = service.NewAuthorizer(nil)
+	ah.authz.Authenticate = ah.authenticate
+	ah.authz.RespondError = returnError
+	// the registration repository is set in Prepare
+	ah.registration = service.NewRegistrationService(nil, accountRepo)
+	return ah
 }
 
 func (ah *accountHandler) getPaginatedAccountsHandler() gin.HandlerFunc {
@@ -73,8 +88,12 @@ func (ah *accountHandler) PatchPromoteAccountHandler() gin.HandlerFunc {
 			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrUnauthorized, err))
 			return
 		}
-		if claims.Role != "Admin" {
-			returnError(c, fmt.Errorf("%w: only admin can modify roles", errDefs.ErrUnauthorized))
+		if !ah.authz.HasPermission(claims.Role, types.AccountPromotePermission) {
+			returnError(c, fmt.Errorf("%w: permission %s required to modify roles", errDefs.ErrForbidden, types.AccountPromotePermission))
+			return
+		}
+		if err := ah.requireTwoFactor(claims); err != nil {
+			returnError(c, err)
 			return
 		}
 
@@ -83,6 +102,18 @@ func (ah *accountHandler) PatchPromoteAccountHandler() gin.HandlerFunc {
 			returnError(c, fmt.Errorf("%w: invalid_json", errDefs.ErrBadRequest))
 			return
 		}
+		if !ah.authz.RoleExists(types.Role(data.Role)) {
+			returnError(c, fmt.Errorf("%w: no role %s", errDefs.ErrBadRequest, data.Role))
+			return
+		}
+		if !ah.authz.Covers(claims.Role, types.Role(data.Role)) {
+			returnError(c, fmt.Errorf("%w: role %s holds permissions role %s lacks", errDefs.ErrForbidden, data.Role, claims.Role))
+			return
+		}
+		if err := ah.mayManage(claims, data.Username); err != nil {
+			returnError(c, err)
+			return
+		}
 		if err := ah.repo.PromoteExistingAccount(&data); err != nil {
 			returnError(c, err)
 			return
@@ -95,7 +126,11 @@ func (ah *accountHandler) PatchAccountHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		username := c.GetHeader("Username")
 		password := c.GetHeader("Password")
-		if err := ah.repo.ConfirmAccount(username, password); err != nil {
+		if err := ah.confirmCredentials(c, username, password); err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := ah.confirmSecondFactor(c, username); err != nil {
 			returnError(c, err)
 			return
 		}
@@ -132,12 +167,16 @@ func (ah *accountHandler) DeleteAccountHandler() gin.HandlerFunc {
 		}
 
 		password := c.GetHeader("Password")
-		if err := ah.repo.ConfirmAccount(username, password); err != nil {
+		if err := ah.confirmCredentials(c, username, password); err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := ah.confirmSecondFactor(c, username); err != nil {
 			returnError(c, err)
 			return
 		}
 
-		if err := ah.repo.DeleteAccount(username); err != nil {
+		if err := ah.eraseAccount(username); err != nil {
 			returnError(c, err)
 			return
 		}
@@ -145,49 +184,62 @@ func (ah *accountHandler) DeleteAccountHandler() gin.HandlerFunc {
 	}
 }
 
-func generateToken(username string) string {
-	token := random.RandSeq(80)
-	tokenStoreMutex.Lock()
-	tokenStore[token] = userTokenInfo{Username: username, Expiry: time.Now().Add(30 * time.Minute)}
-	tokenStoreMutex.Unlock()
-	return token
-}
-
-func confirmToken(val string) (string, error) {
-	tokenStoreMutex.RLock()
-	info, exists := tokenStore[val]
-	tokenStoreMutex.RUnlock()
-
-	if !exists {
-		return "", errors.New("invalid token")
-	}
-	if time.Now().After(info.Expiry) {
-		tokenStoreMutex.Lock()
-		delete(tokenStore, val)
-		tokenStoreMutex.Unlock()
-		return "", errors.New("token expired")
-	}
-	return info.Username, nil
-}
-
 func (ah *accountHandler) tokenAuth(c *gin.Context) (jwt.Claims, error) {
-	token := c.GetHeader("User-Token")
+	token := service.AccessToken(c)
 	if token == "" {
 		return jwt.Claims{}, fmt.Errorf("%w: no token provided", errDefs.ErrUnauthorized)
 	}
+	if ah.sessions != nil && isSessionToken(token) {
+		claims, err := ah.sessions.Validate(token)
+		if err != nil {
+			return jwt.Claims{}, fmt.Errorf("%w: %v", errDefs.ErrUnauthorized, err.Error())
+		}
+		if err := ah.checkSuspension(claims.Username); err != nil {
+			return jwt.Claims{}, err
+		}
+		return claims, nil
+	}
 	claims, err := jwt.ValidateToken(token)
 	if err != nil {
 		return jwt.Claims{}, fmt.Errorf("%w: %v", errDefs.ErrUnauthorized, err.Error())
 	}
+	if err := ah.checkSuspension(claims.Username); err != nil {
+		return jwt.Claims{}, err
+	}
+	if len(claims.Scopes) > 0 {
+		if err := checkScope(c, claims, "client credentials token"); err != nil {
+			return jwt.Claims{}, err
+		}
+	}
 	return claims, nil
 }
 
+// checkSuspension refuses tokens of accounts suspended after they were issued.
+func (ah *accountHandler) checkSuspension(username string) error {
+	if ah.suspensions == nil {
+		return nil
+	}
+	return ah.suspensions.Check(username)
+}
+
+// isSessionToken tells opaque session tokens apart from JWTs, which always
+// consist of three dot separated parts.
+func isSessionToken(token string) bool {
+	return !strings.Contains(token, ".")
+}
+
 func (ah *accountHandler) passwordAuth(c *gin.Context) (jwt.Claims, error) {
 	username := c.GetHeader("Username")
 	password := c.GetHeader("Password")
-	if err := ah.repo.ConfirmAccount(username, password); err != nil {
+	if err := ah.confirmCredentials(c, username, password); err != nil {
+		if errors.Is(err, errDefs.ErrTooManyRequests) || errors.Is(err, errDefs.ErrForbidden) {
+			return jwt.Claims{}, err
+		}
 		return jwt.Claims{}, fmt.Errorf("%w: invalid credentials", errDefs.ErrUnauthorized)
 	}
+	if err := ah.confirmSecondFactor(c, username); err != nil {
+		return jwt.Claims{}, err
+	}
 	role, err := ah.repo.FindUserRole(username)
 	if err != nil {
 		return jwt.Claims{}, fmt.Errorf("error retrieving user role: %w", err)
@@ -195,18 +247,95 @@ func (ah *accountHandler) passwordAuth(c *gin.Context) (jwt.Claims, error) {
 	return jwt.Claims{Username: username, Role: role}, nil
 }
 
+// confirmCredentials checks a username and password, counting failures
+// against the username and the client IP when a throttle is configured and
+// adding them to the login history. A correct password does not clear the
+// failures, which would give every guess at the second factor a fresh
+// budget; loginSucceeded does that.
+func (ah *accountHandler) confirmCredentials(c *gin.Context, username string, password string) error {
+	err := ah.checkCredentials(c, username, password)
+	if err != nil {
+		ah.recordLogin(c, username, err)
+	}
+	return err
+}
+
+func (ah *accountHandler) checkCredentials(c *gin.Context, username string, password string) error {
+	if ah.throttle == nil {
+		return ah.repo.ConfirmAccount(username, password)
+	}
+	if err := ah.throttle.Allow(username, c.ClientIP()); err != nil {
+		return err
+	}
+	err := ah.repo.ConfirmAccount(username, password)
+	if errors.Is(err, errDefs.ErrUnauthorized) || errors.Is(err, errDefs.ErrEntityNotFound) {
+		ah.throttle.Fail(username, c.ClientIP())
+	}
+	return err
+}
+
+// loginSucceeded clears the failures of username once every factor passed.
+func (ah *accountHandler) loginSucceeded(username string) {
+	if ah.throttle != nil {
+		ah.throttle.Succeed(username)
+	}
+}
+
 func (ah *accountHandler) ConfirmAccountFromGinContext(c *gin.Context) (jwt.Claims, error) {
+	if key, ok := apiKeyFromHeader(c); ok {
+		return ah.apiKeyAuth(c, key)
+	}
 	if c.GetHeader("Password") != "" {
 		return ah.passwordAuth(c)
 	}
 	return ah.tokenAuth(c)
 }
 
+// authenticate confirms the account of a request to a permission-protected
+// route, which may also demand two-factor authentication.
+func (ah *accountHandler) authenticate(c *gin.Context) (jwt.Claims, error) {
+	claims, err := ah.ConfirmAccountFromGinContext(c)
+	if err != nil {
+		return jwt.Claims{}, err
+	}
+	if err := ah.requireTwoFactor(claims); err != nil {
+		return jwt.Claims{}, err
+	}
+	return claims, nil
+}
+
+// mayManage refuses to let claims act on the account of username when its
+// role outranks the caller's.
+func (ah *accountHandler) mayManage(claims jwt.Claims, username string) error {
+	role, err := ah.repo.FindUserRole(username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: no account %s", errDefs.ErrEntityNotFound, username)
+	}
+	if err != nil {
+		return err
+	}
+	if !ah.authz.Covers(claims.Role, role) {
+		return fmt.Errorf("%w: account %s has role %s, which outranks role %s", errDefs.ErrForbidden, username, role, claims.Role)
+	}
+	return nil
+}
+
+func (ah *accountHandler) requirePermission(permission string) gin.HandlerFunc {
+	return ah.authz.Require(permission)
+}
+
+// claimsFromContext returns the claims requirePermission confirmed for the request.
+func claimsFromContext(c *gin.Context) jwt.Claims {
+	claims, _ := c.Get(service.ClaimsContextKey)
+	confirmed, _ := claims.(jwt.Claims)
+	return confirmed
+}
+
 func (ah *accountHandler) LoginHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		username := c.GetHeader("Username")
 		password := c.GetHeader("Password")
-		if err := ah.repo.ConfirmAccount(username, password); err != nil {
+		if err := ah.confirmCredentials(c, username, password); err != nil {
 			returnError(c, err)
 			return
 		}
@@ -215,12 +344,148 @@ func (ah *accountHandler) LoginHandler() gin.HandlerFunc {
 			returnError(c, err)
 			return
 		}
-		token, err := jwt.GenerateToken(username, role, 30*time.Minute)
+		if ah.twoFactor != nil {
+			enabled, err := ah.twoFactor.Enabled(username)
+			if err != nil {
+				returnError(c, err)
+				return
+			}
+			if enabled {
+				challenge, err := ah.twoFactor.Challenge(username, role)
+				if err != nil {
+					returnError(c, err)
+					return
+				}
+				c.JSON(http.StatusAccepted, challenge)
+				return
+			}
+		}
+		ah.loginSucceeded(username)
+		ah.completeLogin(c, username, role)
+	}
+}
+
+// startLogin opens a session or issues a token pair for a successful
+// login, depending on the configured mode. clientId is the OAuth2 client
+// logging in, if any.
+func (ah *accountHandler) startLogin(c *gin.Context, username string, role types.Role, clientId string) (types.TokenPair, error) {
+	device := requestDevice(c)
+	if ah.mode == types.SessionAuthMode {
+		session, err := ah.sessions.Open(username, device)
 		if err != nil {
+			return types.TokenPair{}, err
+		}
+		return types.TokenPair{
+			AccessToken: session,
+			ExpiresIn:   int64(ah.sessions.IdleTimeout.Seconds()),
+		}, nil
+	}
+	return ah.tokens.Issue(username, role, device, clientId)
+}
+
+// completeLogin answers a successful login with a session or a token pair.
+func (ah *accountHandler) completeLogin(c *gin.Context, username string, role types.Role) {
+	tokens, err := ah.startLogin(c, username, role, "")
+	if err != nil {
+		if ah.mode == types.SessionAuthMode {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Session creation error"})
+		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Token generation error"})
+		}
+		return
+	}
+	ah.recordLogin(c, username, nil)
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (ah *accountHandler) RefreshTokenHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var data types.RefreshTokenPostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		tokens, err := ah.tokens.Refresh(data.RefreshToken, "")
+		if err != nil {
+			returnError(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, token)
+		c.JSON(http.StatusOK, tokens)
+	}
+}
+
+func (ah *accountHandler) LogoutHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := ah.tokenAuth(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		var data types.LogoutPostData
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&data); err != nil {
+				returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+				return
+			}
+		}
+
+		if token := service.AccessToken(c); ah.sessions != nil && isSessionToken(token) {
+			if err := ah.sessions.Revoke(token); err != nil {
+				returnError(c, err)
+				return
+			}
+		} else if claims.Jti != "" {
+			if err := ah.revocations.Revoke(claims); err != nil {
+				returnError(c, err)
+				return
+			}
+		}
+		if data.RefreshToken != "" {
+			if err := ah.revocations.RevokeRefreshToken(claims.Username, data.RefreshToken); err != nil {
+				returnError(c, err)
+				return
+			}
+		}
+		c.JSON(http.StatusOK, nil)
+	}
+}
+
+func (ah *accountHandler) LogoutEverywhereHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := ah.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := ah.revocations.RevokeAll(claims.Username); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, nil)
+	}
+}
+
+func (ah *accountHandler) UnlockAccountHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var data types.AccountUnlockPostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		if data.Username == "" && data.IP == "" {
+			returnError(c, fmt.Errorf("%w: either Username or IP is required", errDefs.ErrMissingField))
+			return
+		}
+		if ah.throttle == nil {
+			returnError(c, fmt.Errorf("%w: login throttling is disabled", errDefs.ErrEntityNotFound))
+			return
+		}
+		if err := ah.throttle.Unlock(data.Username, data.IP); err != nil {
+			returnError(c, err)
+			return
+		}
+		logrus.Info("unlocked login for ", data.Username, " from ", data.IP)
+		c.JSON(http.StatusOK, nil)
 	}
 }
 
@@ -242,13 +507,16 @@ func (ah *accountHandler) PostAccountHandler() gin.HandlerFunc {
 			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
 			return
 		}
-		if data.Role == "" {
-			data.Role = "User"
-		}
-		if err := ah.repo.SaveAccount(&data); err != nil {
+		pending, err := ah.registration.Register(&data)
+		if err != nil {
 			returnError(c, err)
 			return
 		}
+		data.InviteCode = ""
+		if pending {
+			c.JSON(http.StatusAccepted, data)
+			return
+		}
 		c.JSON(http.StatusCreated, data)
 	}
 }
@@ -258,7 +526,44 @@ func (ah *accountHandler) prepareAccount(route *gin.RouterGroup) {
 	route.GET("/", ah.getPaginatedAccountsHandler())
 	route.POST("/register", ah.PostAccountHandler())
 	route.POST("/login", ah.LoginHandler())
+	route.POST("/login/2fa", ah.TwoFactorLoginHandler())
+	route.POST("/2fa/enroll", ah.EnrollTwoFactorHandler())
+	route.POST("/2fa/confirm", ah.ConfirmTwoFactorHandler())
+	route.POST("/2fa/recovery-codes", ah.RegenerateRecoveryCodesHandler())
+	route.POST("/2fa/disable", ah.DisableTwoFactorHandler())
+	route.POST("/token/refresh", ah.RefreshTokenHandler())
+	route.POST("/logout", ah.LogoutHandler())
+	route.POST("/logout/all", ah.LogoutEverywhereHandler())
 	route.PATCH("/modify", ah.PatchAccountHandler())
 	route.PATCH("/promote", ah.PatchPromoteAccountHandler())
+	route.GET("/api-keys", ah.GetApiKeysHandler())
+	route.POST("/api-keys", ah.PostApiKeyHandler())
+	route.DELETE("/api-keys/:id", ah.DeleteApiKeyHandler())
+	route.GET("/oauth-clients", ah.GetOAuthClientsHandler())
+	route.POST("/oauth-clients", ah.PostOAuthClientHandler())
+	route.DELETE("/oauth-clients/:clientId", ah.DeleteOAuthClientHandler())
+	route.POST("/unlock", requireScope(types.AdminScope), ah.requirePermission(types.AccountUnlockPermission), ah.UnlockAccountHandler())
+	route.POST("/password-reset", ah.RequestPasswordResetHandler())
+	route.POST("/password-reset/issue", requireScope(types.AdminScope), ah.requirePermission(types.AccountResetPermission), ah.IssuePasswordResetHandler())
+	route.POST("/password-reset/confirm", ah.ConfirmPasswordResetHandler())
 	route.DELETE("/delete", ah.DeleteAccountHandler())
+	route.DELETE("/me", ah.EraseMeHandler())
+	route.GET("/me/export", ah.ExportPersonalDataHandler())
+	route.GET("/me/sessions", ah.GetMySessionsHandler())
+	route.GET("/me/sessions/history", ah.GetMyLoginHistoryHandler())
+	route.DELETE("/me/sessions/:id", ah.RevokeMySessionHandler())
+	route.GET("/admin/suspended", requireScope(types.AdminScope), ah.requirePermission(types.AccountSuspendPermission), ah.GetSuspensionsHandler())
+	route.POST("/admin/suspend", requireScope(types.AdminScope), ah.requirePermission(types.AccountSuspendPermission), ah.SuspendAccountHandler())
+	route.POST("/admin/reinstate", requireScope(types.AdminScope), ah.requirePermission(types.AccountSuspendPermission), ah.ReinstateAccountHandler())
+	route.POST("/admin/force-reset", requireScope(types.AdminScope), ah.requirePermission(types.AccountResetPermission), ah.ForcePasswordResetHandler())
+	route.DELETE("/admin/:username", requireScope(types.AdminScope), ah.requirePermission(types.AccountDeletePermission), ah.AdminDeleteAccountHandler())
+	route.GET("/admin/sessions/:username", requireScope(types.AdminScope), ah.requirePermission(types.AccountViewPermission), ah.GetAccountSessionsHandler())
+	route.GET("/admin/sessions/:username/history", requireScope(types.AdminScope), ah.requirePermission(types.AccountViewPermission), ah.GetAccountLoginHistoryHandler())
+	route.DELETE("/admin/sessions/:username/:id", requireScope(types.AdminScope), ah.requirePermission(types.AccountSessionsPermission), ah.RevokeAccountSessionHandler())
+	route.GET("/admin/invites", requireScope(types.AdminScope), ah.requirePermission(types.AccountInvitePermission), ah.GetInvitesHandler())
+	route.POST("/admin/invites", requireScope(types.AdminScope), ah.requirePermission(types.AccountInvitePermission), ah.PostInviteHandler())
+	route.DELETE("/admin/invites/:id", requireScope(types.AdminScope), ah.requirePermission(types.AccountInvitePermission), ah.DeleteInviteHandler())
+	route.GET("/admin/pending", requireScope(types.AdminScope), ah.requirePermission(types.AccountApprovePermission), ah.GetPendingAccountsHandler())
+	route.POST("/admin/pending/:username/approve", requireScope(types.AdminScope), ah.requirePermission(types.AccountApprovePermission), ah.ApprovePendingAccountHandler())
+	route.DELETE("/admin/pending/:username", requireScope(types.AdminScope), ah.requirePermission(types.AccountApprovePermission), ah.RejectPendingAccountHandler())
 }
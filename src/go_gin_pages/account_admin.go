@@ -0,0 +1,132 @@
+package go_gin_pages
+
+import (
+	"fmt"
+	"net/http"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// notOwnAccount keeps Admins from locking themselves out.
+func notOwnAccount(c *gin.Context, username string) bool {
+	if username == claimsFromContext(c).Username {
+		returnError(c, fmt.Errorf("%w: this endpoint cannot be used on your own account", errDefs.ErrConflict))
+		return false
+	}
+	return true
+}
+
+func (ah *accountHandler) GetSuspensionsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		suspensions, err := ah.suspensions.List()
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, suspensions)
+	}
+}
+
+func (ah *accountHandler) SuspendAccountHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var data types.SuspensionPostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		if !notOwnAccount(c, data.Username) {
+			return
+		}
+		claims := claimsFromContext(c)
+		if err := ah.mayManage(claims, data.Username); err != nil {
+			returnError(c, err)
+			return
+		}
+		suspension, err := ah.suspensions.Suspend(data, claims.Username)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		logrus.Info(claims.Username, " suspended ", data.Username, ": ", data.Reason)
+		c.JSON(http.StatusOK, suspension)
+	}
+}
+
+func (ah *accountHandler) ReinstateAccountHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var data types.AccountTargetPostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		if err := ah.mayManage(claimsFromContext(c), data.Username); err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := ah.suspensions.Reinstate(data.Username); err != nil {
+			returnError(c, err)
+			return
+		}
+		logrus.Info(claimsFromContext(c).Username, " reinstated ", data.Username)
+		c.JSON(http.StatusOK, nil)
+	}
+}
+
+func (ah *accountHandler) ForcePasswordResetHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ah.passwordResetsEnabled(c) {
+			return
+		}
+		var data types.AccountTargetPostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		if !notOwnAccount(c, data.Username) {
+			return
+		}
+		claims := claimsFromContext(c)
+		if err := ah.mayManage(claims, data.Username); err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := ah.resets.Force(data.Username, claims.Username); err != nil {
+			returnError(c, err)
+			return
+		}
+		logrus.Info(claims.Username, " forced a password reset for ", data.Username)
+		c.JSON(http.StatusAccepted, nil)
+	}
+}
+
+func (ah *accountHandler) AdminDeleteAccountHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("username")
+		if !notOwnAccount(c, username) {
+			return
+		}
+		exists, err := ah.repo.UserExists(username)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if !exists {
+			returnError(c, fmt.Errorf("%w: no account found with the specified username", errDefs.ErrEntityNotFound))
+			return
+		}
+		if err := ah.mayManage(claimsFromContext(c), username); err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := ah.eraseAccount(username); err != nil {
+			returnError(c, err)
+			return
+		}
+		logrus.Info(claimsFromContext(c).Username, " deleted account ", username)
+		c.JSON(http.StatusAccepted, nil)
+	}
+}
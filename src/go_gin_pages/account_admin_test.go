@@ -0,0 +1,104 @@
+package go_gin_pages_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ginPages "tick_test/go_gin_pages"
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type discardNotifier struct{}
+
+func (discardNotifier) NotifyPasswordReset(types.PasswordReset) error { return nil }
+
+var adminTestRoles = map[string]types.Role{"mod": "Moderator", "root": types.AdminRole, "john": types.UserRole, "boss": types.AdminRole}
+
+// newAdminHandler returns an account handler whose Moderator role holds the
+// account permissions but is outranked by Admin.
+func newAdminHandler(t *testing.T, acted *bool) *ginPages.AccountHandler {
+	ah := ginPages.NewAccountHandler(&mocks.AccountRepositoryMock{
+		FindUserRoleFn: func(username string) (types.Role, error) { return adminTestRoles[username], nil },
+		UserExistsFn:   func(string) (bool, error) { return true, nil },
+	})
+	require.NoError(t, ah.UseRoles(&mocks.RoleRepositoryMock{
+		FindRolesFn: func() ([]types.RoleInfo, error) {
+			return []types.RoleInfo{
+				{Name: types.UserRole, Permissions: []string{}},
+				{Name: types.AdminRole, Permissions: []string{}},
+				{Name: "Moderator", Permissions: []string{types.AccountSuspendPermission, types.AccountResetPermission, types.AccountDeletePermission}},
+			}, nil
+		},
+	}))
+	ah.UseSuspensions(service.NewSuspensionStore(&mocks.SuspensionRepositoryMock{
+		SuspendAccountFn: func(types.Suspension) error {
+			*acted = true
+			return nil
+		},
+		ReinstateAccountFn: func(string) error {
+			*acted = true
+			return nil
+		},
+	}))
+	ah.UseResets(service.NewPasswordResetService(&mocks.PasswordResetRepositoryMock{
+		ClearPasswordFn: func(string) error {
+			*acted = true
+			return nil
+		},
+		SavePasswordResetFn: func(string, string, time.Time) error {
+			*acted = true
+			return nil
+		},
+	}, discardNotifier{}))
+	return ah
+}
+
+func TestAdminTargetsOutranked(t *testing.T) {
+	testCases := []struct {
+		name           string
+		handler        func(ah *ginPages.AccountHandler) gin.HandlerFunc
+		caller         string
+		target         string
+		expectedStatus int
+	}{
+		{name: "Suspend - User", handler: (*ginPages.AccountHandler).SuspendAccountHandler, caller: "mod", target: "john", expectedStatus: http.StatusOK},
+		{name: "Suspend - Admin", handler: (*ginPages.AccountHandler).SuspendAccountHandler, caller: "mod", target: "boss", expectedStatus: http.StatusForbidden},
+		{name: "Suspend - Admin by Admin", handler: (*ginPages.AccountHandler).SuspendAccountHandler, caller: "root", target: "boss", expectedStatus: http.StatusOK},
+		{name: "Reinstate - Admin", handler: (*ginPages.AccountHandler).ReinstateAccountHandler, caller: "mod", target: "boss", expectedStatus: http.StatusForbidden},
+		{name: "Force reset - User", handler: (*ginPages.AccountHandler).ForcePasswordResetHandler, caller: "mod", target: "john", expectedStatus: http.StatusAccepted},
+		{name: "Force reset - Admin", handler: (*ginPages.AccountHandler).ForcePasswordResetHandler, caller: "mod", target: "boss", expectedStatus: http.StatusForbidden},
+		{name: "Force reset - Admin by Admin", handler: (*ginPages.AccountHandler).ForcePasswordResetHandler, caller: "root", target: "boss", expectedStatus: http.StatusAccepted},
+		{name: "Issue reset - User", handler: (*ginPages.AccountHandler).IssuePasswordResetHandler, caller: "mod", target: "john", expectedStatus: http.StatusAccepted},
+		{name: "Issue reset - Admin", handler: (*ginPages.AccountHandler).IssuePasswordResetHandler, caller: "mod", target: "boss", expectedStatus: http.StatusForbidden},
+		{name: "Delete - Admin", handler: (*ginPages.AccountHandler).AdminDeleteAccountHandler, caller: "mod", target: "boss", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			acted := false
+			ah := newAdminHandler(t, &acted)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin", strings.NewReader(`{"username":"`+tc.target+`","reason":"spam"}`))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "username", Value: tc.target}}
+			c.Set(service.ClaimsContextKey, jwt.Claims{Username: tc.caller, Role: adminTestRoles[tc.caller]})
+
+			tc.handler(ah)(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedStatus != http.StatusForbidden, acted)
+		})
+	}
+}
@@ -2,19 +2,26 @@ package go_gin_pages_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
 	ginPages "tick_test/go_gin_pages"
 	"tick_test/go_gin_pages/mocks"
 	"tick_test/repository"
+	"tick_test/service"
 	"tick_test/types"
 	"tick_test/utils/errDefs"
 	"tick_test/utils/jwt"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetAllAccounts(t *testing.T) {
@@ -77,6 +84,7 @@ func TestGetAllAccounts(t *testing.T) {
 
 func TestLoginHandler_Success(t *testing.T) {
 	jwt.SetSecretKey([]byte("RU5DT0RFRF9TRUNSRVRfVEVYVA=="))
+	var savedHash string
 	repo := &mocks.AccountRepositoryMock{
 		ConfirmAccountFn: func(username, password string) error {
 			if username == "valid" && password == "pass" {
@@ -87,6 +95,11 @@ func TestLoginHandler_Success(t *testing.T) {
 		FindUserRoleFn: func(username string) (types.Role, error) {
 			return types.Role("User"), nil
 		},
+		SaveRefreshTokenFn: func(username, family, clientId, tokenHash string, device types.DeviceInfo, expiresAt time.Time) error {
+			assert.Empty(t, clientId, "logins through the account endpoints belong to no client")
+			savedHash = tokenHash
+			return nil
+		},
 	}
 
 	handler := ginPages.NewAccountHandler(repo).LoginHandler()
@@ -100,6 +113,55 @@ func TestLoginHandler_Success(t *testing.T) {
 	handler(c)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	var tokens types.TokenPair
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	claims, err := jwt.ValidateToken(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "valid", claims.Username)
+	assert.Equal(t, service.HashToken(tokens.RefreshToken), savedHash)
+	assert.Equal(t, int64(1800), tokens.ExpiresIn)
+}
+
+func TestRefreshTokenHandler(t *testing.T) {
+	jwt.SetSecretKey([]byte("RU5DT0RFRF9TRUNSRVRfVEVYVA=="))
+	testCases := []struct {
+		name           string
+		payload        string
+		rotateErr      error
+		expectedStatus int
+	}{
+		{name: "Success", payload: `{"refreshToken":"old"}`, expectedStatus: http.StatusOK},
+		{name: "Fail - Missing token", payload: `{}`, expectedStatus: http.StatusBadRequest},
+		{name: "Fail - Reused token", payload: `{"refreshToken":"old"}`, rotateErr: fmt.Errorf("%w: refresh token reuse detected", errDefs.ErrUnauthorized), expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &mocks.AccountRepositoryMock{
+				RotateRefreshTokenFn: func(oldHash, newHash, clientId string, expiresAt time.Time) (repository.RotatedRefreshToken, error) {
+					assert.Equal(t, service.HashToken("old"), oldHash)
+					assert.Empty(t, clientId)
+					assert.NotEqual(t, oldHash, newHash)
+					return repository.RotatedRefreshToken{Username: "valid", Role: types.UserRole, Family: "family"}, tc.rotateErr
+				},
+			}
+			handler := ginPages.NewAccountHandler(repo).RefreshTokenHandler()
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/token/refresh", strings.NewReader(tc.payload))
+			c.Request.Header.Set("Content-Type", "application/json")
+			handler(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var tokens types.TokenPair
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+				assert.NotEmpty(t, tokens.AccessToken)
+				assert.NotEqual(t, "old", tokens.RefreshToken)
+			}
+		})
+	}
 }
 
 func TestPostAccountHandler(t *testing.T) {
@@ -117,9 +179,9 @@ func TestPostAccountHandler(t *testing.T) {
 					return nil
 				},
 			},
-			inputPayload:    `{"username":"TESTER","password":"WORKING_PASSWORD","samePassword":"WORKING_PASSWORD","role":"Admin"}`,
+			inputPayload:    `{"username":"TESTER","password":"WORKING_PASSWORD","samePassword":"WORKING_PASSWORD","role":"User"}`,
 			expectedStatus:  http.StatusCreated,
-			expectedPayload: `{"username":"TESTER","password":"WORKING_PASSWORD","samePassword":"WORKING_PASSWORD","role":"Admin"}`,
+			expectedPayload: `{"username":"TESTER","password":"WORKING_PASSWORD","samePassword":"WORKING_PASSWORD","role":"User"}`,
 		},
 		{
 			name: "Success with default role",
@@ -132,6 +194,28 @@ func TestPostAccountHandler(t *testing.T) {
 			expectedStatus:  http.StatusCreated,
 			expectedPayload: `{"username":"TESTER","password":"WORKING_PASSWORD","samePassword":"WORKING_PASSWORD","role":"User"}`,
 		},
+		{
+			name: "Fail - self-assigned role",
+			repo: &mocks.AccountRepositoryMock{
+				SaveAccountFn: func(data *types.AccountPostData) error {
+					return nil
+				},
+			},
+			inputPayload:    `{"username":"TESTER","password":"WORKING_PASSWORD","samePassword":"WORKING_PASSWORD","role":"BookKeeper"}`,
+			expectedStatus:  http.StatusForbidden,
+			expectedPayload: `{"Error":"forbidden: role BookKeeper can only be granted by an invite or an admin"}`,
+		},
+		{
+			name: "Fail - self-assigned Admin",
+			repo: &mocks.AccountRepositoryMock{
+				SaveAccountFn: func(data *types.AccountPostData) error {
+					return nil
+				},
+			},
+			inputPayload:    `{"username":"TESTER","password":"WORKING_PASSWORD","samePassword":"WORKING_PASSWORD","role":"Admin"}`,
+			expectedStatus:  http.StatusForbidden,
+			expectedPayload: `{"Error":"forbidden: role Admin can only be granted by an invite or an admin"}`,
+		},
 		{
 			name: "Fail - bind error",
 			repo: &mocks.AccountRepositoryMock{
@@ -268,7 +352,7 @@ func TestPatchPromoteAccountHandler(t *testing.T) {
 				},
 			},
 			inputPayload:   `{"username":"PROMOTEÉ","role":"Admin"}`,
-			expectedStatus: http.StatusUnauthorized,
+			expectedStatus: http.StatusForbidden,
 			userRole:       "User",
 			confirmUserErr: nil,
 		},
@@ -314,6 +398,66 @@ func TestPatchPromoteAccountHandler(t *testing.T) {
 	}
 }
 
+// promoteRoles grants Moderator account.promote but none of BookKeeper's
+// permissions.
+func promoteRoles() *mocks.RoleRepositoryMock {
+	return &mocks.RoleRepositoryMock{
+		FindRolesFn: func() ([]types.RoleInfo, error) {
+			return []types.RoleInfo{
+				{Name: types.UserRole, Permissions: []string{}},
+				{Name: types.BookKeeperRole, Permissions: []string{types.BookManagePermission, types.SubjectManagePermission}},
+				{Name: types.AdminRole, Permissions: []string{}},
+				{Name: "Moderator", Permissions: []string{types.AccountPromotePermission, types.ReviewModeratePermission}},
+			}, nil
+		},
+	}
+}
+
+func TestPromoteEscalation(t *testing.T) {
+	roles := map[string]types.Role{"mod": "Moderator", "root": types.AdminRole, "john": types.UserRole, "boss": types.AdminRole}
+
+	testCases := []struct {
+		name           string
+		caller         string
+		inputPayload   string
+		expectedStatus int
+	}{
+		{name: "Success - covered role", caller: "mod", inputPayload: `{"username":"john","role":"User"}`, expectedStatus: http.StatusOK},
+		{name: "Success - Admin assigns Admin", caller: "root", inputPayload: `{"username":"john","role":"Admin"}`, expectedStatus: http.StatusOK},
+		{name: "Fail - assigns Admin", caller: "mod", inputPayload: `{"username":"john","role":"Admin"}`, expectedStatus: http.StatusForbidden},
+		{name: "Fail - promotes self to Admin", caller: "mod", inputPayload: `{"username":"mod","role":"Admin"}`, expectedStatus: http.StatusForbidden},
+		{name: "Fail - role with permissions the caller lacks", caller: "mod", inputPayload: `{"username":"john","role":"BookKeeper"}`, expectedStatus: http.StatusForbidden},
+		{name: "Fail - demotes an Admin", caller: "mod", inputPayload: `{"username":"boss","role":"User"}`, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			promoted := false
+			ah := ginPages.NewAccountHandler(&mocks.AccountRepositoryMock{
+				ConfirmAccountFn: func(string, string) error { return nil },
+				FindUserRoleFn:   func(username string) (types.Role, error) { return roles[username], nil },
+				PromoteExistingAccountFn: func(data *types.AccountPatchPromoteData) error {
+					promoted = true
+					return nil
+				},
+			})
+			require.NoError(t, ah.UseRoles(promoteRoles()))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPatch, "/promote", bytes.NewBufferString(tc.inputPayload))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request.Header.Set("Username", tc.caller)
+			c.Request.Header.Set("Password", "VERIFICATION")
+
+			ah.PatchPromoteAccountHandler()(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedStatus == http.StatusOK, promoted)
+		})
+	}
+}
+
 func TestDeleteAccountHandler(t *testing.T) {
 	testCases := []struct {
 		name             string
@@ -0,0 +1,139 @@
+package go_gin_pages
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+const requiredScopeContextKey = "requiredScope"
+
+// requireScope declares the scope an API key needs for the route. Routes
+// without one refuse API keys.
+func requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(requiredScopeContextKey, scope)
+		c.Next()
+	}
+}
+
+// apiKeyFromHeader extracts the key of an "Authorization: ApiKey <key>" header.
+func apiKeyFromHeader(c *gin.Context) (string, bool) {
+	scheme, key, found := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "ApiKey") {
+		return "", false
+	}
+	return strings.TrimSpace(key), true
+}
+
+func (ah *accountHandler) apiKeyAuth(c *gin.Context, key string) (jwt.Claims, error) {
+	if ah.apiKeys == nil {
+		return jwt.Claims{}, fmt.Errorf("%w: API keys are not accepted", errDefs.ErrUnauthorized)
+	}
+	claims, err := ah.apiKeys.Authenticate(key)
+	if err != nil {
+		return jwt.Claims{}, err
+	}
+	if err := ah.checkSuspension(claims.Username); err != nil {
+		return jwt.Claims{}, err
+	}
+	if err := checkScope(c, claims, "API key"); err != nil {
+		return jwt.Claims{}, err
+	}
+	return claims, nil
+}
+
+// checkScope refuses a credential limited to scopes, named by what, unless
+// the route declares one of its scopes.
+func checkScope(c *gin.Context, claims jwt.Claims, what string) error {
+	scope := c.GetString(requiredScopeContextKey)
+	if scope == "" {
+		return fmt.Errorf("%w: endpoint does not accept %ss", errDefs.ErrForbidden, what)
+	}
+	if !slices.Contains(claims.Scopes, scope) {
+		return fmt.Errorf("%w: %s lacks scope %s", errDefs.ErrForbidden, what, scope)
+	}
+	return nil
+}
+
+func (ah *accountHandler) apiKeysEnabled(c *gin.Context) bool {
+	if ah.apiKeys == nil {
+		returnError(c, fmt.Errorf("%w: API keys are disabled", errDefs.ErrEntityNotFound))
+		return false
+	}
+	return true
+}
+
+func (ah *accountHandler) GetApiKeysHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ah.apiKeysEnabled(c) {
+			return
+		}
+		claims, err := ah.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		keys, err := ah.apiKeys.List(claims.Username)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, keys)
+	}
+}
+
+func (ah *accountHandler) PostApiKeyHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ah.apiKeysEnabled(c) {
+			return
+		}
+		claims, err := ah.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		var data types.ApiKeyPostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		created, err := ah.apiKeys.Create(claims.Username, data)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, created)
+	}
+}
+
+func (ah *accountHandler) DeleteApiKeyHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ah.apiKeysEnabled(c) {
+			return
+		}
+		claims, err := ah.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			returnError(c, fmt.Errorf("%w: parameter id needs to be a number", errDefs.ErrBadRequest))
+			return
+		}
+		if err := ah.apiKeys.Revoke(claims.Username, id); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, nil)
+	}
+}
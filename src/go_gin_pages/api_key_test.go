@@ -0,0 +1,66 @@
+package go_gin_pages_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ginPages "tick_test/go_gin_pages"
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+	"tick_test/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireScope(t *testing.T) {
+	testCases := []struct {
+		name            string
+		keyScopes       []string
+		routeScope      string
+		expectedStatus  int
+		expectedPayload string
+	}{
+		{name: "Success", keyScopes: []string{types.BooksReadScope, types.ShelvesReadScope}, routeScope: types.BooksReadScope, expectedStatus: http.StatusOK},
+		{name: "Fail - scope missing", keyScopes: []string{types.ShelvesReadScope}, routeScope: types.BooksReadScope, expectedStatus: http.StatusForbidden,
+			expectedPayload: `{"Error":"forbidden: API key lacks scope books:read"}`},
+		{name: "Fail - route without scope", keyScopes: []string{types.BooksReadScope}, expectedStatus: http.StatusForbidden,
+			expectedPayload: `{"Error":"forbidden: endpoint does not accept API keys"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ah := ginPages.NewAccountHandler(&mocks.AccountRepositoryMock{})
+			ah.UseApiKeys(service.NewApiKeyService(&mocks.ApiKeyRepositoryMock{
+				AuthenticateApiKeyFn: func(keyHash string, now time.Time) (string, types.Role, []string, error) {
+					assert.Equal(t, service.HashToken("tk_key"), keyHash)
+					return "john", types.UserRole, tc.keyScopes, nil
+				},
+			}))
+			handlers := []gin.HandlerFunc{func(c *gin.Context) {
+				if _, err := ah.ConfirmAccountFromGinContext(c); err != nil {
+					ginPages.ReturnError(c, err)
+					return
+				}
+				c.JSON(http.StatusOK, nil)
+			}}
+			if tc.routeScope != "" {
+				handlers = append([]gin.HandlerFunc{ginPages.RequireScope(tc.routeScope)}, handlers...)
+			}
+			engine := gin.New()
+			engine.GET("/scoped", handlers...)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/scoped", nil)
+			req.Header.Set("Authorization", "ApiKey tk_key")
+			engine.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedPayload != "" {
+				assert.JSONEq(t, tc.expectedPayload, w.Body.String())
+			}
+		})
+	}
+}
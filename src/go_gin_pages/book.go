@@ -6,26 +6,103 @@ import (
 	"net/http"
 	"strconv"
 	"tick_test/repository"
+	"tick_test/service"
 	"tick_test/types"
+	"tick_test/utils/codegen"
+	"tick_test/utils/dedup"
 	"tick_test/utils/errDefs"
-	"tick_test/utils/random"
 
 	"github.com/gin-gonic/gin"
 )
 
 type bookHandler struct {
 	repo           repository.BookRepository
+	duplicates     *service.DuplicateService
 	accountHandler *accountHandler
 }
 
 func NewBookHandler(bookRepo repository.BookRepository) (res *bookHandler) {
 	return &bookHandler{
-		repo: bookRepo,
+		repo:       bookRepo,
+		duplicates: service.NewDuplicateService(bookRepo),
 	}
 }
 
+// confirmed reports whether the caller overrode duplicate detection with ?confirm=true.
+func confirmed(c *gin.Context) bool {
+	confirm, _ := strconv.ParseBool(c.Query("confirm"))
+	return confirm
+}
+
+func normalizeBookIsbn(book *types.Book) error {
+	isbn, err := dedup.NormalizeISBN(book.Isbn)
+	if err != nil {
+		return fmt.Errorf("%w: %v %q", errDefs.ErrBadRequest, err, book.Isbn)
+	}
+	book.Isbn = isbn
+	return nil
+}
+
+// sortByRating reads the sort query the book lists share: empty for their
+// own order or "rating" for the highest average rating first.
+func sortByRating(c *gin.Context) (bool, error) {
+	switch c.Query("sort") {
+	case "":
+		return false, nil
+	case "rating":
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: unknown sort %q", errDefs.ErrBadRequest, c.Query("sort"))
+	}
+}
+
+// respondSubjectBooks lists the books in a subject and the subjects below it.
+func respondSubjectBooks(c *gin.Context, repo repository.BookRepository, subjectId int64) {
+	byRating, err := sortByRating(c)
+	if err != nil {
+		returnError(c, err)
+		return
+	}
+	var books any
+	if byRating {
+		books, err = repo.FindBooksInSubjectByRating(subjectId)
+	} else {
+		books, err = repo.FindBooksInSubject(subjectId)
+	}
+	if err != nil {
+		returnError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, books)
+}
+
 func (bh *bookHandler) GetAllBooksHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if subject := c.Query("subject"); subject != "" {
+			subjectId, err := strconv.ParseInt(subject, 10, 64)
+			if err != nil {
+				returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err))
+				return
+			}
+			respondSubjectBooks(c, bh.repo, subjectId)
+			return
+		}
+
+		byRating, err := sortByRating(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if byRating {
+			books, err := bh.repo.FindAllBooksByRating()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, books)
+			return
+		}
+
 		books, err := bh.repo.FindAllBooks()
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
@@ -55,8 +132,18 @@ func (bh *bookHandler) GetPaginatedBooksHandler() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": "pageSize must be greater than 0"})
 			return
 		}
+		byRating, err := sortByRating(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
 
-		books, err := bh.repo.FindPaginatedBooks(pageSize, pageNumber)
+		var books any
+		if byRating {
+			books, err = bh.repo.FindPaginatedBooksByRating(pageSize, pageNumber)
+		} else {
+			books, err = bh.repo.FindPaginatedBooks(pageSize, pageNumber)
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 			return
@@ -89,8 +176,6 @@ func (bh *bookHandler) PostBookHandler() gin.HandlerFunc {
 			return
 		}
 
-		book.Code = random.RandSeq(80)
-
 		if book.Title == "" {
 			c.JSON(http.StatusBadRequest, fmt.Errorf("%w; field Title", errDefs.ErrMissingField))
 			return
@@ -99,6 +184,32 @@ func (bh *bookHandler) PostBookHandler() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, fmt.Errorf("%w; field Author", errDefs.ErrMissingField))
 			return
 		}
+		if err := normalizeBookIsbn(&book); err != nil {
+			returnError(c, err)
+			return
+		}
+
+		if !confirmed(c) {
+			candidates, err := bh.duplicates.Candidates(book)
+			if err != nil {
+				returnError(c, err)
+				return
+			}
+			if len(candidates) > 0 {
+				c.JSON(http.StatusConflict, gin.H{
+					"Error":      "possible duplicate; repeat with ?confirm=true to create anyway",
+					"Candidates": candidates,
+				})
+				return
+			}
+		}
+
+		code, err := codegen.Generate(codegen.BookCodes)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		book.Code = code
 
 		if err := bh.repo.CreateBook(&book); err != nil {
 			c.JSON(errDefs.DetermineStatus(err), gin.H{"Error": "code already exists"})
@@ -109,6 +220,58 @@ func (bh *bookHandler) PostBookHandler() gin.HandlerFunc {
 	}
 }
 
+// PostImportBooksHandler creates a batch of books, reporting per entry
+// whether it was created or held back as a likely duplicate of an existing
+// book or of an earlier entry in the same batch.
+func (bh *bookHandler) PostImportBooksHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var books []types.Book
+		if err := c.ShouldBindJSON(&books); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err))
+			return
+		}
+
+		pool, err := bh.repo.FindAllBooks()
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		confirm := confirmed(c)
+		results := make([]types.BookImportResult, 0, len(books))
+		for _, book := range books {
+			result := types.BookImportResult{Book: book}
+			book.Code = ""
+			switch {
+			case book.Title == "":
+				result.Status, result.Error = types.ImportInvalid, fmt.Errorf("%w; field Title", errDefs.ErrMissingField).Error()
+			case book.Author == "":
+				result.Status, result.Error = types.ImportInvalid, fmt.Errorf("%w; field Author", errDefs.ErrMissingField).Error()
+			}
+			if result.Status == "" {
+				if err := normalizeBookIsbn(&book); err != nil {
+					result.Status, result.Error = types.ImportInvalid, err.Error()
+				}
+			}
+			if result.Status == "" && !confirm {
+				if result.Candidates = service.CandidatesIn(book, pool); len(result.Candidates) > 0 {
+					result.Status = types.ImportDuplicate
+				}
+			}
+			if result.Status == "" {
+				if err := bh.repo.CreateBook(&book); err != nil {
+					result.Status, result.Error = types.ImportFailed, err.Error()
+				} else {
+					result.Status, result.Book = types.ImportCreated, book
+					pool = append(pool, book)
+				}
+			}
+			results = append(results, result)
+		}
+		c.JSON(http.StatusOK, results)
+	}
+}
+
 func (bh *bookHandler) PatchBookHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		code := c.Param("code")
@@ -128,8 +291,12 @@ func (bh *bookHandler) PatchBookHandler() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 			return
 		}
+		if err := normalizeBookIsbn(&updates); err != nil {
+			returnError(c, err)
+			return
+		}
 
-		updatedBook, err := bh.repo.UpdateBookByCode(code, updates)
+		updatedBook, err := bh.repo.UpdateBookByCode(code, updates, claimsFromContext(c).Username)
 		if err != nil {
 			c.JSON(errDefs.DetermineStatus(err), gin.H{"Error": err.Error()})
 			return
@@ -156,45 +323,12 @@ func (bh *bookHandler) DeleteBookHandler() gin.HandlerFunc {
 	}
 }
 
-func (bh *bookHandler) RoleRequirer(handler gin.HandlerFunc, roles []types.Role) func(c *gin.Context) {
-	return func(c *gin.Context) {
-		claims, err := bh.accountHandler.ConfirmAccountFromGinContext(c)
-		if err != nil {
-			returnError(c, err)
-			c.Abort()
-			return
-		}
-
-		authorized := false
-		for _, role := range roles {
-			if role == claims.Role {
-				authorized = true
-				break
-			}
-		}
-
-		if !authorized {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":      "user does not have the required role",
-				"validRoles": roles,
-			})
-			c.Abort()
-			return
-		}
-
-		handler(c)
-	}
-}
-
-func (bh *bookHandler) requireBookKeeperRole(handler gin.HandlerFunc) gin.HandlerFunc {
-	return bh.RoleRequirer(handler, []types.Role{"Admin", "BookKeeper"})
-}
-
 func (bh *bookHandler) prepareBook(route *gin.RouterGroup) {
 	route.GET("/all", bh.GetAllBooksHandler())
 	route.GET("/", bh.GetPaginatedBooksHandler())
 	route.GET("/code/:code", bh.GetBookHandler())
-	route.POST("/create", bh.requireBookKeeperRole(bh.PostBookHandler()))
-	route.PATCH("/code/:code", bh.requireBookKeeperRole(bh.PatchBookHandler()))
-	route.DELETE("/code/:code", bh.requireBookKeeperRole(bh.DeleteBookHandler()))
+	route.POST("/create", requireScope(types.BooksWriteScope), bh.accountHandler.requirePermission(types.BookManagePermission), bh.PostBookHandler())
+	route.POST("/import", requireScope(types.BooksWriteScope), bh.accountHandler.requirePermission(types.BookManagePermission), bh.PostImportBooksHandler())
+	route.PATCH("/code/:code", requireScope(types.BooksWriteScope), bh.accountHandler.requirePermission(types.BookManagePermission), bh.PatchBookHandler())
+	route.DELETE("/code/:code", requireScope(types.BooksWriteScope), bh.accountHandler.requirePermission(types.BookManagePermission), bh.DeleteBookHandler())
 }
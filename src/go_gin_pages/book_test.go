@@ -71,6 +71,7 @@ func TestGetPaginatedBooksHandler(t *testing.T) {
 		repo            *mocks.BookRepositoryMock
 		pageSize        string
 		pageNumber      string
+		sort            string
 		expectedStatus  int
 		expectedPayload string
 	}{
@@ -89,6 +90,32 @@ func TestGetPaginatedBooksHandler(t *testing.T) {
 			expectedStatus:  http.StatusOK,
 			expectedPayload: `[{"code":"CODE_ZERO","title":"BOOK","author":"WRITER"},{"code":"CODE_ONE","title":"MEDIA","author":"AUTHOR"}]`,
 		},
+		{
+			name:       "Success - sorted by rating",
+			pageSize:   "1",
+			pageNumber: "2",
+			sort:       "rating",
+			repo: &mocks.BookRepositoryMock{
+				FindPaginatedBooksByRatingFn: func(pageSize, pageNumber int) ([]types.RatedBook, error) {
+					assert.Equal(t, 1, pageSize)
+					assert.Equal(t, 2, pageNumber)
+					return []types.RatedBook{
+						{Book: types.Book{Code: "CODE_ONE", Title: "MEDIA", Author: "AUTHOR"}, AverageRating: 4.5, RatingCount: 2},
+					}, nil
+				},
+			},
+			expectedStatus:  http.StatusOK,
+			expectedPayload: `[{"code":"CODE_ONE","title":"MEDIA","author":"AUTHOR","averageRating":4.5,"ratingCount":2}]`,
+		},
+		{
+			name:            "Fail - unknown sort",
+			pageSize:        "1",
+			pageNumber:      "1",
+			sort:            "title",
+			repo:            &mocks.BookRepositoryMock{},
+			expectedStatus:  http.StatusBadRequest,
+			expectedPayload: `{"Error":"bad request: unknown sort \"title\""}`,
+		},
 		{
 			name:       "Fail - invalid page size",
 			pageSize:   "invalid",
@@ -152,6 +179,9 @@ func TestGetPaginatedBooksHandler(t *testing.T) {
 			q := c.Request.URL.Query()
 			q.Add("pageSize", tc.pageSize)
 			q.Add("pageNumber", tc.pageNumber)
+			if tc.sort != "" {
+				q.Add("sort", tc.sort)
+			}
 			c.Request.URL.RawQuery = q.Encode()
 
 			handler(c)
@@ -214,6 +244,7 @@ func TestPostBookHandler(t *testing.T) {
 	testCases := []struct {
 		name            string
 		repo            *mocks.BookRepositoryMock
+		query           string
 		inputPayload    string
 		expectedStatus  int
 		expectedPayload string
@@ -221,6 +252,9 @@ func TestPostBookHandler(t *testing.T) {
 		{
 			name: "Success",
 			repo: &mocks.BookRepositoryMock{
+				FindAllBooksFn: func() ([]types.Book, error) {
+					return []types.Book{}, nil
+				},
 				CreateBookFn: func(book *types.Book) error {
 					return nil
 				},
@@ -259,6 +293,9 @@ func TestPostBookHandler(t *testing.T) {
 		{
 			name: "Fail - CreateBook error",
 			repo: &mocks.BookRepositoryMock{
+				FindAllBooksFn: func() ([]types.Book, error) {
+					return []types.Book{}, nil
+				},
 				CreateBookFn: func(book *types.Book) error {
 					return errDefs.ErrConflict
 				},
@@ -267,6 +304,36 @@ func TestPostBookHandler(t *testing.T) {
 			expectedStatus:  http.StatusConflict,
 			expectedPayload: `{"Error":"code already exists"}`,
 		},
+		{
+			name: "Fail - Invalid ISBN",
+			repo: &mocks.BookRepositoryMock{
+				CreateBookFn: func(book *types.Book) error { return nil },
+			},
+			inputPayload:   `{"title":"BOOK","author":"WRITER","isbn":"978-3-16-148410-1"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail - Possible duplicate",
+			repo: &mocks.BookRepositoryMock{
+				FindAllBooksFn: func() ([]types.Book, error) {
+					return []types.Book{{Code: "EXISTING", Title: "Les Misérables", Author: "Victor Hugo"}}, nil
+				},
+				CreateBookFn: func(book *types.Book) error { return nil },
+			},
+			inputPayload:    `{"title":"les miserables","author":"Victor Hugo"}`,
+			expectedStatus:  http.StatusConflict,
+			expectedPayload: `{"Error":"possible duplicate; repeat with ?confirm=true to create anyway","Candidates":[{"code":"EXISTING","title":"Les Misérables","author":"Victor Hugo","score":1,"reason":"similar title and author"}]}`,
+		},
+		{
+			name: "Success - Duplicate confirmed",
+			repo: &mocks.BookRepositoryMock{
+				CreateBookFn: func(book *types.Book) error { return nil },
+			},
+			query:           "?confirm=true",
+			inputPayload:    `{"title":"les miserables","author":"Victor Hugo","isbn":"0-306-40615-2"}`,
+			expectedStatus:  http.StatusCreated,
+			expectedPayload: `{"title":"les miserables","author":"Victor Hugo","isbn":"9780306406157"}`,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -276,7 +343,7 @@ func TestPostBookHandler(t *testing.T) {
 
 			w := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(w)
-			c.Request = httptest.NewRequest(http.MethodPost, "/books", bytes.NewBufferString(tc.inputPayload))
+			c.Request = httptest.NewRequest(http.MethodPost, "/books"+tc.query, bytes.NewBufferString(tc.inputPayload))
 			c.Request.Header.Set("Content-Type", "application/json")
 			handler(c)
 
@@ -291,6 +358,7 @@ func TestPostBookHandler(t *testing.T) {
 					assert.NoError(t, errActual)
 					assert.Equal(t, expected.Title, actual.Title)
 					assert.Equal(t, expected.Author, actual.Author)
+					assert.Equal(t, expected.Isbn, actual.Isbn)
 					assert.NotEmpty(t, actual.Code)
 				} else {
 					assert.JSONEq(t, tc.expectedPayload, w.Body.String())
@@ -316,7 +384,7 @@ func TestPatchBookHandler(t *testing.T) {
 				FindBookByCodeFn: func(code string) (types.Book, error) {
 					return types.Book{Code: code, Title: "Old Title", Author: "Old Author"}, nil
 				},
-				UpdateBookByCodeFn: func(code string, updates types.Book) (types.Book, error) {
+				UpdateBookByCodeFn: func(code string, updates types.Book, editor string) (types.Book, error) {
 					updatedBook := types.Book{Code: code, Title: updates.Title, Author: updates.Author}
 					return updatedBook, nil
 				},
@@ -332,7 +400,7 @@ func TestPatchBookHandler(t *testing.T) {
 				FindBookByCodeFn: func(code string) (types.Book, error) {
 					return types.Book{}, errors.New("sql: no rows in result set")
 				},
-				UpdateBookByCodeFn: func(code string, updates types.Book) (types.Book, error) {
+				UpdateBookByCodeFn: func(code string, updates types.Book, editor string) (types.Book, error) {
 					return types.Book{}, nil
 				},
 			},
@@ -347,7 +415,7 @@ func TestPatchBookHandler(t *testing.T) {
 				FindBookByCodeFn: func(code string) (types.Book, error) {
 					return types.Book{Code: code, Title: "Old Title", Author: "Old Author"}, nil
 				},
-				UpdateBookByCodeFn: func(code string, updates types.Book) (types.Book, error) {
+				UpdateBookByCodeFn: func(code string, updates types.Book, editor string) (types.Book, error) {
 					return types.Book{}, nil
 				},
 			},
@@ -361,7 +429,7 @@ func TestPatchBookHandler(t *testing.T) {
 				FindBookByCodeFn: func(code string) (types.Book, error) {
 					return types.Book{Code: code, Title: "Old Title", Author: "Old Author"}, nil
 				},
-				UpdateBookByCodeFn: func(code string, updates types.Book) (types.Book, error) {
+				UpdateBookByCodeFn: func(code string, updates types.Book, editor string) (types.Book, error) {
 					return types.Book{}, errors.New("DB error")
 				},
 			},
@@ -0,0 +1,240 @@
+package go_gin_pages
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"time"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/blobstore"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/imaging"
+
+	"github.com/gin-gonic/gin"
+)
+
+const coverOriginalSize = "original"
+const coverMaxPixels = 40_000_000
+
+// uploadOverhead is what multipart boundaries and headers may add to an
+// upload on top of the image itself.
+const uploadOverhead = 64 << 10
+
+var coverThumbnailWidths = map[string]int{
+	"small":  96,
+	"medium": 240,
+	"large":  480,
+}
+
+var coverFormats = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+}
+
+type coverHandler struct {
+	repo           repository.BookRepository
+	store          blobstore.Store
+	maxSize        int64
+	accountHandler *accountHandler
+}
+
+func NewCoverHandler(bookRepo repository.BookRepository, store blobstore.Store, maxSize int64) (res *coverHandler) {
+	return &coverHandler{
+		repo:    bookRepo,
+		store:   store,
+		maxSize: maxSize,
+	}
+}
+
+func coverKey(code string, size string) string {
+	return "covers/" + code + "/" + size
+}
+
+func storeError(err error) error {
+	switch {
+	case errors.Is(err, blobstore.ErrNotFound):
+		return fmt.Errorf("%w: %v", errDefs.ErrEntityNotFound, err)
+	case errors.Is(err, blobstore.ErrInvalidKey):
+		return fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err)
+	default:
+		return err
+	}
+}
+
+// readImageUpload reads a JPEG or PNG image from the multipart form field of
+// the same name or else from the raw request body.
+func readImageUpload(c *gin.Context, field string, maxSize int64) (data []byte, format string, img image.Image, err error) {
+	// keeps the multipart parser from spooling oversized uploads to disk
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+uploadOverhead)
+	tooLarge := fmt.Errorf("%w: %s must not exceed %d bytes", errDefs.ErrPayloadTooLarge, field, maxSize)
+
+	var body io.Reader = c.Request.Body
+	file, _, err := c.Request.FormFile(field)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return nil, "", nil, tooLarge
+	}
+	if err == nil {
+		defer file.Close()
+		body = file
+	}
+
+	data, err = io.ReadAll(io.LimitReader(body, maxSize+1))
+	if errors.As(err, &maxBytesErr) {
+		return nil, "", nil, tooLarge
+	}
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, "", nil, tooLarge
+	}
+	if len(data) == 0 {
+		return nil, "", nil, fmt.Errorf("%w; field %s", errDefs.ErrMissingField, field)
+	}
+
+	contentType := http.DetectContentType(data)
+	format, ok := coverFormats[contentType]
+	if !ok {
+		return nil, "", nil, fmt.Errorf("%w: %s must be JPEG or PNG, got %s", errDefs.ErrUnsupportedMediaType, field, contentType)
+	}
+	imgConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err)
+	}
+	if imgConfig.Width*imgConfig.Height > coverMaxPixels {
+		return nil, "", nil, fmt.Errorf("%w: %s dimensions are too large", errDefs.ErrPayloadTooLarge, field)
+	}
+	img, _, err = image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err)
+	}
+	return data, format, img, nil
+}
+
+func (ch *coverHandler) PostCoverHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		code := c.Param("code")
+		if _, err := ch.repo.FindBookByCode(code); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = fmt.Errorf("%w: book not found", errDefs.ErrEntityNotFound)
+			}
+			returnError(c, err)
+			return
+		}
+
+		data, format, img, err := readImageUpload(c, "cover", ch.maxSize)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		if err := ch.store.Put(coverKey(code, coverOriginalSize), data); err != nil {
+			returnError(c, storeError(err))
+			return
+		}
+		sizes := []string{coverOriginalSize}
+		for size, width := range coverThumbnailWidths {
+			thumbnail, err := imaging.Encode(imaging.Thumbnail(img, width), format)
+			if err != nil {
+				returnError(c, err)
+				return
+			}
+			if err := ch.store.Put(coverKey(code, size), thumbnail); err != nil {
+				returnError(c, storeError(err))
+				return
+			}
+			sizes = append(sizes, size)
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"code":        code,
+			"contentType": http.DetectContentType(data),
+			"sizes":       sizes,
+		})
+	}
+}
+
+func (ch *coverHandler) GetCoverHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		size := c.DefaultQuery("size", coverOriginalSize)
+		if _, ok := coverThumbnailWidths[size]; !ok && size != coverOriginalSize {
+			returnError(c, fmt.Errorf("%w: unknown size %q", errDefs.ErrBadRequest, size))
+			return
+		}
+
+		data, modTime, err := ch.store.Get(coverKey(c.Param("code"), size))
+		if err != nil {
+			returnError(c, storeError(err))
+			return
+		}
+		serveImage(c, data, modTime, "public, max-age=86400")
+	}
+}
+
+// serveImage answers with data, or with 304 when the client's copy is current.
+func serveImage(c *gin.Context, data []byte, modTime time.Time, cacheControl string) {
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("Cache-Control", cacheControl)
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+
+	if c.GetHeader("If-None-Match") == etag {
+		c.AbortWithStatus(http.StatusNotModified)
+		return
+	}
+	if since, err := time.Parse(http.TimeFormat, c.GetHeader("If-Modified-Since")); err == nil && c.GetHeader("If-None-Match") == "" {
+		if !modTime.Truncate(time.Second).After(since) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return
+		}
+	}
+
+	contentType := http.DetectContentType(data)
+	c.Header("Content-Type", contentType)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+func (ch *coverHandler) DeleteCoverHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := deleteCovers(ch.store, c.Param("code")); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, nil)
+	}
+}
+
+func deleteCovers(store blobstore.Store, code string) error {
+	for _, size := range append([]string{coverOriginalSize}, mapKeys(coverThumbnailWidths)...) {
+		if err := store.Delete(coverKey(code, size)); err != nil {
+			return storeError(err)
+		}
+	}
+	return nil
+}
+
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (ch *coverHandler) prepareCover(route *gin.RouterGroup) {
+	route.GET("/cover", ch.GetCoverHandler())
+	route.POST("/cover", requireScope(types.BooksWriteScope), ch.accountHandler.requirePermission(types.BookManagePermission), ch.PostCoverHandler())
+	route.DELETE("/cover", requireScope(types.BooksWriteScope), ch.accountHandler.requirePermission(types.BookManagePermission), ch.DeleteCoverHandler())
+}
@@ -0,0 +1,182 @@
+package go_gin_pages_test
+
+import (
+	"bytes"
+	"database/sql"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tick_test/go_gin_pages"
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		for y := range height {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestPostCoverHandler(t *testing.T) {
+	existingBook := func(code string) (types.Book, error) {
+		return types.Book{Code: code, Title: "BOOK", Author: "WRITER"}, nil
+	}
+
+	testCases := []struct {
+		name           string
+		repo           *mocks.BookRepositoryMock
+		body           []byte
+		maxSize        int64
+		expectedStatus int
+		expectedBlobs  int
+	}{
+		{
+			name:           "Success",
+			repo:           &mocks.BookRepositoryMock{FindBookByCodeFn: existingBook},
+			body:           encodeTestPNG(t, 600, 900),
+			maxSize:        1 << 20,
+			expectedStatus: http.StatusCreated,
+			expectedBlobs:  4,
+		},
+		{
+			name:           "Fail - not an image",
+			repo:           &mocks.BookRepositoryMock{FindBookByCodeFn: existingBook},
+			body:           []byte("GIF89a definitely not a cover"),
+			maxSize:        1 << 20,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "Fail - too large",
+			repo:           &mocks.BookRepositoryMock{FindBookByCodeFn: existingBook},
+			body:           encodeTestPNG(t, 600, 900),
+			maxSize:        64,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "Fail - book not found",
+			repo: &mocks.BookRepositoryMock{
+				FindBookByCodeFn: func(string) (types.Book, error) { return types.Book{}, errDefs.ErrEntityNotFound },
+			},
+			body:           encodeTestPNG(t, 10, 10),
+			maxSize:        1 << 20,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Fail - book not found in database",
+			repo: &mocks.BookRepositoryMock{
+				FindBookByCodeFn: func(string) (types.Book, error) { return types.Book{}, sql.ErrNoRows },
+			},
+			body:           encodeTestPNG(t, 10, 10),
+			maxSize:        1 << 20,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Fail - database offline",
+			repo: &mocks.BookRepositoryMock{
+				FindBookByCodeFn: func(string) (types.Book, error) { return types.Book{}, errDefs.ErrDatabaseOffline },
+			},
+			body:           encodeTestPNG(t, 10, 10),
+			maxSize:        1 << 20,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &mocks.BlobStoreMock{}
+			handler := go_gin_pages.NewCoverHandler(tc.repo, store, tc.maxSize).PostCoverHandler()
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = []gin.Param{{Key: "code", Value: "CODE"}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/books/code/CODE/cover", bytes.NewReader(tc.body))
+			handler(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Len(t, store.Blobs, tc.expectedBlobs)
+		})
+	}
+}
+
+func TestPostCoverHandlerMultipartTooLarge(t *testing.T) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("cover", "cover.png")
+	assert.NoError(t, err)
+	_, err = part.Write(make([]byte, 256<<10))
+	assert.NoError(t, err)
+	assert.NoError(t, form.Close())
+
+	store := &mocks.BlobStoreMock{}
+	repo := &mocks.BookRepositoryMock{
+		FindBookByCodeFn: func(code string) (types.Book, error) { return types.Book{Code: code}, nil },
+	}
+	handler := go_gin_pages.NewCoverHandler(repo, store, 1024).PostCoverHandler()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = []gin.Param{{Key: "code", Value: "CODE"}}
+	size := body.Len()
+	c.Request = httptest.NewRequest(http.MethodPost, "/books/code/CODE/cover", &body)
+	c.Request.Header.Set("Content-Type", form.FormDataContentType())
+	handler(c)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Empty(t, store.Blobs)
+	assert.Greater(t, body.Len(), size/2, "the upload is refused before it is read in full")
+}
+
+func TestGetCoverHandler(t *testing.T) {
+	cover := encodeTestPNG(t, 4, 4)
+	store := &mocks.BlobStoreMock{
+		Blobs:   map[string][]byte{"covers/CODE/small": cover},
+		ModTime: time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC),
+	}
+	handler := go_gin_pages.NewCoverHandler(&mocks.BookRepositoryMock{}, store, 1<<20).GetCoverHandler()
+
+	request := func(size string, header http.Header) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = []gin.Param{{Key: "code", Value: "CODE"}}
+		c.Request = httptest.NewRequest(http.MethodGet, "/books/code/CODE/cover?size="+size, nil)
+		for key, values := range header {
+			c.Request.Header[key] = values
+		}
+		handler(c)
+		return w
+	}
+
+	w := request("small", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=86400", w.Header().Get("Cache-Control"))
+	assert.Equal(t, cover, w.Body.Bytes())
+
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	w = request("small", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = request("large", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = request("huge", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
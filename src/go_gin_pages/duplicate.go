@@ -0,0 +1,66 @@
+package go_gin_pages
+
+import (
+	"fmt"
+	"net/http"
+
+	"tick_test/repository"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/blobstore"
+	"tick_test/utils/errDefs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type duplicateHandler struct {
+	repo           repository.DuplicateRepository
+	duplicates     *service.DuplicateService
+	coverStore     blobstore.Store
+	accountHandler *accountHandler
+}
+
+func NewDuplicateHandler(duplicateRepo repository.DuplicateRepository, bookRepo repository.BookRepository, coverStore blobstore.Store) (res *duplicateHandler) {
+	return &duplicateHandler{
+		repo:       duplicateRepo,
+		duplicates: service.NewDuplicateService(bookRepo),
+		coverStore: coverStore,
+	}
+}
+
+func (dh *duplicateHandler) getClustersHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clusters, err := dh.duplicates.Clusters()
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, clusters)
+	}
+}
+
+func (dh *duplicateHandler) postMergeHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var data types.BookMergePostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		if err := dh.repo.MergeBooks(data.Target, data.Sources); err != nil {
+			returnError(c, err)
+			return
+		}
+		for _, source := range data.Sources {
+			if err := deleteCovers(dh.coverStore, source); err != nil {
+				logrus.Error("deleting cover of merged book ", source, ": ", err)
+			}
+		}
+		c.JSON(http.StatusAccepted, nil)
+	}
+}
+
+func (dh *duplicateHandler) prepareDuplicate(route *gin.RouterGroup) {
+	route.GET("", requireScope(types.AdminScope), dh.accountHandler.requirePermission(types.BookMergePermission), dh.getClustersHandler())
+	route.POST("/merge", requireScope(types.AdminScope), dh.accountHandler.requirePermission(types.BookMergePermission), dh.postMergeHandler())
+}
@@ -0,0 +1,65 @@
+package go_gin_pages
+
+import (
+	"tick_test/repository"
+	"tick_test/service"
+	"tick_test/types"
+)
+
+// The handler tests in go_gin_pages_test use these to wire up the services
+// Prepare would otherwise set.
+
+type AccountHandler = accountHandler
+
+func (ah *accountHandler) UseSessions(sessions *service.SessionService) {
+	ah.mode = types.SessionAuthMode
+	ah.sessions = sessions
+}
+
+func (ah *accountHandler) UseThrottle(throttle *service.LoginThrottle) {
+	ah.throttle = throttle
+}
+
+func (ah *accountHandler) UseTwoFactor(twoFactor *service.TwoFactorService) {
+	ah.twoFactor = twoFactor
+}
+
+func (ah *accountHandler) UseApiKeys(apiKeys *service.ApiKeyService) {
+	ah.apiKeys = apiKeys
+}
+
+func (ah *accountHandler) UseSuspensions(suspensions *service.SuspensionStore) {
+	ah.suspensions = suspensions
+}
+
+func (ah *accountHandler) UseResets(resets *service.PasswordResetService) {
+	ah.resets = resets
+}
+
+func (ah *accountHandler) UseRevocations(revocations *service.RevocationStore) {
+	ah.revocations = revocations
+}
+
+func (ah *accountHandler) UseLoginHistory(logins *service.LoginHistory) {
+	ah.logins = logins
+}
+
+func (ah *accountHandler) UseOAuth(oauth *service.OAuthService) {
+	ah.oauth = oauth
+}
+
+func (ah *accountHandler) UseRegistration(repo repository.RegistrationRepository) {
+	ah.registration.Repo = repo
+}
+
+func (ah *accountHandler) UseRoles(roles repository.RoleRepository) error {
+	ah.authz.Repo = roles
+	return ah.authz.Load()
+}
+
+var (
+	ConfigureRegistration = configureRegistration
+	ConfigureSigning      = configureSigning
+	RequireScope          = requireScope
+	ReturnError           = returnError
+)
@@ -1,27 +1,59 @@
 package go_gin_pages
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"tick_test/internal/config"
 	"tick_test/repository"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/blobstore"
 	"tick_test/utils/errDefs"
+	"tick_test/utils/jwt"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 const urlFile = "../.config/url.txt"
 
+const revocationCleanupInterval = 10 * time.Minute
+const loginThrottleCleanupInterval = 10 * time.Minute
+const twoFactorCleanupInterval = time.Minute
+const suspensionReloadInterval = time.Minute
+const signingKeyRefreshInterval = time.Minute
+const loginHistoryCleanupInterval = time.Hour
+const sessionCleanupInterval = 10 * time.Minute
+
 func returnError(c *gin.Context, err error) {
+	var violationsErr *errDefs.ViolationsError
+	if errors.As(err, &violationsErr) {
+		c.JSON(errDefs.DetermineStatus(err), gin.H{"Error": err.Error(), "Violations": violationsErr.Violations})
+		return
+	}
+	setRetryAfter(c, err)
 	c.JSON(errDefs.DetermineStatus(err), gin.H{"Error": err.Error()})
 }
 
+// setRetryAfter tells the client in seconds when a request rejected with a
+// RetryAfterError may be repeated.
+func setRetryAfter(c *gin.Context, err error) {
+	var retryErr *errDefs.RetryAfterError
+	if errors.As(err, &retryErr) {
+		seconds := int64(math.Ceil(retryErr.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+	}
+}
+
 func index(c *gin.Context) {
 	if err := repository.LoadIteration(); err != nil {
 		c.JSON(
@@ -65,7 +97,170 @@ func UseConfigToDetermineURL(cfg *config.Config) (url string) {
 	return net.JoinHostPort(cfg.BaseURL, cfg.Port)
 }
 
-func Prepare(engine *gin.Engine, url string, repo repository.Repository) {
+func configureAuth(ah *accountHandler, cfg config.AuthConfig) error {
+	switch mode := types.AuthMode(cfg.Mode); mode {
+	case types.JwtAuthMode, types.SessionAuthMode:
+		ah.mode = mode
+	default:
+		return fmt.Errorf("auth.mode: unknown mode %q", cfg.Mode)
+	}
+
+	access, err := types.ParseISO8601Duration(cfg.AccessTokenLifetime, time.Minute)
+	if err != nil {
+		return fmt.Errorf("auth.accessTokenLifetime: %w", err)
+	}
+	refresh, err := types.ParseISO8601Duration(cfg.RefreshTokenLifetime, access)
+	if err != nil {
+		return fmt.Errorf("auth.refreshTokenLifetime: %w", err)
+	}
+	ah.tokens.AccessLifetime, ah.tokens.RefreshLifetime = access, refresh
+	ah.oauth.AccessLifetime = access
+
+	idle, err := types.ParseISO8601Duration(cfg.SessionIdleTimeout, time.Minute)
+	if err != nil {
+		return fmt.Errorf("auth.sessionIdleTimeout: %w", err)
+	}
+	maxLifetime, err := types.ParseISO8601Duration(cfg.SessionMaxLifetime, idle)
+	if err != nil {
+		return fmt.Errorf("auth.sessionMaxLifetime: %w", err)
+	}
+	ah.sessions.IdleTimeout, ah.sessions.MaxLifetime = idle, maxLifetime
+
+	lockout := cfg.Lockout
+	baseDelay, err := types.ParseISO8601Duration(lockout.BaseDelay, 0)
+	if err != nil {
+		return fmt.Errorf("auth.lockout.baseDelay: %w", err)
+	}
+	maxDelay, err := types.ParseISO8601Duration(lockout.MaxDelay, baseDelay)
+	if err != nil {
+		return fmt.Errorf("auth.lockout.maxDelay: %w", err)
+	}
+	lockoutDuration, err := types.ParseISO8601Duration(lockout.Duration, 0)
+	if err != nil {
+		return fmt.Errorf("auth.lockout.duration: %w", err)
+	}
+	ah.throttle.MaxUserFailures, ah.throttle.MaxIPFailures = lockout.MaxUserFailures, lockout.MaxIPFailures
+	ah.throttle.BaseDelay, ah.throttle.MaxDelay, ah.throttle.LockoutDuration = baseDelay, maxDelay, lockoutDuration
+
+	challenge, err := types.ParseISO8601Duration(cfg.TwoFactor.ChallengeLifetime, time.Minute)
+	if err != nil {
+		return fmt.Errorf("auth.twoFactor.challengeLifetime: %w", err)
+	}
+	ah.twoFactor.ChallengeLifetime = challenge
+	if cfg.TwoFactor.Issuer != "" {
+		ah.twoFactor.Issuer = cfg.TwoFactor.Issuer
+	}
+	ah.twoFactor.EnforcedRoles = nil
+	for _, role := range cfg.TwoFactor.RequiredForRoles {
+		if !ah.authz.RoleExists(types.Role(role)) {
+			return fmt.Errorf("auth.twoFactor.requiredForRoles: no role %q", role)
+		}
+		ah.twoFactor.EnforcedRoles = append(ah.twoFactor.EnforcedRoles, types.Role(role))
+	}
+
+	resetLifetime, err := types.ParseISO8601Duration(cfg.PasswordReset.TokenLifetime, time.Minute)
+	if err != nil {
+		return fmt.Errorf("auth.passwordReset.tokenLifetime: %w", err)
+	}
+	cooldown, err := types.ParseISO8601Duration(cfg.PasswordReset.RequestCooldown, 0)
+	if err != nil {
+		return fmt.Errorf("auth.passwordReset.requestCooldown: %w", err)
+	}
+	ah.resets.Lifetime, ah.resets.RequestCooldown = resetLifetime, cooldown
+
+	retention, err := types.ParseISO8601Duration(cfg.LoginHistory.Retention, time.Hour)
+	if err != nil {
+		return fmt.Errorf("auth.loginHistory.retention: %w", err)
+	}
+	ah.logins.Retention = retention
+	return nil
+}
+
+func configureRegistration(ah *accountHandler, cfg config.RegistrationConfig) error {
+	switch mode := types.RegistrationMode(cfg.Mode); mode {
+	case types.OpenRegistration, types.InviteRegistration, types.ApprovalRegistration:
+		ah.registration.Mode = mode
+	default:
+		return fmt.Errorf("registration.mode: unknown mode %q", cfg.Mode)
+	}
+	lifetime, err := types.ParseISO8601Duration(cfg.InviteLifetime, time.Minute)
+	if err != nil {
+		return fmt.Errorf("registration.inviteLifetime: %w", err)
+	}
+	ah.registration.InviteLifetime = lifetime
+	return nil
+}
+
+// configureSigning returns the rotator of the signing keys, or nil when
+// tokens are signed with the shared secret.
+func configureSigning(cfg config.SigningConfig, accessLifetime time.Duration) (*service.SigningKeyRotator, error) {
+	switch cfg.Algorithm {
+	case jwt.HS256Algorithm:
+		return nil, nil
+	case jwt.RS256Algorithm, jwt.EdDSAAlgorithm:
+	default:
+		return nil, fmt.Errorf("auth.signing.algorithm: unknown algorithm %q", cfg.Algorithm)
+	}
+	rotator := service.NewSigningKeyRotator(cfg.KeyDir, cfg.Algorithm)
+	rotator.RotationInterval = 0
+	if cfg.RotationInterval != "" {
+		interval, err := types.ParseISO8601Duration(cfg.RotationInterval, time.Hour)
+		if err != nil {
+			return nil, fmt.Errorf("auth.signing.rotationInterval: %w", err)
+		}
+		rotator.RotationInterval = interval
+	}
+	retired, err := types.ParseISO8601Duration(cfg.RetiredKeyLifetime, 0)
+	if err != nil {
+		return nil, fmt.Errorf("auth.signing.retiredKeyLifetime: %w", err)
+	}
+	// tokens signed just before a rotation stay valid for a whole lifetime
+	if retired < accessLifetime {
+		return nil, fmt.Errorf("auth.signing.retiredKeyLifetime: %s is shorter than the access token lifetime %s", retired, accessLifetime)
+	}
+	rotator.RetiredKeyLifetime = retired
+	return rotator, nil
+}
+
+// prepareRepository creates the tables and applies the policies the
+// repository hashes and checks passwords and erases accounts with.
+func prepareRepository(repo repository.Repository, cfg *config.Config) error {
+	repo.DoPostgresPreparation()
+	passwordPolicy, err := service.NewPasswordPolicy(cfg.PasswordPolicy)
+	if err != nil {
+		return err
+	}
+	repository.SetPasswordPolicy(passwordPolicy)
+	passwordHasher, err := service.NewPasswordHasher(cfg.PasswordHashing)
+	if err != nil {
+		return err
+	}
+	repository.SetPasswordHasher(passwordHasher)
+	erasurePolicy, err := service.NewErasurePolicy(cfg.Erasure)
+	if err != nil {
+		return err
+	}
+	repository.SetErasurePolicy(erasurePolicy)
+	return nil
+}
+
+// CreateFirstAdmin creates the Admin account of a fresh installation, which
+// cannot be registered through the API. It fails once any admin exists.
+func CreateFirstAdmin(repo repository.Repository, cfg *config.Config, username string, password string) error {
+	if err := prepareRepository(repo, cfg); err != nil {
+		return err
+	}
+	return repo.CreateFirstAdmin(&types.AccountPostData{
+		Username:     username,
+		Password:     password,
+		SamePassword: password,
+		Role:         string(types.AdminRole),
+	})
+}
+
+// Prepare registers every route on engine. It returns an error, and the
+// server must not start, when a security-relevant setting is invalid.
+func Prepare(engine *gin.Engine, url string, repo repository.Repository, cfg *config.Config) error {
 	cmw := &corsMiddleware{
 		origin: url,
 	}
@@ -93,7 +288,7 @@ func Prepare(engine *gin.Engine, url string, repo repository.Repository) {
 			c.Writer.Header().Set("Access-Control-Allow-Credentials", "false")
 			c.Writer.Header().Set("Access-Control-Expose-Headers", "*")
 			c.Writer.Header().Set("Access-Control-Max-Age", "900")
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Origin, Accept, Authorization, X-Requested-With, Username, Password, User-Token")
+			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Origin, Accept, Authorization, X-Requested-With, Username, Password, User-Token, Otp-Code")
 			c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 			c.AbortWithStatus(204)
 			return
@@ -102,21 +297,98 @@ func Prepare(engine *gin.Engine, url string, repo repository.Repository) {
 		c.Next()
 	})
 
-	repo.DoPostgresPreparation()
+	if err := prepareRepository(repo, cfg); err != nil {
+		return err
+	}
 	engine.GET("/v1", index)
+	engine.GET("/.well-known/jwks.json", jwksHandler)
 	accountHandler := NewAccountHandler(repo)
+	accountHandler.sessions = service.NewSessionService(repo)
+	accountHandler.throttle = service.NewLoginThrottle()
+	accountHandler.twoFactor = service.NewTwoFactorService(repo)
+	accountHandler.apiKeys = service.NewApiKeyService(repo)
+	accountHandler.resets = service.NewPasswordResetService(repo, &service.InboxNotifier{Repo: repo})
+	accountHandler.suspensions = service.NewSuspensionStore(repo)
+	accountHandler.logins = service.NewLoginHistory(repo)
+	accountHandler.oauth = service.NewOAuthService(repo)
+	accountHandler.registration.Repo = repo
+	accountHandler.authz.Repo = repo
+	if err := accountHandler.authz.Load(); err != nil {
+		logrus.Error("loading role permissions: ", err)
+	}
+	if err := configureAuth(accountHandler, cfg.Auth); err != nil {
+		return err
+	}
+	if err := configureRegistration(accountHandler, cfg.Registration); err != nil {
+		return err
+	}
+	rotator, err := configureSigning(cfg.Auth.Signing, accountHandler.tokens.AccessLifetime)
+	if err != nil {
+		return err
+	}
+	if rotator != nil {
+		if err := rotator.Refresh(); err != nil {
+			return fmt.Errorf("loading JWT signing keys: %w", err)
+		}
+		rotator.Start(signingKeyRefreshInterval)
+	}
+	accountHandler.throttle.Start(loginThrottleCleanupInterval)
+	accountHandler.twoFactor.Start(twoFactorCleanupInterval)
+	accountHandler.suspensions.Start(suspensionReloadInterval)
+	accountHandler.logins.Start(loginHistoryCleanupInterval)
+	accountHandler.sessions.Start(sessionCleanupInterval)
+	accountHandler.revocations = service.NewRevocationStore(repo, accountHandler.tokens.AccessLifetime)
+	accountHandler.revocations.Start(revocationCleanupInterval)
+	jwt.SetRevocationChecker(accountHandler.revocations.Check)
 	bookHandler := NewBookHandler(repo)
 	manipulatorHandler := NewManipulatorHandler(repo)
 	messageHandler := NewMessageHandler(repo)
+	reviewHandler := NewReviewHandler(repo)
+	revisionHandler := NewRevisionHandler(repo)
+	subjectHandler := NewSubjectHandler(repo, repo)
+	shelfHandler := NewShelfHandler(repo)
+	recommendationService := service.NewRecommendationService(repo)
+	if interval, err := types.ParseISO8601Duration(cfg.Recommendations.RefreshInterval, time.Minute); err != nil {
+		logrus.Error("recommendations refresh interval: ", err)
+	} else {
+		recommendationService.Start(interval)
+	}
+	recommendationHandler := NewRecommendationHandler(recommendationService)
+	coverStore := blobstore.NewLocalStore(cfg.Covers.Dir)
+	coverHandler := NewCoverHandler(repo, coverStore, cfg.Covers.MaxSize)
+	duplicateHandler := NewDuplicateHandler(repo, repo, coverStore)
+	roleHandler := NewRoleHandler(accountHandler.authz)
+	profileHandler := NewProfileHandler(repo, repo, coverStore, cfg.Avatars.MaxSize)
+	accountHandler.personalData = service.NewPersonalDataService(repo, repo, coverStore)
 
 	bookHandler.accountHandler = accountHandler
 	messageHandler.accountHandler = accountHandler
+	reviewHandler.accountHandler = accountHandler
+	revisionHandler.accountHandler = accountHandler
+	coverHandler.accountHandler = accountHandler
+	subjectHandler.accountHandler = accountHandler
+	shelfHandler.accountHandler = accountHandler
+	recommendationHandler.accountHandler = accountHandler
+	duplicateHandler.accountHandler = accountHandler
+	roleHandler.accountHandler = accountHandler
+	profileHandler.accountHandler = accountHandler
 
 	manipulatorHandler.prepareManipulator(engine.Group("/v1/manipulators"))
 	prepareSort(engine.Group("/v1/sort"))
 	preparePassword(engine.Group("/v1/password"))
 	accountHandler.prepareAccount(engine.Group("/v1/accounts"))
+	accountHandler.prepareOAuth(engine.Group("/oauth"))
+	profileHandler.prepareProfile(engine.Group("/v1/accounts"))
 	messageHandler.prepareMessage(engine.Group("/v1/messages"))
 	bookHandler.prepareBook(engine.Group("/v1/books"))
-
+	recommendationHandler.prepareRecommendation(engine.Group("/v1/books"))
+	duplicateHandler.prepareDuplicate(engine.Group("/v1/books/duplicates"))
+	reviewHandler.prepareReview(engine.Group("/v1/books/code/:code"))
+	revisionHandler.prepareRevision(engine.Group("/v1/books/code/:code"))
+	coverHandler.prepareCover(engine.Group("/v1/books/code/:code"))
+	subjectHandler.prepareBookSubject(engine.Group("/v1/books/code/:code"))
+	subjectHandler.prepareSubject(engine.Group("/v1/subjects"))
+	shelfHandler.prepareShelf(engine.Group("/v1/shelves"))
+	roleHandler.prepareRole(engine.Group("/v1/roles"))
+	return nil
 }
@@ -0,0 +1,39 @@
+package go_gin_pages_test
+
+import (
+	"testing"
+	"time"
+
+	ginPages "tick_test/go_gin_pages"
+	"tick_test/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureSigning(t *testing.T) {
+	rotator, err := ginPages.ConfigureSigning(config.SigningConfig{Algorithm: "HS256"}, 30*time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, rotator, "the shared secret is not rotated")
+
+	rotator, err = ginPages.ConfigureSigning(config.SigningConfig{Algorithm: "EdDSA", KeyDir: t.TempDir(), RotationInterval: "P30D", RetiredKeyLifetime: "P1D"}, 30*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, rotator.RetiredKeyLifetime)
+
+	_, err = ginPages.ConfigureSigning(config.SigningConfig{Algorithm: "EdDSA", RetiredKeyLifetime: "PT10M"}, 30*time.Minute)
+	assert.ErrorContains(t, err, "auth.signing.retiredKeyLifetime")
+
+	_, err = ginPages.ConfigureSigning(config.SigningConfig{Algorithm: "none"}, 30*time.Minute)
+	assert.ErrorContains(t, err, "auth.signing.algorithm")
+}
+
+func TestConfigureRegistration(t *testing.T) {
+	ah := ginPages.NewAccountHandler(nil)
+	require.NoError(t, ginPages.ConfigureRegistration(ah, config.RegistrationConfig{Mode: "invite-code", InviteLifetime: "P7D"}))
+
+	err := ginPages.ConfigureRegistration(ah, config.RegistrationConfig{Mode: "closed", InviteLifetime: "P7D"})
+	assert.ErrorContains(t, err, "registration.mode")
+
+	err = ginPages.ConfigureRegistration(ah, config.RegistrationConfig{Mode: "open", InviteLifetime: "PT10S"})
+	assert.ErrorContains(t, err, "registration.inviteLifetime")
+}
@@ -0,0 +1,21 @@
+package go_gin_pages
+
+import (
+	"net/http"
+
+	"tick_test/utils/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jwksHandler publishes the public keys access tokens are signed with, so
+// that other services can verify them without asking this one. The set is
+// empty while tokens are signed with the shared secret.
+func jwksHandler(c *gin.Context) {
+	set := jwt.JWKSet{Keys: []jwt.JWK{}}
+	if keyring := jwt.GetKeyring(); keyring != nil {
+		set = keyring.JWKS()
+	}
+	c.Header("Cache-Control", "public, max-age=60")
+	c.JSON(http.StatusOK, set)
+}
@@ -6,7 +6,7 @@ import (
 
 	"tick_test/repository"
 	"tick_test/types"
-	"tick_test/utils/random"
+	"tick_test/utils/codegen"
 
 	"github.com/gin-gonic/gin"
 )
@@ -59,8 +59,13 @@ func (mh *manipulatorHandler) createIterationManipulatorHandler() gin.HandlerFun
 			}
 		}()
 
+		code, err := codegen.Generate(codegen.ManipulatorCodes)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
 		iterationManipulator := repository.IterationManipulator{
-			Code:        random.RandSeq(80),
+			Code:        code,
 			Data:        data,
 			Manipulator: ticker,
 		}
@@ -77,7 +82,12 @@ func (mh *manipulatorHandler) createIterationManipulatorHandler() gin.HandlerFun
 			if unique {
 				break
 			}
-			iterationManipulator.Code = random.RandSeq(80)
+			iterationManipulator.Code, err = codegen.Generate(codegen.ManipulatorCodes)
+			if err != nil {
+				repository.IterationManipulatorMutex.Unlock()
+				c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+				return
+			}
 		}
 
 		repository.IterationManipulatorMutex.Unlock()
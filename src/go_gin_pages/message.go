@@ -104,8 +104,8 @@ func (mh *messageHandler) getReceivedMessagesHandler() gin.HandlerFunc {
 }
 
 func (mh *messageHandler) prepareMessage(route *gin.RouterGroup) {
-	route.POST("/send", mh.sendMessageHandler())
-	route.GET("/user", mh.getMessagesHandler())
-	route.GET("/sent-by", mh.getSentMessagesHandler())
-	route.GET("/recv-by", mh.getReceivedMessagesHandler())
+	route.POST("/send", requireScope(types.MessagesSendScope), mh.sendMessageHandler())
+	route.GET("/user", requireScope(types.MessagesReadScope), mh.getMessagesHandler())
+	route.GET("/sent-by", requireScope(types.MessagesReadScope), mh.getSentMessagesHandler())
+	route.GET("/recv-by", requireScope(types.MessagesReadScope), mh.getReceivedMessagesHandler())
 }
@@ -1,6 +1,9 @@
 package mocks
 
 import (
+	"time"
+
+	"tick_test/repository"
 	"tick_test/types"
 	"tick_test/utils/jwt"
 
@@ -17,6 +20,7 @@ type AccountRepositoryMock struct {
 	FindPaginatedAccountsFn   func(pageSize, pageNumber int) ([]types.AccountGetData, error)
 	ConfirmNoAdminsFn         func() (int, error)
 	SaveAccountFn             func(*types.AccountPostData) error
+	CreateFirstAdminFn        func(*types.AccountPostData) error
 	DeleteAccountFn           func(string) error
 	UpdateExistingAccountFn   func(string, *types.AccountPatchData) (int64, error)
 	PromoteExistingAccountFn  func(*types.AccountPatchPromoteData) error
@@ -24,6 +28,8 @@ type AccountRepositoryMock struct {
 	ValidateTokenFn           func(string) (jwt.Claims, error)
 	GenerateTokenForUserFn    func(string) (string, error)
 	IsAdminFn                 func(string) (bool, error)
+	SaveRefreshTokenFn        func(string, string, string, string, types.DeviceInfo, time.Time) error
+	RotateRefreshTokenFn      func(string, string, string, time.Time) (repository.RotatedRefreshToken, error)
 }
 
 func (arm *AccountRepositoryMock) EnsureDatabaseIsOK(fn func(*gin.Context)) func(c *gin.Context) {
@@ -65,6 +71,10 @@ func (arm *AccountRepositoryMock) SaveAccount(obj *types.AccountPostData) error
 	return arm.SaveAccountFn(obj)
 }
 
+func (arm *AccountRepositoryMock) CreateFirstAdmin(obj *types.AccountPostData) error {
+	return arm.CreateFirstAdminFn(obj)
+}
+
 func (arm *AccountRepositoryMock) DeleteAccount(username string) error {
 	return arm.DeleteAccountFn(username)
 }
@@ -92,3 +102,11 @@ func (arm *AccountRepositoryMock) GenerateTokenForUser(username string) (string,
 func (arm *AccountRepositoryMock) IsAdmin(token string) (bool, error) {
 	return arm.IsAdminFn(token)
 }
+
+func (arm *AccountRepositoryMock) SaveRefreshToken(username string, family string, clientId string, tokenHash string, device types.DeviceInfo, expiresAt time.Time) error {
+	return arm.SaveRefreshTokenFn(username, family, clientId, tokenHash, device, expiresAt)
+}
+
+func (arm *AccountRepositoryMock) RotateRefreshToken(oldHash string, newHash string, clientId string, expiresAt time.Time) (repository.RotatedRefreshToken, error) {
+	return arm.RotateRefreshTokenFn(oldHash, newHash, clientId, expiresAt)
+}
@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"time"
+
+	"tick_test/types"
+)
+
+type ApiKeyRepositoryMock struct {
+	CreateApiKeyFn       func(string, types.ApiKey, string) (int64, error)
+	FindApiKeysFn        func(string) ([]types.ApiKey, error)
+	AuthenticateApiKeyFn func(string, time.Time) (string, types.Role, []string, error)
+	RevokeApiKeyFn       func(string, int64) error
+}
+
+func (akm *ApiKeyRepositoryMock) CreateApiKey(username string, key types.ApiKey, keyHash string) (int64, error) {
+	return akm.CreateApiKeyFn(username, key, keyHash)
+}
+
+func (akm *ApiKeyRepositoryMock) FindApiKeys(username string) ([]types.ApiKey, error) {
+	return akm.FindApiKeysFn(username)
+}
+
+func (akm *ApiKeyRepositoryMock) AuthenticateApiKey(keyHash string, now time.Time) (string, types.Role, []string, error) {
+	return akm.AuthenticateApiKeyFn(keyHash, now)
+}
+
+func (akm *ApiKeyRepositoryMock) RevokeApiKey(username string, id int64) error {
+	return akm.RevokeApiKeyFn(username, id)
+}
@@ -0,0 +1,33 @@
+package mocks
+
+import (
+	"time"
+
+	"tick_test/utils/blobstore"
+)
+
+type BlobStoreMock struct {
+	Blobs   map[string][]byte
+	ModTime time.Time
+}
+
+func (bsm *BlobStoreMock) Put(key string, data []byte) error {
+	if bsm.Blobs == nil {
+		bsm.Blobs = make(map[string][]byte)
+	}
+	bsm.Blobs[key] = data
+	return nil
+}
+
+func (bsm *BlobStoreMock) Get(key string) ([]byte, time.Time, error) {
+	data, ok := bsm.Blobs[key]
+	if !ok {
+		return nil, time.Time{}, blobstore.ErrNotFound
+	}
+	return data, bsm.ModTime, nil
+}
+
+func (bsm *BlobStoreMock) Delete(key string) error {
+	delete(bsm.Blobs, key)
+	return nil
+}
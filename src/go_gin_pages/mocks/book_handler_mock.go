@@ -7,13 +7,17 @@ import (
 )
 
 type BookRepositoryMock struct {
-	EnsureDatabaseIsOKFn func(func(*gin.Context)) func(*gin.Context)
-	FindAllBooksFn       func() ([]types.Book, error)
-	FindPaginatedBooksFn func(int, int) ([]types.Book, error)
-	FindBookByCodeFn     func(string) (types.Book, error)
-	CreateBookFn         func(*types.Book) error
-	UpdateBookByCodeFn   func(string, types.Book) (types.Book, error)
-	RemoveBookByCodeFn   func(string) (int64, error)
+	EnsureDatabaseIsOKFn         func(func(*gin.Context)) func(*gin.Context)
+	FindAllBooksFn               func() ([]types.Book, error)
+	FindAllBooksByRatingFn       func() ([]types.RatedBook, error)
+	FindBooksInSubjectFn         func(int64) ([]types.Book, error)
+	FindPaginatedBooksFn         func(int, int) ([]types.Book, error)
+	FindBooksInSubjectByRatingFn func(int64) ([]types.RatedBook, error)
+	FindPaginatedBooksByRatingFn func(int, int) ([]types.RatedBook, error)
+	FindBookByCodeFn             func(string) (types.Book, error)
+	CreateBookFn                 func(*types.Book) error
+	UpdateBookByCodeFn           func(string, types.Book, string) (types.Book, error)
+	RemoveBookByCodeFn           func(string) (int64, error)
 }
 
 func (brm *BookRepositoryMock) EnsureDatabaseIsOK(fn func(*gin.Context)) func(c *gin.Context) {
@@ -24,6 +28,22 @@ func (brm *BookRepositoryMock) FindAllBooks() (books []types.Book, err error) {
 	return brm.FindAllBooksFn()
 }
 
+func (brm *BookRepositoryMock) FindAllBooksByRating() (books []types.RatedBook, err error) {
+	return brm.FindAllBooksByRatingFn()
+}
+
+func (brm *BookRepositoryMock) FindBooksInSubject(subjectId int64) (books []types.Book, err error) {
+	return brm.FindBooksInSubjectFn(subjectId)
+}
+
+func (brm *BookRepositoryMock) FindBooksInSubjectByRating(subjectId int64) (books []types.RatedBook, err error) {
+	return brm.FindBooksInSubjectByRatingFn(subjectId)
+}
+
+func (brm *BookRepositoryMock) FindPaginatedBooksByRating(pageSize int, pageNumber int) (books []types.RatedBook, err error) {
+	return brm.FindPaginatedBooksByRatingFn(pageSize, pageNumber)
+}
+
 func (brm *BookRepositoryMock) FindPaginatedBooks(pageSize int, pageNumber int) (books []types.Book, err error) {
 	return brm.FindPaginatedBooksFn(pageSize, pageNumber)
 }
@@ -36,8 +56,8 @@ func (brm *BookRepositoryMock) CreateBook(book *types.Book) (err error) {
 	return brm.CreateBookFn(book)
 }
 
-func (brm *BookRepositoryMock) UpdateBookByCode(code string, updates types.Book) (book types.Book, err error) {
-	return brm.UpdateBookByCodeFn(code, updates)
+func (brm *BookRepositoryMock) UpdateBookByCode(code string, updates types.Book, editor string) (book types.Book, err error) {
+	return brm.UpdateBookByCodeFn(code, updates, editor)
 }
 
 func (brm *BookRepositoryMock) RemoveBookByCode(code string) (n int64, err error) {
@@ -0,0 +1,25 @@
+package mocks
+
+import (
+	"time"
+
+	"tick_test/types"
+)
+
+type LoginHistoryRepositoryMock struct {
+	SaveLoginEventFn          func(string, types.LoginEvent) error
+	FindLoginEventsFn         func(string, int) ([]types.LoginEvent, error)
+	DeleteLoginEventsBeforeFn func(time.Time) error
+}
+
+func (lhrm *LoginHistoryRepositoryMock) SaveLoginEvent(username string, event types.LoginEvent) error {
+	return lhrm.SaveLoginEventFn(username, event)
+}
+
+func (lhrm *LoginHistoryRepositoryMock) FindLoginEvents(username string, limit int) ([]types.LoginEvent, error) {
+	return lhrm.FindLoginEventsFn(username, limit)
+}
+
+func (lhrm *LoginHistoryRepositoryMock) DeleteLoginEventsBefore(before time.Time) error {
+	return lhrm.DeleteLoginEventsBeforeFn(before)
+}
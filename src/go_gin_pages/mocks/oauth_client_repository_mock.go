@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"tick_test/repository"
+	"tick_test/types"
+)
+
+type OAuthClientRepositoryMock struct {
+	CreateOAuthClientFn          func(string, types.OAuthClient, string) error
+	FindOAuthClientsFn           func(string) ([]types.OAuthClient, error)
+	FindOAuthClientCredentialsFn func(string) (repository.OAuthClientCredentials, error)
+	DeleteOAuthClientFn          func(string, string) error
+}
+
+func (ocrm *OAuthClientRepositoryMock) CreateOAuthClient(username string, client types.OAuthClient, secretHash string) error {
+	return ocrm.CreateOAuthClientFn(username, client, secretHash)
+}
+
+func (ocrm *OAuthClientRepositoryMock) FindOAuthClients(username string) ([]types.OAuthClient, error) {
+	return ocrm.FindOAuthClientsFn(username)
+}
+
+func (ocrm *OAuthClientRepositoryMock) FindOAuthClientCredentials(clientId string) (repository.OAuthClientCredentials, error) {
+	return ocrm.FindOAuthClientCredentialsFn(clientId)
+}
+
+func (ocrm *OAuthClientRepositoryMock) DeleteOAuthClient(username string, clientId string) error {
+	return ocrm.DeleteOAuthClientFn(username, clientId)
+}
@@ -0,0 +1,21 @@
+package mocks
+
+import "time"
+
+type PasswordResetRepositoryMock struct {
+	SavePasswordResetFn func(string, string, time.Time) error
+	ResetPasswordFn     func(string, string, time.Time) (string, error)
+	ClearPasswordFn     func(string) error
+}
+
+func (prm *PasswordResetRepositoryMock) SavePasswordReset(username string, tokenHash string, expiresAt time.Time) error {
+	return prm.SavePasswordResetFn(username, tokenHash, expiresAt)
+}
+
+func (prm *PasswordResetRepositoryMock) ResetPassword(tokenHash string, password string, now time.Time) (string, error) {
+	return prm.ResetPasswordFn(tokenHash, password, now)
+}
+
+func (prm *PasswordResetRepositoryMock) ClearPassword(username string) error {
+	return prm.ClearPasswordFn(username)
+}
@@ -0,0 +1,11 @@
+package mocks
+
+import "tick_test/types"
+
+type PersonalDataRepositoryMock struct {
+	FindPersonalDataFn func(string) (types.PersonalData, error)
+}
+
+func (pdrm *PersonalDataRepositoryMock) FindPersonalData(username string) (types.PersonalData, error) {
+	return pdrm.FindPersonalDataFn(username)
+}
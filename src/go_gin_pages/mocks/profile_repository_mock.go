@@ -0,0 +1,21 @@
+package mocks
+
+import "tick_test/types"
+
+type ProfileRepositoryMock struct {
+	FindProfileFn func(string) (types.Profile, error)
+	SaveProfileFn func(types.Profile) error
+	SetAvatarFn   func(string, bool) error
+}
+
+func (prm *ProfileRepositoryMock) FindProfile(username string) (types.Profile, error) {
+	return prm.FindProfileFn(username)
+}
+
+func (prm *ProfileRepositoryMock) SaveProfile(profile types.Profile) error {
+	return prm.SaveProfileFn(profile)
+}
+
+func (prm *ProfileRepositoryMock) SetAvatar(username string, hasAvatar bool) error {
+	return prm.SetAvatarFn(username, hasAvatar)
+}
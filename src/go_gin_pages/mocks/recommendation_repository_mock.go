@@ -0,0 +1,23 @@
+package mocks
+
+import (
+	"tick_test/types"
+)
+
+type RecommendationRepositoryMock struct {
+	FindInteractionsFn func() ([]types.Interaction, error)
+	FindRelatedBooksFn func(string, int) ([]types.Book, error)
+	FindBooksByCodesFn func([]string) ([]types.Book, error)
+}
+
+func (rrm *RecommendationRepositoryMock) FindInteractions() (interactions []types.Interaction, err error) {
+	return rrm.FindInteractionsFn()
+}
+
+func (rrm *RecommendationRepositoryMock) FindRelatedBooks(code string, limit int) (books []types.Book, err error) {
+	return rrm.FindRelatedBooksFn(code, limit)
+}
+
+func (rrm *RecommendationRepositoryMock) FindBooksByCodes(codes []string) (books []types.Book, err error) {
+	return rrm.FindBooksByCodesFn(codes)
+}
@@ -0,0 +1,50 @@
+package mocks
+
+import (
+	"time"
+
+	"tick_test/types"
+)
+
+type RegistrationRepositoryMock struct {
+	CreateInviteFn          func(types.Invite, string) (int64, error)
+	FindInvitesFn           func() ([]types.Invite, error)
+	DeleteInviteFn          func(int64) error
+	SaveInvitedAccountFn    func(*types.AccountPostData, string, time.Time) (types.Role, error)
+	SavePendingAccountFn    func(*types.AccountPostData, time.Time) error
+	FindPendingAccountsFn   func() ([]types.PendingAccount, error)
+	FindPendingAccountFn    func(string) (types.PendingAccount, error)
+	ApprovePendingAccountFn func(string) error
+}
+
+func (rrm *RegistrationRepositoryMock) CreateInvite(invite types.Invite, codeHash string) (int64, error) {
+	return rrm.CreateInviteFn(invite, codeHash)
+}
+
+func (rrm *RegistrationRepositoryMock) FindInvites() ([]types.Invite, error) {
+	return rrm.FindInvitesFn()
+}
+
+func (rrm *RegistrationRepositoryMock) DeleteInvite(id int64) error {
+	return rrm.DeleteInviteFn(id)
+}
+
+func (rrm *RegistrationRepositoryMock) SaveInvitedAccount(obj *types.AccountPostData, codeHash string, now time.Time) (types.Role, error) {
+	return rrm.SaveInvitedAccountFn(obj, codeHash, now)
+}
+
+func (rrm *RegistrationRepositoryMock) SavePendingAccount(obj *types.AccountPostData, now time.Time) error {
+	return rrm.SavePendingAccountFn(obj, now)
+}
+
+func (rrm *RegistrationRepositoryMock) FindPendingAccounts() ([]types.PendingAccount, error) {
+	return rrm.FindPendingAccountsFn()
+}
+
+func (rrm *RegistrationRepositoryMock) FindPendingAccount(username string) (types.PendingAccount, error) {
+	return rrm.FindPendingAccountFn(username)
+}
+
+func (rrm *RegistrationRepositoryMock) ApprovePendingAccount(username string) error {
+	return rrm.ApprovePendingAccountFn(username)
+}
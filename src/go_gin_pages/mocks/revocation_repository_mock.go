@@ -0,0 +1,40 @@
+package mocks
+
+import (
+	"time"
+
+	"tick_test/types"
+)
+
+type RevocationRepositoryMock struct {
+	RevokeTokenFn              func(string, string, time.Time) error
+	RevokeAllTokensFn          func(string) error
+	RevokeRefreshTokenFamilyFn func(string, string) error
+	RevokeAccountSessionFn     func(string, types.SessionKind, string) error
+	FindRevocationsFn          func() ([]types.RevokedToken, []types.TokenCutoff, error)
+	DeleteExpiredRevocationsFn func(time.Time, time.Time) error
+}
+
+func (rrm *RevocationRepositoryMock) RevokeToken(jti string, username string, expiresAt time.Time) error {
+	return rrm.RevokeTokenFn(jti, username, expiresAt)
+}
+
+func (rrm *RevocationRepositoryMock) RevokeAllTokens(username string) error {
+	return rrm.RevokeAllTokensFn(username)
+}
+
+func (rrm *RevocationRepositoryMock) RevokeRefreshTokenFamily(username string, tokenHash string) error {
+	return rrm.RevokeRefreshTokenFamilyFn(username, tokenHash)
+}
+
+func (rrm *RevocationRepositoryMock) RevokeAccountSession(username string, kind types.SessionKind, key string) error {
+	return rrm.RevokeAccountSessionFn(username, kind, key)
+}
+
+func (rrm *RevocationRepositoryMock) FindRevocations() ([]types.RevokedToken, []types.TokenCutoff, error) {
+	return rrm.FindRevocationsFn()
+}
+
+func (rrm *RevocationRepositoryMock) DeleteExpiredRevocations(now time.Time, cutoffsBefore time.Time) error {
+	return rrm.DeleteExpiredRevocationsFn(now, cutoffsBefore)
+}
@@ -0,0 +1,33 @@
+package mocks
+
+import (
+	"tick_test/types"
+)
+
+type RoleRepositoryMock struct {
+	FindRolesFn        func() ([]types.RoleInfo, error)
+	CreateRoleFn       func(types.Role, []string) error
+	DeleteRoleFn       func(types.Role) error
+	GrantPermissionFn  func(types.Role, string) error
+	RevokePermissionFn func(types.Role, string) error
+}
+
+func (rrm *RoleRepositoryMock) FindRoles() ([]types.RoleInfo, error) {
+	return rrm.FindRolesFn()
+}
+
+func (rrm *RoleRepositoryMock) CreateRole(name types.Role, permissions []string) error {
+	return rrm.CreateRoleFn(name, permissions)
+}
+
+func (rrm *RoleRepositoryMock) DeleteRole(name types.Role) error {
+	return rrm.DeleteRoleFn(name)
+}
+
+func (rrm *RoleRepositoryMock) GrantPermission(role types.Role, permission string) error {
+	return rrm.GrantPermissionFn(role, permission)
+}
+
+func (rrm *RoleRepositoryMock) RevokePermission(role types.Role, permission string) error {
+	return rrm.RevokePermissionFn(role, permission)
+}
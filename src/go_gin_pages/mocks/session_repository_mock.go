@@ -0,0 +1,35 @@
+package mocks
+
+import (
+	"time"
+
+	"tick_test/types"
+)
+
+type SessionRepositoryMock struct {
+	CreateSessionFn         func(string, string, types.DeviceInfo, time.Time, time.Time) error
+	TouchSessionFn          func(string, time.Time, time.Time) (int64, string, types.Role, error)
+	RevokeSessionFn         func(string) error
+	FindActiveSessionsFn    func(string, time.Time) ([]types.Session, error)
+	DeleteExpiredSessionsFn func(time.Time) error
+}
+
+func (srm *SessionRepositoryMock) CreateSession(username string, tokenHash string, device types.DeviceInfo, idleExpiresAt time.Time, absoluteExpiresAt time.Time) error {
+	return srm.CreateSessionFn(username, tokenHash, device, idleExpiresAt, absoluteExpiresAt)
+}
+
+func (srm *SessionRepositoryMock) TouchSession(tokenHash string, now time.Time, idleExpiresAt time.Time) (int64, string, types.Role, error) {
+	return srm.TouchSessionFn(tokenHash, now, idleExpiresAt)
+}
+
+func (srm *SessionRepositoryMock) RevokeSession(tokenHash string) error {
+	return srm.RevokeSessionFn(tokenHash)
+}
+
+func (srm *SessionRepositoryMock) FindActiveSessions(username string, now time.Time) ([]types.Session, error) {
+	return srm.FindActiveSessionsFn(username, now)
+}
+
+func (srm *SessionRepositoryMock) DeleteExpiredSessions(now time.Time) error {
+	return srm.DeleteExpiredSessionsFn(now)
+}
@@ -0,0 +1,25 @@
+package mocks
+
+import (
+	"time"
+
+	"tick_test/types"
+)
+
+type SuspensionRepositoryMock struct {
+	SuspendAccountFn   func(types.Suspension) error
+	ReinstateAccountFn func(string) error
+	FindSuspensionsFn  func(time.Time) ([]types.Suspension, error)
+}
+
+func (srm *SuspensionRepositoryMock) SuspendAccount(suspension types.Suspension) error {
+	return srm.SuspendAccountFn(suspension)
+}
+
+func (srm *SuspensionRepositoryMock) ReinstateAccount(username string) error {
+	return srm.ReinstateAccountFn(username)
+}
+
+func (srm *SuspensionRepositoryMock) FindSuspensions(now time.Time) ([]types.Suspension, error) {
+	return srm.FindSuspensionsFn(now)
+}
@@ -0,0 +1,43 @@
+package mocks
+
+import (
+	"tick_test/types"
+)
+
+type TwoFactorRepositoryMock struct {
+	SaveTotpSecretFn       func(string, string) error
+	FindTotpFn             func(string) (types.TotpState, error)
+	EnableTotpFn           func(string, int64, []string) error
+	UseTotpCounterFn       func(string, int64) error
+	UseRecoveryCodeFn      func(string, string) error
+	ReplaceRecoveryCodesFn func(string, []string) error
+	DisableTotpFn          func(string) error
+}
+
+func (tfm *TwoFactorRepositoryMock) SaveTotpSecret(username string, secret string) error {
+	return tfm.SaveTotpSecretFn(username, secret)
+}
+
+func (tfm *TwoFactorRepositoryMock) FindTotp(username string) (types.TotpState, error) {
+	return tfm.FindTotpFn(username)
+}
+
+func (tfm *TwoFactorRepositoryMock) EnableTotp(username string, counter int64, recoveryCodeHashes []string) error {
+	return tfm.EnableTotpFn(username, counter, recoveryCodeHashes)
+}
+
+func (tfm *TwoFactorRepositoryMock) UseTotpCounter(username string, counter int64) error {
+	return tfm.UseTotpCounterFn(username, counter)
+}
+
+func (tfm *TwoFactorRepositoryMock) UseRecoveryCode(username string, codeHash string) error {
+	return tfm.UseRecoveryCodeFn(username, codeHash)
+}
+
+func (tfm *TwoFactorRepositoryMock) ReplaceRecoveryCodes(username string, recoveryCodeHashes []string) error {
+	return tfm.ReplaceRecoveryCodesFn(username, recoveryCodeHashes)
+}
+
+func (tfm *TwoFactorRepositoryMock) DisableTotp(username string) error {
+	return tfm.DisableTotpFn(username)
+}
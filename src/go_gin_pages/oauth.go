@@ -0,0 +1,238 @@
+package go_gin_pages
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// respondOAuthError answers a token request with the error response of
+// RFC 6749. Errors of the account services become invalid_grant or
+// invalid_request.
+func respondOAuthError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	var oauthErr *types.OAuthError
+	switch {
+	case errors.As(err, &oauthErr):
+		if oauthErr.Code == types.OAuthInvalidClient {
+			status = http.StatusUnauthorized
+			c.Header("WWW-Authenticate", `Basic realm="tick_test"`)
+		}
+	case errors.Is(err, errDefs.ErrTooManyRequests):
+		setRetryAfter(c, err)
+		status = http.StatusTooManyRequests
+		oauthErr = &types.OAuthError{Code: types.OAuthInvalidGrant, Description: err.Error()}
+	case errors.Is(err, errDefs.ErrUnauthorized), errors.Is(err, errDefs.ErrForbidden), errors.Is(err, errDefs.ErrEntityNotFound):
+		oauthErr = &types.OAuthError{Code: types.OAuthInvalidGrant, Description: err.Error()}
+	case errors.Is(err, errDefs.ErrBadRequest):
+		oauthErr = &types.OAuthError{Code: types.OAuthInvalidRequest, Description: err.Error()}
+	default:
+		logrus.Error("OAuth token request: ", err)
+		status = http.StatusInternalServerError
+		// the cause is logged, not shown to the client
+		oauthErr = &types.OAuthError{Code: types.OAuthServerError, Description: "the token request could not be completed"}
+	}
+	c.JSON(status, oauthErr)
+}
+
+// oauthClient authenticates the client of a token request, which sends its
+// id and secret either with HTTP Basic authentication or as client_id and
+// client_secret in the body, but not both.
+func (ah *accountHandler) oauthClient(c *gin.Context) (repository.OAuthClientCredentials, error) {
+	clientId, secret, basic := c.Request.BasicAuth()
+	if basic {
+		if c.PostForm("client_id") != "" || c.PostForm("client_secret") != "" {
+			return repository.OAuthClientCredentials{}, &types.OAuthError{Code: types.OAuthInvalidRequest, Description: "client credentials sent both in the Authorization header and in the body"}
+		}
+		// Basic credentials of clients are form-encoded before base64
+		var idErr, secretErr error
+		clientId, idErr = url.QueryUnescape(clientId)
+		secret, secretErr = url.QueryUnescape(secret)
+		if idErr != nil || secretErr != nil {
+			return repository.OAuthClientCredentials{}, &types.OAuthError{Code: types.OAuthInvalidClient, Description: "malformed client credentials"}
+		}
+	} else {
+		clientId, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	if clientId == "" || secret == "" {
+		return repository.OAuthClientCredentials{}, &types.OAuthError{Code: types.OAuthInvalidClient, Description: "client authentication required"}
+	}
+	return ah.oauth.Authenticate(clientId, secret)
+}
+
+// OAuthTokenHandler is the token endpoint of RFC 6749 for confidential
+// clients. It takes form-encoded requests of the password,
+// client_credentials and refresh_token grants.
+func (ah *accountHandler) OAuthTokenHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		c.Header("Pragma", "no-cache")
+		if ah.oauth == nil {
+			respondOAuthError(c, &types.OAuthError{Code: types.OAuthInvalidClient, Description: "OAuth2 clients are disabled"})
+			return
+		}
+		grantType := c.PostForm("grant_type")
+		if grantType == "" {
+			respondOAuthError(c, &types.OAuthError{Code: types.OAuthInvalidRequest, Description: "grant_type is required"})
+			return
+		}
+		client, err := ah.oauthClient(c)
+		if err != nil {
+			respondOAuthError(c, err)
+			return
+		}
+		switch grantType {
+		case types.PasswordGrant:
+			ah.passwordGrant(c, client)
+		case types.ClientCredentialsGrant:
+			ah.clientCredentialsGrant(c, client)
+		case types.RefreshTokenGrant:
+			ah.refreshTokenGrant(c, client)
+		default:
+			respondOAuthError(c, &types.OAuthError{Code: types.OAuthUnsupportedGrantType, Description: fmt.Sprintf("grant type %q is not supported", grantType)})
+		}
+	}
+}
+
+// passwordGrant logs the resource owner in like /v1/accounts/login, with
+// the two-factor code, if any, in the Otp-Code header.
+func (ah *accountHandler) passwordGrant(c *gin.Context, client repository.OAuthClientCredentials) {
+	username, password := c.PostForm("username"), c.PostForm("password")
+	if username == "" || password == "" {
+		respondOAuthError(c, &types.OAuthError{Code: types.OAuthInvalidRequest, Description: "username and password are required"})
+		return
+	}
+	if err := ah.confirmCredentials(c, username, password); err != nil {
+		if !errors.Is(err, errDefs.ErrTooManyRequests) && !errors.Is(err, errDefs.ErrForbidden) {
+			err = &types.OAuthError{Code: types.OAuthInvalidGrant, Description: "invalid username or password"}
+		}
+		respondOAuthError(c, err)
+		return
+	}
+	if err := ah.confirmSecondFactor(c, username); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	role, err := ah.repo.FindUserRole(username)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	tokens, err := ah.startLogin(c, username, role, client.Client.ClientId)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	ah.recordLogin(c, username, nil)
+	c.JSON(http.StatusOK, tokens.OAuth())
+}
+
+func (ah *accountHandler) clientCredentialsGrant(c *gin.Context, client repository.OAuthClientCredentials) {
+	if err := ah.checkSuspension(client.Owner); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	token, err := ah.oauth.ClientCredentials(client, c.PostForm("scope"))
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, token)
+}
+
+// refreshTokenGrant only accepts refresh tokens issued to the authenticated
+// client (RFC 6749 section 6).
+func (ah *accountHandler) refreshTokenGrant(c *gin.Context, client repository.OAuthClientCredentials) {
+	refreshToken := c.PostForm("refresh_token")
+	if refreshToken == "" {
+		respondOAuthError(c, &types.OAuthError{Code: types.OAuthInvalidRequest, Description: "refresh_token is required"})
+		return
+	}
+	tokens, err := ah.tokens.Refresh(refreshToken, client.Client.ClientId)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tokens.OAuth())
+}
+
+func (ah *accountHandler) oauthEnabled(c *gin.Context) bool {
+	if ah.oauth == nil {
+		returnError(c, fmt.Errorf("%w: OAuth2 clients are disabled", errDefs.ErrEntityNotFound))
+		return false
+	}
+	return true
+}
+
+func (ah *accountHandler) GetOAuthClientsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ah.oauthEnabled(c) {
+			return
+		}
+		claims, err := ah.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		clients, err := ah.oauth.List(claims.Username)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, clients)
+	}
+}
+
+func (ah *accountHandler) PostOAuthClientHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ah.oauthEnabled(c) {
+			return
+		}
+		claims, err := ah.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		var data types.OAuthClientPostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		created, err := ah.oauth.Register(claims.Username, data)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, created)
+	}
+}
+
+func (ah *accountHandler) DeleteOAuthClientHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ah.oauthEnabled(c) {
+			return
+		}
+		claims, err := ah.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := ah.oauth.Delete(claims.Username, c.Param("clientId")); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, nil)
+	}
+}
+
+func (ah *accountHandler) prepareOAuth(route *gin.RouterGroup) {
+	route.POST("/token", ah.OAuthTokenHandler())
+}
@@ -0,0 +1,147 @@
+package go_gin_pages_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ginPages "tick_test/go_gin_pages"
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/repository"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBearerToken(t *testing.T) {
+	jwt.SetSecretKey([]byte("RU5DT0RFRF9TRUNSRVRfVEVYVA=="))
+	login, _ := jwt.GenerateToken("admin", types.AdminRole, time.Minute)
+
+	testCases := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+	}{
+		{name: "Success", authorization: "Bearer " + login, expectedStatus: http.StatusOK},
+		{name: "Fail - Unknown scheme", authorization: "Token " + login, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &mocks.AccountRepositoryMock{
+				PromoteExistingAccountFn: func(data *types.AccountPatchPromoteData) error { return nil },
+				FindUserRoleFn:           func(string) (types.Role, error) { return types.UserRole, nil },
+			}
+			handler := ginPages.NewAccountHandler(repo).PatchPromoteAccountHandler()
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPatch, "/promote", strings.NewReader(`{"username":"john","role":"BookKeeper"}`))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request.Header.Set("Authorization", tc.authorization)
+
+			handler(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestClientCredentialsTokenWithoutScope(t *testing.T) {
+	jwt.SetSecretKey([]byte("RU5DT0RFRF9TRUNSRVRfVEVYVA=="))
+	scoped, _ := jwt.GenerateScopedToken("john", types.UserRole, time.Minute, []string{types.BooksReadScope})
+	handler := ginPages.NewAccountHandler(&mocks.AccountRepositoryMock{}).ExportPersonalDataHandler()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/me/export", nil)
+	c.Request.Header.Set("Authorization", "Bearer "+scoped)
+
+	handler(c)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"Error":"forbidden: endpoint does not accept client credentials tokens"}`, w.Body.String())
+}
+
+func TestOAuthTokenHandlerDisabled(t *testing.T) {
+	handler := ginPages.NewAccountHandler(&mocks.AccountRepositoryMock{}).OAuthTokenHandler()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader("grant_type=client_credentials"))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	handler(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"error":"invalid_client","error_description":"OAuth2 clients are disabled"}`, w.Body.String())
+}
+
+func TestRefreshTokenGrant(t *testing.T) {
+	jwt.SetSecretKey([]byte("RU5DT0RFRF9TRUNSRVRfVEVYVA=="))
+	testCases := []struct {
+		name                string
+		clientId            string
+		rotateErr           error
+		expectedStatus      int
+		expectedError       string
+		expectedDescription string
+	}{
+		{name: "Success", clientId: "client-a", expectedStatus: http.StatusOK},
+		{name: "Fail - other client", clientId: "client-b", expectedStatus: http.StatusBadRequest, expectedError: types.OAuthInvalidGrant, expectedDescription: "unauthorized: refresh token was issued to another client"},
+		{name: "Fail - server error", clientId: "client-a", rotateErr: errors.New("pq: connection refused"), expectedStatus: http.StatusInternalServerError, expectedError: types.OAuthServerError, expectedDescription: "the token request could not be completed"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ah := ginPages.NewAccountHandler(&mocks.AccountRepositoryMock{
+				RotateRefreshTokenFn: func(oldHash, newHash, clientId string, expiresAt time.Time) (repository.RotatedRefreshToken, error) {
+					if tc.rotateErr != nil {
+						return repository.RotatedRefreshToken{}, tc.rotateErr
+					}
+					// the family was issued to client-a
+					if clientId != "client-a" {
+						return repository.RotatedRefreshToken{}, fmt.Errorf("%w: refresh token was issued to another client", errDefs.ErrUnauthorized)
+					}
+					return repository.RotatedRefreshToken{Username: "john", Role: types.UserRole, Family: "family"}, nil
+				},
+			})
+			ah.UseOAuth(service.NewOAuthService(&mocks.OAuthClientRepositoryMock{
+				FindOAuthClientCredentialsFn: func(clientId string) (repository.OAuthClientCredentials, error) {
+					return repository.OAuthClientCredentials{
+						Client:     types.OAuthClient{ClientId: clientId},
+						Owner:      "john",
+						SecretHash: service.HashToken("secret"),
+					}, nil
+				},
+			}))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader("grant_type=refresh_token&refresh_token=old"))
+			c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			c.Request.SetBasicAuth(tc.clientId, "secret")
+
+			ah.OAuthTokenHandler()(c)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedError != "" {
+				var oauthErr types.OAuthError
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &oauthErr))
+				assert.Equal(t, tc.expectedError, oauthErr.Code)
+				assert.Equal(t, tc.expectedDescription, oauthErr.Description)
+			}
+		})
+	}
+}
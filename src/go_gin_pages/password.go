@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
-	"strings"
-	"unicode"
+
+	"tick_test/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator"
@@ -13,13 +13,6 @@ import (
 
 var passwords []string = make([]string, 0)
 
-type runeChecker struct {
-	Score   int
-	Checker func(rune) bool
-}
-
-var runeCheckers = make([]runeChecker, 0)
-
 type PasswordSimpleConfig struct {
 	Size    int      `json:"size"     binding:"omitempty,gt=0"`
 	MinSize int      `json:"minSize"  binding:"omitempty,gt=0"`
@@ -69,20 +62,7 @@ func findAllPasswords(c *gin.Context) {
 
 func ratePassword(c *gin.Context) {
 	password := c.Param("password")
-	score := 0
-	passwordLen := len(password)
-	for _, pLen := range []int{5, 8, 14, 20} {
-		if passwordLen > pLen {
-			score += 1
-		} else {
-			break
-		}
-	}
-	for _, glyphChecker := range runeCheckers {
-		if strings.IndexFunc(password, glyphChecker.Checker) != -1 {
-			score += glyphChecker.Score
-		}
-	}
+	score := service.RatePassword(password)
 	c.JSON(
 		http.StatusOK,
 		gin.H{
@@ -156,23 +136,6 @@ var passwordValidator = validator.New()
 func preparePassword(route *gin.RouterGroup) {
 	passwordValidator.RegisterStructValidation(passwordConfigStructLevelValidation, PasswordSimpleConfig{})
 
-	runeCheckers = append(runeCheckers, runeChecker{
-		Score:   3,
-		Checker: func(glyph rune) bool { return glyph > unicode.MaxASCII },
-	})
-	runeCheckers = append(runeCheckers, runeChecker{
-		Score:   1,
-		Checker: unicode.IsUpper,
-	})
-	runeCheckers = append(runeCheckers, runeChecker{
-		Score:   1,
-		Checker: unicode.IsLower,
-	})
-	runeCheckers = append(runeCheckers, runeChecker{
-		Score:   1,
-		Checker: unicode.IsSymbol,
-	})
-
 	route.GET("", findAllPasswords)
 	route.GET("/rate/:password", ratePassword)
 	route.POST("/simple", createSimplePassword)
@@ -0,0 +1,86 @@
+package go_gin_pages
+
+import (
+	"fmt"
+	"net/http"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func (ah *accountHandler) passwordResetsEnabled(c *gin.Context) bool {
+	if ah.resets == nil {
+		returnError(c, fmt.Errorf("%w: password resets are disabled", errDefs.ErrEntityNotFound))
+		return false
+	}
+	return true
+}
+
+// RequestPasswordResetHandler answers 202 whether or not the account exists.
+func (ah *accountHandler) RequestPasswordResetHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ah.passwordResetsEnabled(c) {
+			return
+		}
+		var data types.PasswordResetRequestData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		if err := ah.resets.Request(data.Username); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, nil)
+	}
+}
+
+func (ah *accountHandler) IssuePasswordResetHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ah.passwordResetsEnabled(c) {
+			return
+		}
+		var data types.PasswordResetRequestData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		claims := claimsFromContext(c)
+		if err := ah.mayManage(claims, data.Username); err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := ah.resets.Issue(data.Username, claims.Username); err != nil {
+			returnError(c, err)
+			return
+		}
+		logrus.Info(claims.Username, " issued a password reset for ", data.Username)
+		c.JSON(http.StatusAccepted, nil)
+	}
+}
+
+func (ah *accountHandler) ConfirmPasswordResetHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ah.passwordResetsEnabled(c) {
+			return
+		}
+		var data types.PasswordResetConfirmData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		username, err := ah.resets.Confirm(data)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		// the new password ends any lockout caused by guessing the old one
+		if ah.throttle != nil {
+			ah.throttle.Succeed(username)
+		}
+		c.JSON(http.StatusOK, nil)
+	}
+}
@@ -0,0 +1,69 @@
+package go_gin_pages
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"tick_test/utils/errDefs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// eraseAccount ends every login of the account and deletes it.
+func (ah *accountHandler) eraseAccount(username string) error {
+	// tokens only name the account, so without a cutoff they would keep
+	// working and would even pass for a new account of the same name
+	if ah.revocations != nil {
+		if err := ah.revocations.RevokeAll(username); err != nil {
+			return err
+		}
+	}
+	if ah.personalData == nil {
+		return ah.repo.DeleteAccount(username)
+	}
+	return ah.personalData.Erase(username)
+}
+
+func (ah *accountHandler) ExportPersonalDataHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := ah.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		// the archive is built in full first so that a failure can still be
+		// answered with an error instead of a truncated download
+		var archive bytes.Buffer
+		if err := ah.personalData.Export(claims.Username, &archive); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="personal-data.zip"`)
+		c.Header("Cache-Control", "no-store")
+		c.Data(http.StatusOK, "application/zip", archive.Bytes())
+	}
+}
+
+// EraseMeHandler deletes the account of the caller, who has to confirm it
+// with their password even when holding a token.
+func (ah *accountHandler) EraseMeHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Username") == "" || c.GetHeader("Password") == "" {
+			returnError(c, fmt.Errorf("%w: confirm the deletion with headers Username and Password", errDefs.ErrUnauthorized))
+			return
+		}
+		claims, err := ah.passwordAuth(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := ah.eraseAccount(claims.Username); err != nil {
+			returnError(c, err)
+			return
+		}
+		logrus.Info(claims.Username, " erased their account")
+		c.JSON(http.StatusAccepted, nil)
+	}
+}
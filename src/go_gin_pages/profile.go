@@ -0,0 +1,185 @@
+package go_gin_pages
+
+import (
+	"fmt"
+	"net/http"
+
+	"tick_test/repository"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/blobstore"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/imaging"
+
+	"github.com/gin-gonic/gin"
+)
+
+const avatarWidth = 256
+
+type profileHandler struct {
+	profiles       *service.ProfileService
+	accounts       repository.AccountRepository
+	store          blobstore.Store
+	maxSize        int64
+	accountHandler *accountHandler
+}
+
+func NewProfileHandler(profileRepo repository.ProfileRepository, accountRepo repository.AccountRepository, store blobstore.Store, maxSize int64) (res *profileHandler) {
+	return &profileHandler{
+		profiles: service.NewProfileService(profileRepo),
+		accounts: accountRepo,
+		store:    store,
+		maxSize:  maxSize,
+	}
+}
+
+func (ph *profileHandler) avatarKey(username string) (string, error) {
+	id, err := ph.accounts.FindAccountIdByUsername(username)
+	if err != nil {
+		return "", err
+	}
+	return service.AvatarKey(id), nil
+}
+
+func (ph *profileHandler) GetMeHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := ph.accountHandler.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		profile, err := ph.profiles.Get(claims.Username)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, profile)
+	}
+}
+
+func (ph *profileHandler) PatchMeHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := ph.accountHandler.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		var data types.ProfilePatchData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		profile, err := ph.profiles.Update(claims.Username, data)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, profile)
+	}
+}
+
+func (ph *profileHandler) PostAvatarHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := ph.accountHandler.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		_, format, img, err := readImageUpload(c, "avatar", ph.maxSize)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		key, err := ph.avatarKey(claims.Username)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		avatar, err := imaging.Encode(imaging.Thumbnail(img, avatarWidth), format)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := ph.store.Put(key, avatar); err != nil {
+			returnError(c, storeError(err))
+			return
+		}
+		if err := ph.profiles.SetAvatar(claims.Username, true); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, nil)
+	}
+}
+
+func (ph *profileHandler) DeleteAvatarHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := ph.accountHandler.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		key, err := ph.avatarKey(claims.Username)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := ph.store.Delete(key); err != nil {
+			returnError(c, storeError(err))
+			return
+		}
+		if err := ph.profiles.SetAvatar(claims.Username, false); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, nil)
+	}
+}
+
+func (ph *profileHandler) GetPublicProfileHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		profile, err := ph.profiles.Get(c.Param("username"))
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, profile.Public())
+	}
+}
+
+func (ph *profileHandler) GetAvatarHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, err := ph.avatarKey(c.Param("username"))
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		data, modTime, err := ph.store.Get(key)
+		if err != nil {
+			returnError(c, storeError(err))
+			return
+		}
+		serveImage(c, data, modTime, "public, max-age=300")
+	}
+}
+
+func (ph *profileHandler) GetAdminProfileHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		profile, err := ph.profiles.Get(c.Param("username"))
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, profile)
+	}
+}
+
+func (ph *profileHandler) prepareProfile(route *gin.RouterGroup) {
+	route.GET("/me", ph.GetMeHandler())
+	route.PATCH("/me", ph.PatchMeHandler())
+	route.POST("/me/avatar", ph.PostAvatarHandler())
+	route.DELETE("/me/avatar", ph.DeleteAvatarHandler())
+	route.GET("/profiles/:username", ph.GetPublicProfileHandler())
+	route.GET("/profiles/:username/avatar", ph.GetAvatarHandler())
+	route.GET("/admin/profiles/:username", requireScope(types.AdminScope), ph.accountHandler.requirePermission(types.AccountViewPermission), ph.GetAdminProfileHandler())
+}
@@ -0,0 +1,83 @@
+package go_gin_pages
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultRecommendationLimit = 10
+	maxRecommendationLimit     = 50
+)
+
+type recommendationHandler struct {
+	service        *service.RecommendationService
+	accountHandler *accountHandler
+}
+
+func NewRecommendationHandler(recommendationService *service.RecommendationService) (res *recommendationHandler) {
+	return &recommendationHandler{
+		service: recommendationService,
+	}
+}
+
+func recommendationLimit(c *gin.Context) (limit int, err error) {
+	limit = defaultRecommendationLimit
+	if c.Query("limit") == "" {
+		return
+	}
+	limit, err = strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 || limit > maxRecommendationLimit {
+		err = fmt.Errorf("%w: limit must be between 1 and %d", errDefs.ErrBadRequest, maxRecommendationLimit)
+	}
+	return
+}
+
+func (rh *recommendationHandler) getSimilarHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := recommendationLimit(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		recommendations, err := rh.service.Similar(c.Param("code"), limit)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, recommendations)
+	}
+}
+
+func (rh *recommendationHandler) getRecommendedHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := rh.accountHandler.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		limit, err := recommendationLimit(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		recommendations, err := rh.service.Recommended(claims.Username, limit)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, recommendations)
+	}
+}
+
+func (rh *recommendationHandler) prepareRecommendation(route *gin.RouterGroup) {
+	route.GET("/code/:code/similar", rh.getSimilarHandler())
+	route.GET("/recommended", requireScope(types.BooksReadScope), rh.getRecommendedHandler())
+}
@@ -0,0 +1,115 @@
+package go_gin_pages
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func (ah *accountHandler) GetInvitesHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		invites, err := ah.registration.Invites()
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, invites)
+	}
+}
+
+// PostInviteHandler issues an invite code. Inviting anyone but a User grants
+// a role, so it takes what promoting an account to that role would: the
+// promote permission and every permission of the role.
+func (ah *accountHandler) PostInviteHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var data types.InvitePostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		claims := claimsFromContext(c)
+		if data.Role != "" && types.Role(data.Role) != types.UserRole {
+			if !ah.authz.RoleExists(types.Role(data.Role)) {
+				returnError(c, fmt.Errorf("%w: no role %s", errDefs.ErrBadRequest, data.Role))
+				return
+			}
+			if !ah.authz.HasPermission(claims.Role, types.AccountPromotePermission) {
+				returnError(c, fmt.Errorf("%w: permission %s required to invite role %s", errDefs.ErrForbidden, types.AccountPromotePermission, data.Role))
+				return
+			}
+			if !ah.authz.Covers(claims.Role, types.Role(data.Role)) {
+				returnError(c, fmt.Errorf("%w: role %s holds permissions role %s lacks", errDefs.ErrForbidden, data.Role, claims.Role))
+				return
+			}
+		}
+		invite, err := ah.registration.Invite(claims.Username, data)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		logrus.Info(claims.Username, " invited a new ", invite.Role)
+		c.JSON(http.StatusCreated, invite)
+	}
+}
+
+func (ah *accountHandler) DeleteInviteHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			returnError(c, fmt.Errorf("%w: parameter id needs to be a number", errDefs.ErrBadRequest))
+			return
+		}
+		if err := ah.registration.RevokeInvite(id); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, nil)
+	}
+}
+
+func (ah *accountHandler) GetPendingAccountsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accounts, err := ah.registration.Pending()
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, accounts)
+	}
+}
+
+func (ah *accountHandler) ApprovePendingAccountHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("username")
+		if err := ah.registration.Approve(username); err != nil {
+			returnError(c, err)
+			return
+		}
+		logrus.Info(claimsFromContext(c).Username, " approved account ", username)
+		c.JSON(http.StatusOK, nil)
+	}
+}
+
+// RejectPendingAccountHandler deletes an account whose registration awaits
+// approval; approved accounts are deleted through DELETE /admin/:username.
+func (ah *accountHandler) RejectPendingAccountHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("username")
+		if _, err := ah.registration.PendingAccount(username); err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := ah.eraseAccount(username); err != nil {
+			returnError(c, err)
+			return
+		}
+		logrus.Info(claimsFromContext(c).Username, " rejected account ", username)
+		c.JSON(http.StatusAccepted, nil)
+	}
+}
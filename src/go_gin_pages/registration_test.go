@@ -0,0 +1,70 @@
+package go_gin_pages_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ginPages "tick_test/go_gin_pages"
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostInviteHandler(t *testing.T) {
+	testCases := []struct {
+		name           string
+		callerRole     types.Role
+		body           string
+		expectedStatus int
+	}{
+		{name: "User invite", callerRole: "Clerk", body: `{}`, expectedStatus: http.StatusCreated},
+		{name: "Role covered", callerRole: "Manager", body: `{"role":"BookKeeper"}`, expectedStatus: http.StatusCreated},
+		{name: "Role not covered", callerRole: "Clerk", body: `{"role":"BookKeeper"}`, expectedStatus: http.StatusForbidden},
+		{name: "No promote permission", callerRole: types.BookKeeperRole, body: `{"role":"BookKeeper"}`, expectedStatus: http.StatusForbidden},
+		{name: "Admin by Manager", callerRole: "Manager", body: `{"role":"Admin"}`, expectedStatus: http.StatusForbidden},
+		{name: "Admin by Admin", callerRole: types.AdminRole, body: `{"role":"Admin"}`, expectedStatus: http.StatusCreated},
+		{name: "Unknown role", callerRole: types.AdminRole, body: `{"role":"Nobody"}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			created := false
+			ah := ginPages.NewAccountHandler(&mocks.AccountRepositoryMock{})
+			require.NoError(t, ah.UseRoles(&mocks.RoleRepositoryMock{
+				FindRolesFn: func() ([]types.RoleInfo, error) {
+					return []types.RoleInfo{
+						{Name: types.UserRole, Permissions: []string{}},
+						{Name: types.AdminRole, Permissions: []string{}},
+						{Name: types.BookKeeperRole, Permissions: []string{types.BookManagePermission}},
+						{Name: "Manager", Permissions: []string{types.AccountPromotePermission, types.BookManagePermission}},
+						{Name: "Clerk", Permissions: []string{types.AccountPromotePermission}},
+					}, nil
+				},
+			}))
+			ah.UseRegistration(&mocks.RegistrationRepositoryMock{
+				CreateInviteFn: func(invite types.Invite, codeHash string) (int64, error) {
+					created = true
+					return 1, nil
+				},
+			})
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/admin/invites", strings.NewReader(tc.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Set(service.ClaimsContextKey, jwt.Claims{Username: "caller", Role: tc.callerRole})
+
+			ah.PostInviteHandler()(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedStatus == http.StatusCreated, created)
+		})
+	}
+}
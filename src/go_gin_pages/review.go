@@ -0,0 +1,115 @@
+package go_gin_pages
+
+import (
+	"fmt"
+	"net/http"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/gin-gonic/gin"
+)
+
+type reviewHandler struct {
+	repo           repository.ReviewRepository
+	accountHandler *accountHandler
+}
+
+func NewReviewHandler(reviewRepo repository.ReviewRepository) (res *reviewHandler) {
+	return &reviewHandler{
+		repo: reviewRepo,
+	}
+}
+
+func (rh *reviewHandler) getReviewsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reviews, err := rh.repo.FindReviewsForBook(c.Param("code"))
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, reviews)
+	}
+}
+
+func (rh *reviewHandler) getRatingHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rating, err := rh.repo.FindBookRating(c.Param("code"))
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, rating)
+	}
+}
+
+func (rh *reviewHandler) postReviewHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := rh.accountHandler.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		var data types.ReviewPostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+
+		review, err := rh.repo.SaveReview(c.Param("code"), claims.Username, &data)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, review)
+	}
+}
+
+func (rh *reviewHandler) patchReviewHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := rh.accountHandler.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		var data types.ReviewPatchData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+
+		review, err := rh.repo.UpdateReview(c.Param("code"), claims.Username, &data)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, review)
+	}
+}
+
+func (rh *reviewHandler) deleteReviewHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rowsAffected, err := rh.repo.RemoveReview(c.Param("code"), c.Param("username"))
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		if rowsAffected > 0 {
+			c.JSON(http.StatusAccepted, nil)
+		} else {
+			c.JSON(http.StatusOK, nil)
+		}
+	}
+}
+
+func (rh *reviewHandler) prepareReview(route *gin.RouterGroup) {
+	route.GET("/rating", rh.getRatingHandler())
+	route.GET("/reviews", rh.getReviewsHandler())
+	route.POST("/reviews", requireScope(types.ReviewsWriteScope), rh.postReviewHandler())
+	route.PATCH("/reviews", requireScope(types.ReviewsWriteScope), rh.patchReviewHandler())
+	route.DELETE("/reviews/:username", requireScope(types.ReviewsWriteScope), rh.accountHandler.requirePermission(types.ReviewModeratePermission), rh.deleteReviewHandler())
+}
@@ -0,0 +1,55 @@
+package go_gin_pages
+
+import (
+	"fmt"
+	"net/http"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/gin-gonic/gin"
+)
+
+type revisionHandler struct {
+	repo           repository.RevisionRepository
+	accountHandler *accountHandler
+}
+
+func NewRevisionHandler(revisionRepo repository.RevisionRepository) (res *revisionHandler) {
+	return &revisionHandler{
+		repo: revisionRepo,
+	}
+}
+
+func (rh *revisionHandler) getHistoryHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		revisions, err := rh.repo.FindBookRevisions(c.Param("code"))
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, revisions)
+	}
+}
+
+func (rh *revisionHandler) postRevertHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var data types.BookRevertPostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		book, err := rh.repo.RevertBook(c.Param("code"), *data.Revision, claimsFromContext(c).Username)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, book)
+	}
+}
+
+func (rh *revisionHandler) prepareRevision(route *gin.RouterGroup) {
+	route.GET("/history", rh.getHistoryHandler())
+	route.POST("/history/revert", requireScope(types.BooksWriteScope), rh.accountHandler.requirePermission(types.BookManagePermission), rh.postRevertHandler())
+}
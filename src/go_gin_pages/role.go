@@ -0,0 +1,103 @@
+package go_gin_pages
+
+import (
+	"fmt"
+	"net/http"
+
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/gin-gonic/gin"
+)
+
+type roleHandler struct {
+	authz          *service.Authorizer
+	accountHandler *accountHandler
+}
+
+func NewRoleHandler(authz *service.Authorizer) (res *roleHandler) {
+	return &roleHandler{
+		authz: authz,
+	}
+}
+
+func (rh *roleHandler) getRolesHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roles, err := rh.authz.Roles()
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, roles)
+	}
+}
+
+func (rh *roleHandler) getPermissionsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, types.Permissions)
+	}
+}
+
+func (rh *roleHandler) postRoleHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var data types.RolePostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		if data.Permissions == nil {
+			data.Permissions = make([]string, 0)
+		}
+		if err := rh.authz.CreateRole(claimsFromContext(c).Role, data); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, types.RoleInfo{Name: types.Role(data.Name), Permissions: data.Permissions})
+	}
+}
+
+func (rh *roleHandler) deleteRoleHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := rh.authz.DeleteRole(types.Role(c.Param("name"))); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, nil)
+	}
+}
+
+func (rh *roleHandler) postPermissionHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var data types.PermissionPostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		if err := rh.authz.Grant(claimsFromContext(c).Role, types.Role(c.Param("name")), data.Permission); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, nil)
+	}
+}
+
+func (rh *roleHandler) deletePermissionHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := rh.authz.Revoke(types.Role(c.Param("name")), c.Param("permission")); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, nil)
+	}
+}
+
+func (rh *roleHandler) prepareRole(route *gin.RouterGroup) {
+	route.Use(requireScope(types.AdminScope), rh.accountHandler.requirePermission(types.RoleManagePermission))
+	route.GET("", rh.getRolesHandler())
+	route.GET("/permissions", rh.getPermissionsHandler())
+	route.POST("", rh.postRoleHandler())
+	route.DELETE("/:name", rh.deleteRoleHandler())
+	route.POST("/:name/permissions", rh.postPermissionHandler())
+	route.DELETE("/:name/permissions/:permission", rh.deletePermissionHandler())
+}
@@ -0,0 +1,149 @@
+package go_gin_pages
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func requestDevice(c *gin.Context) types.DeviceInfo {
+	return types.DeviceInfo{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
+
+// recordLogin adds a login attempt on username to its login history; err
+// is nil for a successful login.
+func (ah *accountHandler) recordLogin(c *gin.Context, username string, err error) {
+	if ah.logins != nil {
+		ah.logins.Record(username, requestDevice(c), err)
+	}
+}
+
+func loginHistoryLimit(c *gin.Context) (limit int, err error) {
+	limit = service.DefaultLoginHistoryLimit
+	if c.Query("limit") == "" {
+		return
+	}
+	limit, err = strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 || limit > service.MaxLoginHistoryLimit {
+		err = fmt.Errorf("%w: limit must be between 1 and %d", errDefs.ErrBadRequest, service.MaxLoginHistoryLimit)
+	}
+	return
+}
+
+// accountExists answers 404 for admin requests on unknown accounts, which
+// would otherwise just have no sessions.
+func (ah *accountHandler) accountExists(c *gin.Context, username string) bool {
+	exists, err := ah.repo.UserExists(username)
+	if err != nil {
+		returnError(c, err)
+		return false
+	}
+	if !exists {
+		returnError(c, fmt.Errorf("%w: no account found with the specified username", errDefs.ErrEntityNotFound))
+		return false
+	}
+	return true
+}
+
+func (ah *accountHandler) respondSessions(c *gin.Context, username string, currentId string) {
+	sessions, err := ah.sessions.List(username, currentId)
+	if err != nil {
+		returnError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (ah *accountHandler) respondLoginHistory(c *gin.Context, username string) {
+	limit, err := loginHistoryLimit(c)
+	if err != nil {
+		returnError(c, err)
+		return
+	}
+	events, err := ah.logins.List(username, limit)
+	if err != nil {
+		returnError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+func (ah *accountHandler) GetMySessionsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := ah.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		ah.respondSessions(c, claims.Username, claims.SessionId)
+	}
+}
+
+func (ah *accountHandler) GetMyLoginHistoryHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := ah.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		ah.respondLoginHistory(c, claims.Username)
+	}
+}
+
+func (ah *accountHandler) RevokeMySessionHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := ah.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := ah.revocations.RevokeSession(claims.Username, c.Param("id")); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, nil)
+	}
+}
+
+func (ah *accountHandler) GetAccountSessionsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("username")
+		if !ah.accountExists(c, username) {
+			return
+		}
+		ah.respondSessions(c, username, "")
+	}
+}
+
+func (ah *accountHandler) GetAccountLoginHistoryHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("username")
+		if !ah.accountExists(c, username) {
+			return
+		}
+		ah.respondLoginHistory(c, username)
+	}
+}
+
+func (ah *accountHandler) RevokeAccountSessionHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("username")
+		if err := ah.mayManage(claimsFromContext(c), username); err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := ah.revocations.RevokeSession(username, c.Param("id")); err != nil {
+			returnError(c, err)
+			return
+		}
+		logrus.Info(claimsFromContext(c).Username, " revoked session ", c.Param("id"), " of ", username)
+		c.JSON(http.StatusAccepted, nil)
+	}
+}
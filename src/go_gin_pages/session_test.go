@@ -0,0 +1,262 @@
+package go_gin_pages_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ginPages "tick_test/go_gin_pages"
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionLogin(t *testing.T) {
+	var storedHash string
+	sessions := service.NewSessionService(&mocks.SessionRepositoryMock{
+		CreateSessionFn: func(username string, tokenHash string, device types.DeviceInfo, idleExpiresAt time.Time, absoluteExpiresAt time.Time) error {
+			assert.Equal(t, "john", username)
+			assert.True(t, idleExpiresAt.Before(absoluteExpiresAt))
+			storedHash = tokenHash
+			return nil
+		},
+	})
+	ah := ginPages.NewAccountHandler(&mocks.AccountRepositoryMock{
+		ConfirmAccountFn: func(string, string) error { return nil },
+		FindUserRoleFn:   func(string) (types.Role, error) { return types.UserRole, nil },
+	})
+	ah.UseSessions(sessions)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/login", nil)
+	c.Request.Header.Set("Username", "john")
+	c.Request.Header.Set("Password", "password123")
+
+	ah.LoginHandler()(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var tokens types.TokenPair
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	assert.NotContains(t, tokens.AccessToken, ".", "sessions are opaque, not JWTs")
+	assert.Empty(t, tokens.RefreshToken)
+	assert.Equal(t, int64(service.DefaultSessionIdleTimeout.Seconds()), tokens.ExpiresIn)
+	assert.Equal(t, service.HashToken(tokens.AccessToken), storedHash, "only the hash is stored")
+}
+
+func TestSessionValidation(t *testing.T) {
+	testCases := []struct {
+		name           string
+		touchErr       error
+		expectedStatus int
+	}{
+		{name: "Success", expectedStatus: http.StatusOK},
+		{name: "Fail - expired or revoked", touchErr: fmt.Errorf("%w: session expired or revoked", errDefs.ErrUnauthorized), expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sessions := service.NewSessionService(&mocks.SessionRepositoryMock{
+				TouchSessionFn: func(tokenHash string, now time.Time, idleExpiresAt time.Time) (int64, string, types.Role, error) {
+					assert.Equal(t, service.HashToken("opaque"), tokenHash)
+					assert.Equal(t, now.Add(service.DefaultSessionIdleTimeout), idleExpiresAt)
+					if tc.touchErr != nil {
+						return 0, "", "", tc.touchErr
+					}
+					return 7, "john", types.UserRole, nil
+				},
+				FindActiveSessionsFn: func(username string, now time.Time) ([]types.Session, error) {
+					assert.Equal(t, "john", username)
+					return []types.Session{
+						{Id: types.SessionId(types.ServerSession, "7"), Kind: types.ServerSession},
+						{Id: types.SessionId(types.ServerSession, "8"), Kind: types.ServerSession},
+					}, nil
+				},
+			})
+			ah := ginPages.NewAccountHandler(&mocks.AccountRepositoryMock{})
+			ah.UseSessions(sessions)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/me/sessions", nil)
+			c.Request.Header.Set("User-Token", "opaque")
+
+			ah.GetMySessionsHandler()(c)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			if tc.touchErr == nil {
+				var listed []types.Session
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+				require.Len(t, listed, 2)
+				assert.True(t, listed[0].Current)
+				assert.False(t, listed[1].Current)
+			} else {
+				assert.Contains(t, w.Body.String(), "session expired or revoked")
+			}
+		})
+	}
+}
+
+func TestAccountSessions(t *testing.T) {
+	testCases := []struct {
+		name           string
+		exists         bool
+		expectedStatus int
+	}{
+		{name: "Success", exists: true, expectedStatus: http.StatusOK},
+		{name: "Fail - unknown account", exists: false, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sessions := service.NewSessionService(&mocks.SessionRepositoryMock{
+				FindActiveSessionsFn: func(username string, now time.Time) ([]types.Session, error) {
+					assert.Equal(t, "john", username)
+					return []types.Session{
+						{Id: types.SessionId(types.ServerSession, "7"), Kind: types.ServerSession},
+						{Id: types.SessionId(types.TokenSession, "jti"), Kind: types.TokenSession},
+					}, nil
+				},
+			})
+			ah := ginPages.NewAccountHandler(&mocks.AccountRepositoryMock{
+				UserExistsFn: func(string) (bool, error) { return tc.exists, nil },
+			})
+			ah.UseSessions(sessions)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/sessions/john", nil)
+			c.Params = gin.Params{{Key: "username", Value: "john"}}
+
+			ah.GetAccountSessionsHandler()(c)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			if tc.exists {
+				var listed []types.Session
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+				require.Len(t, listed, 2)
+				assert.False(t, listed[0].Current, "an admin is never on the listed account's session")
+			}
+		})
+	}
+}
+
+func TestRevokeMySession(t *testing.T) {
+	var revoked []string
+	sessions := service.NewSessionService(&mocks.SessionRepositoryMock{
+		TouchSessionFn: func(tokenHash string, now time.Time, idleExpiresAt time.Time) (int64, string, types.Role, error) {
+			return 7, "john", types.UserRole, nil
+		},
+	})
+	ah := ginPages.NewAccountHandler(&mocks.AccountRepositoryMock{})
+	ah.UseSessions(sessions)
+	ah.UseRevocations(service.NewRevocationStore(&mocks.RevocationRepositoryMock{
+		RevokeAccountSessionFn: func(username string, kind types.SessionKind, key string) error {
+			revoked = append(revoked, username+"/"+key)
+			return nil
+		},
+	}, time.Minute))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/me/sessions/8", nil)
+	c.Request.Header.Set("User-Token", "opaque")
+	c.Params = gin.Params{{Key: "id", Value: types.SessionId(types.ServerSession, "8")}}
+
+	ah.RevokeMySessionHandler()(c)
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, []string{"john/8"}, revoked, "only sessions of the caller are revoked")
+}
+
+func TestRevokeAccountSession(t *testing.T) {
+	testCases := []struct {
+		name           string
+		caller         string
+		target         string
+		expectedStatus int
+	}{
+		{name: "Success", caller: "mod", target: "john", expectedStatus: http.StatusAccepted},
+		{name: "Success - Admin on Admin", caller: "root", target: "boss", expectedStatus: http.StatusAccepted},
+		{name: "Fail - Admin target", caller: "mod", target: "boss", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			acted := false
+			ah := newAdminHandler(t, &acted)
+			var revoked []string
+			ah.UseRevocations(service.NewRevocationStore(&mocks.RevocationRepositoryMock{
+				RevokeAccountSessionFn: func(username string, kind types.SessionKind, key string) error {
+					revoked = append(revoked, username+"/"+key)
+					return nil
+				},
+			}, time.Minute))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodDelete, "/admin/sessions/"+tc.target+"/8", nil)
+			c.Params = gin.Params{{Key: "username", Value: tc.target}, {Key: "id", Value: types.SessionId(types.ServerSession, "8")}}
+			c.Set(service.ClaimsContextKey, jwt.Claims{Username: tc.caller, Role: adminTestRoles[tc.caller]})
+
+			ah.RevokeAccountSessionHandler()(c)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusAccepted {
+				assert.Equal(t, []string{tc.target + "/8"}, revoked)
+			} else {
+				assert.Empty(t, revoked)
+			}
+		})
+	}
+}
+
+func TestFailedLoginsRecorded(t *testing.T) {
+	testCases := []struct {
+		name    string
+		handler func(ah *ginPages.AccountHandler) gin.HandlerFunc
+	}{
+		{name: "Login", handler: (*ginPages.AccountHandler).LoginHandler},
+		{name: "Password header", handler: (*ginPages.AccountHandler).GetMySessionsHandler},
+		{name: "Patch account", handler: (*ginPages.AccountHandler).PatchAccountHandler},
+		{name: "Delete account", handler: (*ginPages.AccountHandler).DeleteAccountHandler},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var recorded []types.LoginEvent
+			ah := ginPages.NewAccountHandler(&mocks.AccountRepositoryMock{
+				ConfirmAccountFn: func(string, string) error { return errDefs.ErrUnauthorized },
+				UserExistsFn:     func(string) (bool, error) { return true, nil },
+			})
+			ah.UseLoginHistory(service.NewLoginHistory(&mocks.LoginHistoryRepositoryMock{
+				SaveLoginEventFn: func(username string, event types.LoginEvent) error {
+					assert.Equal(t, "john", username)
+					recorded = append(recorded, event)
+					return nil
+				},
+			}))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/accounts", nil)
+			c.Request.Header.Set("Username", "john")
+			c.Request.Header.Set("Password", "wrong")
+
+			tc.handler(ah)(c)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			require.Len(t, recorded, 1)
+			assert.False(t, recorded[0].Succeeded)
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package go_gin_pages
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/gin-gonic/gin"
+)
+
+type shelfHandler struct {
+	repo           repository.ShelfRepository
+	accountHandler *accountHandler
+}
+
+func NewShelfHandler(shelfRepo repository.ShelfRepository) (res *shelfHandler) {
+	return &shelfHandler{
+		repo: shelfRepo,
+	}
+}
+
+func canViewShelf(shelf types.Shelf, username string) bool {
+	switch {
+	case shelf.Owner == username, shelf.Visibility == types.PublicShelf:
+		return true
+	case shelf.Visibility == types.SharedShelf:
+		return slices.Contains(shelf.SharedWith, username)
+	default:
+		return false
+	}
+}
+
+// ownedShelf resolves the :id parameter to a shelf owned by the authenticated user.
+func (sh *shelfHandler) ownedShelf(c *gin.Context) (shelf types.Shelf, err error) {
+	claims, err := sh.accountHandler.ConfirmAccountFromGinContext(c)
+	if err != nil {
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return shelf, fmt.Errorf("%w: parameter id needs to be a number", errDefs.ErrBadRequest)
+	}
+	if shelf, err = sh.repo.FindShelf(id); err != nil {
+		return
+	}
+	if shelf.Owner != claims.Username {
+		return shelf, fmt.Errorf("%w: only the owner can modify shelf %d", errDefs.ErrUnauthorized, id)
+	}
+	return shelf, nil
+}
+
+func (sh *shelfHandler) getShelvesHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := sh.accountHandler.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := sh.repo.EnsureDefaultShelves(claims.Username); err != nil {
+			returnError(c, err)
+			return
+		}
+		shelves, err := sh.repo.FindShelvesForUser(claims.Username)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, shelves)
+	}
+}
+
+func (sh *shelfHandler) getPublicShelvesHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shelves, err := sh.repo.FindPublicShelves()
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, shelves)
+	}
+}
+
+func (sh *shelfHandler) getShelfHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			returnError(c, fmt.Errorf("%w: parameter id needs to be a number", errDefs.ErrBadRequest))
+			return
+		}
+		shelf, err := sh.repo.FindShelf(id)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if shelf.Visibility != types.PublicShelf {
+			claims, err := sh.accountHandler.ConfirmAccountFromGinContext(c)
+			if err != nil {
+				returnError(c, err)
+				return
+			}
+			if !canViewShelf(shelf, claims.Username) {
+				returnError(c, fmt.Errorf("%w: shelf %d is not shared with you", errDefs.ErrUnauthorized, id))
+				return
+			}
+		}
+		c.JSON(http.StatusOK, shelf)
+	}
+}
+
+func (sh *shelfHandler) postShelfHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := sh.accountHandler.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		var data types.ShelfPostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		shelf, err := sh.repo.CreateShelf(claims.Username, &data)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, shelf)
+	}
+}
+
+func (sh *shelfHandler) patchShelfHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shelf, err := sh.ownedShelf(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		var data types.ShelfPatchData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		shelf, err = sh.repo.UpdateShelf(shelf.Id, &data)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, shelf)
+	}
+}
+
+func (sh *shelfHandler) deleteShelfHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shelf, err := sh.ownedShelf(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		rowsAffected, err := sh.repo.RemoveShelf(shelf.Id)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if rowsAffected > 0 {
+			c.JSON(http.StatusAccepted, nil)
+		} else {
+			c.JSON(http.StatusOK, nil)
+		}
+	}
+}
+
+func (sh *shelfHandler) postShelfItemHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shelf, err := sh.ownedShelf(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		var data types.ShelfItemPostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		shelf, err = sh.repo.AddBookToShelf(shelf.Id, &data)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, shelf)
+	}
+}
+
+func (sh *shelfHandler) patchShelfItemHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shelf, err := sh.ownedShelf(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		var data types.ShelfItemPatchData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		shelf, err = sh.repo.UpdateShelfItem(shelf.Id, c.Param("code"), &data)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, shelf)
+	}
+}
+
+func (sh *shelfHandler) deleteShelfItemHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shelf, err := sh.ownedShelf(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		rowsAffected, err := sh.repo.RemoveBookFromShelf(shelf.Id, c.Param("code"))
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if rowsAffected > 0 {
+			c.JSON(http.StatusAccepted, nil)
+		} else {
+			c.JSON(http.StatusOK, nil)
+		}
+	}
+}
+
+func (sh *shelfHandler) putShelfOrderHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shelf, err := sh.ownedShelf(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		var data types.ShelfOrderData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		shelf, err = sh.repo.ReorderShelf(shelf.Id, data.Books)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, shelf)
+	}
+}
+
+func (sh *shelfHandler) prepareShelf(route *gin.RouterGroup) {
+	route.GET("", requireScope(types.ShelvesReadScope), sh.getShelvesHandler())
+	route.GET("/public", sh.getPublicShelvesHandler())
+	route.POST("", requireScope(types.ShelvesWriteScope), sh.postShelfHandler())
+	route.GET("/id/:id", requireScope(types.ShelvesReadScope), sh.getShelfHandler())
+	route.PATCH("/id/:id", requireScope(types.ShelvesWriteScope), sh.patchShelfHandler())
+	route.DELETE("/id/:id", requireScope(types.ShelvesWriteScope), sh.deleteShelfHandler())
+	route.POST("/id/:id/books", requireScope(types.ShelvesWriteScope), sh.postShelfItemHandler())
+	route.PATCH("/id/:id/books/:code", requireScope(types.ShelvesWriteScope), sh.patchShelfItemHandler())
+	route.DELETE("/id/:id/books/:code", requireScope(types.ShelvesWriteScope), sh.deleteShelfItemHandler())
+	route.PUT("/id/:id/order", requireScope(types.ShelvesWriteScope), sh.putShelfOrderHandler())
+}
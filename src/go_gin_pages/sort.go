@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"tick_test/types"
-	"tick_test/utils/random"
+	"tick_test/utils/codegen"
 	"tick_test/utils/sorting"
 
 	"github.com/gin-gonic/gin"
@@ -51,7 +51,11 @@ func makeSortFunction[T any](fn func(a0 T, a1 T) bool, sortType string) func(c *
 	registerSortType(sortType)
 	return func(c *gin.Context) {
 		var arr []T
-		var code = random.RandSeq(80)
+		code, err := codegen.Generate(codegen.SortCodes)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			return
+		}
 		startedAt := time.Now().UTC()
 		var calcResult = new(intensiveCalculationResult)
 		*calcResult = intensiveCalculationResult{
@@ -171,7 +175,11 @@ func sortIntensivelyCalculatedObjectForComparation(c *gin.Context) {
 		cmpArr[index] = item
 	}
 
-	var code = random.RandSeq(80)
+	code, err := codegen.Generate(codegen.SortCodes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
 	startedAt := time.Now().UTC()
 	var calcResult = new(intensiveCalculationResult)
 	*calcResult = intensiveCalculationResult{
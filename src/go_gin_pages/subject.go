@@ -0,0 +1,190 @@
+package go_gin_pages
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/gin-gonic/gin"
+)
+
+type subjectHandler struct {
+	repo           repository.SubjectRepository
+	bookRepo       repository.BookRepository
+	accountHandler *accountHandler
+}
+
+func NewSubjectHandler(subjectRepo repository.SubjectRepository, bookRepo repository.BookRepository) (res *subjectHandler) {
+	return &subjectHandler{
+		repo:     subjectRepo,
+		bookRepo: bookRepo,
+	}
+}
+
+func subjectIdParam(c *gin.Context, name string) (int64, error) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: parameter %s needs to be a number", errDefs.ErrBadRequest, name)
+	}
+	return id, nil
+}
+
+func (sh *subjectHandler) getSubjectTreeHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roots, err := sh.repo.FindSubjectTree()
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, roots)
+	}
+}
+
+func (sh *subjectHandler) getSubjectHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := subjectIdParam(c, "id")
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		subject, err := sh.repo.FindSubject(id)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, subject)
+	}
+}
+
+func (sh *subjectHandler) getSubjectBooksHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := subjectIdParam(c, "id")
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		respondSubjectBooks(c, sh.bookRepo, id)
+	}
+}
+
+func (sh *subjectHandler) postSubjectHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var data types.SubjectPostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		subject, err := sh.repo.CreateSubject(&data)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, subject)
+	}
+}
+
+func (sh *subjectHandler) patchSubjectHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := subjectIdParam(c, "id")
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		var data types.SubjectPatchData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		subject, err := sh.repo.UpdateSubject(id, &data)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, subject)
+	}
+}
+
+func (sh *subjectHandler) deleteSubjectHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := subjectIdParam(c, "id")
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		rowsAffected, err := sh.repo.RemoveSubject(id)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if rowsAffected > 0 {
+			c.JSON(http.StatusAccepted, nil)
+		} else {
+			c.JSON(http.StatusOK, nil)
+		}
+	}
+}
+
+func (sh *subjectHandler) getBookSubjectsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subjects, err := sh.repo.FindSubjectsForBook(c.Param("code"))
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, subjects)
+	}
+}
+
+func (sh *subjectHandler) postBookSubjectHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var data types.BookSubjectPostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		if err := sh.repo.AssignSubjectToBook(c.Param("code"), data.SubjectId); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, nil)
+	}
+}
+
+func (sh *subjectHandler) deleteBookSubjectHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := subjectIdParam(c, "id")
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		rowsAffected, err := sh.repo.UnassignSubjectFromBook(c.Param("code"), id)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if rowsAffected > 0 {
+			c.JSON(http.StatusAccepted, nil)
+		} else {
+			c.JSON(http.StatusOK, nil)
+		}
+	}
+}
+
+func (sh *subjectHandler) prepareSubject(route *gin.RouterGroup) {
+	route.GET("", sh.getSubjectTreeHandler())
+	route.GET("/:id", sh.getSubjectHandler())
+	route.GET("/:id/books", sh.getSubjectBooksHandler())
+	route.POST("", requireScope(types.BooksWriteScope), sh.accountHandler.requirePermission(types.SubjectManagePermission), sh.postSubjectHandler())
+	route.PATCH("/:id", requireScope(types.BooksWriteScope), sh.accountHandler.requirePermission(types.SubjectManagePermission), sh.patchSubjectHandler())
+	route.DELETE("/:id", requireScope(types.BooksWriteScope), sh.accountHandler.requirePermission(types.SubjectManagePermission), sh.deleteSubjectHandler())
+}
+
+func (sh *subjectHandler) prepareBookSubject(route *gin.RouterGroup) {
+	route.GET("/subjects", sh.getBookSubjectsHandler())
+	route.POST("/subjects", requireScope(types.BooksWriteScope), sh.accountHandler.requirePermission(types.SubjectManagePermission), sh.postBookSubjectHandler())
+	route.DELETE("/subjects/:id", requireScope(types.BooksWriteScope), sh.accountHandler.requirePermission(types.SubjectManagePermission), sh.deleteBookSubjectHandler())
+}
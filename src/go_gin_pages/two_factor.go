@@ -0,0 +1,218 @@
+package go_gin_pages
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// confirmSecondFactor requires the Otp-Code header from accounts with
+// two-factor authentication when they authenticate with the Password header.
+// It follows confirmCredentials and completes the login when it passes;
+// failures are added to the login history.
+func (ah *accountHandler) confirmSecondFactor(c *gin.Context, username string) error {
+	if err := ah.checkSecondFactor(c, username); err != nil {
+		ah.recordLogin(c, username, err)
+		return err
+	}
+	ah.loginSucceeded(username)
+	return nil
+}
+
+func (ah *accountHandler) checkSecondFactor(c *gin.Context, username string) error {
+	if ah.twoFactor == nil {
+		return nil
+	}
+	enabled, err := ah.twoFactor.Enabled(username)
+	if err != nil || !enabled {
+		return err
+	}
+	code := c.GetHeader("Otp-Code")
+	if code == "" {
+		return fmt.Errorf("%w: two-factor code required in header Otp-Code", errDefs.ErrUnauthorized)
+	}
+	return ah.verifyTwoFactorCode(c, username, code)
+}
+
+// verifyTwoFactorCode counts wrong codes against the login throttle, so that
+// codes cannot be guessed faster than passwords.
+func (ah *accountHandler) verifyTwoFactorCode(c *gin.Context, username string, code string) error {
+	if ah.throttle != nil {
+		if err := ah.throttle.Allow(username, c.ClientIP()); err != nil {
+			return err
+		}
+	}
+	err := ah.twoFactor.Verify(username, code)
+	if ah.throttle != nil && errors.Is(err, errDefs.ErrUnauthorized) {
+		ah.throttle.Fail(username, c.ClientIP())
+	}
+	return err
+}
+
+// requireTwoFactor refuses accounts of roles that must use two-factor
+// authentication until they have enabled it.
+func (ah *accountHandler) requireTwoFactor(claims jwt.Claims) error {
+	if ah.twoFactor == nil || !ah.twoFactor.Required(claims.Role) {
+		return nil
+	}
+	enabled, err := ah.twoFactor.Enabled(claims.Username)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return fmt.Errorf("%w: role %s requires two-factor authentication; enroll at /v1/accounts/2fa/enroll", errDefs.ErrForbidden, claims.Role)
+	}
+	return nil
+}
+
+func (ah *accountHandler) twoFactorEnabled(c *gin.Context) bool {
+	if ah.twoFactor == nil {
+		returnError(c, fmt.Errorf("%w: two-factor authentication is disabled", errDefs.ErrEntityNotFound))
+		return false
+	}
+	return true
+}
+
+func (ah *accountHandler) TwoFactorLoginHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ah.twoFactorEnabled(c) {
+			return
+		}
+		var data types.TwoFactorLoginPostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		username, err := ah.twoFactor.Pending(data.MfaToken)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if ah.throttle != nil {
+			if err := ah.throttle.Allow(username, c.ClientIP()); err != nil {
+				ah.recordLogin(c, username, err)
+				returnError(c, err)
+				return
+			}
+		}
+		_, role, err := ah.twoFactor.CompleteChallenge(data.MfaToken, data.Code)
+		if err != nil {
+			if ah.throttle != nil && errors.Is(err, errDefs.ErrUnauthorized) {
+				ah.throttle.Fail(username, c.ClientIP())
+			}
+			ah.recordLogin(c, username, err)
+			returnError(c, err)
+			return
+		}
+		ah.loginSucceeded(username)
+		ah.completeLogin(c, username, role)
+	}
+}
+
+func (ah *accountHandler) EnrollTwoFactorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ah.twoFactorEnabled(c) {
+			return
+		}
+		claims, err := ah.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		enrollment, err := ah.twoFactor.Enroll(claims.Username)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, enrollment)
+	}
+}
+
+func (ah *accountHandler) ConfirmTwoFactorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ah.twoFactorEnabled(c) {
+			return
+		}
+		claims, err := ah.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		var data types.TotpCodePostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		codes, err := ah.twoFactor.Confirm(claims.Username, data.Code)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, types.RecoveryCodes{RecoveryCodes: codes})
+	}
+}
+
+func (ah *accountHandler) RegenerateRecoveryCodesHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ah.twoFactorEnabled(c) {
+			return
+		}
+		claims, err := ah.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		var data types.TotpCodePostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		if err := ah.verifyTwoFactorCode(c, claims.Username, data.Code); err != nil {
+			returnError(c, err)
+			return
+		}
+		codes, err := ah.twoFactor.RegenerateRecoveryCodes(claims.Username)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, types.RecoveryCodes{RecoveryCodes: codes})
+	}
+}
+
+func (ah *accountHandler) DisableTwoFactorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ah.twoFactorEnabled(c) {
+			return
+		}
+		claims, err := ah.ConfirmAccountFromGinContext(c)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		if ah.twoFactor.Required(claims.Role) {
+			returnError(c, fmt.Errorf("%w: role %s requires two-factor authentication", errDefs.ErrForbidden, claims.Role))
+			return
+		}
+		var data types.TotpCodePostData
+		if err := c.ShouldBindJSON(&data); err != nil {
+			returnError(c, fmt.Errorf("%w: %v", errDefs.ErrBadRequest, err.Error()))
+			return
+		}
+		if err := ah.verifyTwoFactorCode(c, claims.Username, data.Code); err != nil {
+			returnError(c, err)
+			return
+		}
+		if err := ah.twoFactor.Disable(claims.Username); err != nil {
+			returnError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, nil)
+	}
+}
@@ -0,0 +1,122 @@
+package go_gin_pages_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ginPages "tick_test/go_gin_pages"
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// twoFactorAccountHandler accepts any password of an account with two-factor
+// authentication enabled, and locks it out after three failures.
+func twoFactorAccountHandler() *ginPages.AccountHandler {
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	ah := ginPages.NewAccountHandler(&mocks.AccountRepositoryMock{
+		ConfirmAccountFn: func(string, string) error { return nil },
+		FindUserRoleFn:   func(string) (types.Role, error) { return types.UserRole, nil },
+	})
+	throttle := service.NewLoginThrottle()
+	throttle.MaxUserFailures, throttle.BaseDelay = 3, 0
+	throttle.Now = func() time.Time { return now }
+	ah.UseThrottle(throttle)
+	twoFactor := service.NewTwoFactorService(&mocks.TwoFactorRepositoryMock{
+		FindTotpFn: func(string) (types.TotpState, error) {
+			return types.TotpState{Secret: "JBSWY3DPEHPK3PXP", Enabled: true}, nil
+		},
+	})
+	twoFactor.Now = func() time.Time { return now }
+	ah.UseTwoFactor(twoFactor)
+	return ah
+}
+
+func TestWrongSecondFactorLocksOut(t *testing.T) {
+	ah := twoFactorAccountHandler()
+	patch := func() int {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPatch, "/modify", strings.NewReader(`{}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Request.Header.Set("Username", "john")
+		c.Request.Header.Set("Password", "correct password")
+		c.Request.Header.Set("Otp-Code", "000000")
+		ah.PatchAccountHandler()(c)
+		return w.Code
+	}
+
+	for range 3 {
+		require.Equal(t, http.StatusUnauthorized, patch())
+	}
+	assert.Equal(t, http.StatusTooManyRequests, patch(), "a correct password must not reset the failures")
+}
+
+func TestWrongLoginChallengeCodeLocksOut(t *testing.T) {
+	ah := twoFactorAccountHandler()
+	login := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/login", nil)
+		c.Request.Header.Set("Username", "john")
+		c.Request.Header.Set("Password", "correct password")
+		ah.LoginHandler()(c)
+		return w
+	}
+
+	for range 3 {
+		w := login()
+		require.Equal(t, http.StatusAccepted, w.Code)
+		var challenge types.TwoFactorChallenge
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &challenge))
+
+		w = httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/login/2fa", strings.NewReader(`{"mfaToken":"`+challenge.MfaToken+`","code":"000000"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		ah.TwoFactorLoginHandler()(c)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, login().Code)
+}
+
+func TestWrongCodeToManageTwoFactorLocksOut(t *testing.T) {
+	jwt.SetSecretKey([]byte("RU5DT0RFRF9TRUNSRVRfVEVYVA=="))
+	token, _ := jwt.GenerateToken("john", types.UserRole, time.Minute)
+	testCases := []struct {
+		name    string
+		handler func(ah *ginPages.AccountHandler) gin.HandlerFunc
+	}{
+		{name: "Disable", handler: (*ginPages.AccountHandler).DisableTwoFactorHandler},
+		{name: "Regenerate recovery codes", handler: (*ginPages.AccountHandler).RegenerateRecoveryCodesHandler},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ah := twoFactorAccountHandler()
+			send := func() int {
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = httptest.NewRequest(http.MethodPost, "/2fa", strings.NewReader(`{"code":"000000"}`))
+				c.Request.Header.Set("Content-Type", "application/json")
+				c.Request.Header.Set("User-Token", token)
+				tc.handler(ah)(c)
+				return w.Code
+			}
+
+			for range 3 {
+				require.Equal(t, http.StatusUnauthorized, send())
+			}
+			assert.Equal(t, http.StatusTooManyRequests, send())
+		})
+	}
+}
@@ -7,15 +7,214 @@ import (
 )
 
 type Config struct {
-	DBPath  string `yaml:"dbPath"`
-	BaseURL string `yaml:"baseURL"`
-	Port    string `yaml:"port"`
+	DBPath  string                `yaml:"dbPath"`
+	BaseURL string                `yaml:"baseURL"`
+	Port    string                `yaml:"port"`
+	Covers  CoverConfig           `yaml:"covers"`
+	Avatars AvatarConfig          `yaml:"avatars"`
+	Codes   map[string]CodeConfig `yaml:"codes"`
+
+	Recommendations RecommendationConfig  `yaml:"recommendations"`
+	Auth            AuthConfig            `yaml:"auth"`
+	PasswordPolicy  PasswordPolicyConfig  `yaml:"passwordPolicy"`
+	PasswordHashing PasswordHashingConfig `yaml:"passwordHashing"`
+	Erasure         ErasureConfig         `yaml:"erasure"`
+	Registration    RegistrationConfig    `yaml:"registration"`
+}
+
+// RegistrationConfig decides who may register: Mode is "open",
+// "invite-code" or "approval-required". InviteLifetime is the ISO8601
+// duration invite codes stay valid unless the invite sets its own.
+type RegistrationConfig struct {
+	Mode           string `yaml:"mode"`
+	InviteLifetime string `yaml:"inviteLifetime"`
+}
+
+// PasswordHashingConfig selects how passwords are stored: "argon2id" with
+// Memory in KiB, Iterations, Parallelism and the lengths in bytes, or
+// "bcrypt" with BcryptCost. Hashes made with another algorithm or other
+// parameters are replaced when their owner next logs in.
+type PasswordHashingConfig struct {
+	Algorithm   string `yaml:"algorithm"`
+	Memory      uint32 `yaml:"memory"`
+	Iterations  uint32 `yaml:"iterations"`
+	Parallelism uint8  `yaml:"parallelism"`
+	SaltLength  uint32 `yaml:"saltLength"`
+	KeyLength   uint32 `yaml:"keyLength"`
+	BcryptCost  int    `yaml:"bcryptCost"`
+}
+
+// ErasureConfig decides what happens to the data other accounts can still
+// see when an account is deleted. SentMessages, ReceivedMessages and Reviews
+// are "delete" or "anonymize"; Revisions are "anonymize" or "keep".
+type ErasureConfig struct {
+	SentMessages     string `yaml:"sentMessages"`
+	ReceivedMessages string `yaml:"receivedMessages"`
+	Reviews          string `yaml:"reviews"`
+	Revisions        string `yaml:"revisions"`
+}
+
+// PasswordPolicyConfig lists the rules new passwords must follow.
+// RequiredClasses may contain lower, upper, digit and symbol; History is how
+// many previous passwords of an account may not be reused.
+type PasswordPolicyConfig struct {
+	MinLength        int      `yaml:"minLength"`
+	RequiredClasses  []string `yaml:"requiredClasses"`
+	MinScore         int      `yaml:"minScore"`
+	Blacklist        []string `yaml:"blacklist"`
+	BlacklistFile    string   `yaml:"blacklistFile"`
+	DisallowUsername bool     `yaml:"disallowUsername"`
+	History          int      `yaml:"history"`
+}
+
+// AuthConfig selects how logins are represented, "jwt" or "session", and
+// holds lifetimes as ISO8601 durations, e.g. PT30M or P30D.
+type AuthConfig struct {
+	Mode                 string              `yaml:"mode"`
+	AccessTokenLifetime  string              `yaml:"accessTokenLifetime"`
+	RefreshTokenLifetime string              `yaml:"refreshTokenLifetime"`
+	SessionIdleTimeout   string              `yaml:"sessionIdleTimeout"`
+	SessionMaxLifetime   string              `yaml:"sessionMaxLifetime"`
+	Lockout              LockoutConfig       `yaml:"lockout"`
+	TwoFactor            TwoFactorConfig     `yaml:"twoFactor"`
+	PasswordReset        PasswordResetConfig `yaml:"passwordReset"`
+	Signing              SigningConfig       `yaml:"signing"`
+	LoginHistory         LoginHistoryConfig  `yaml:"loginHistory"`
+}
+
+// LoginHistoryConfig sets how long login attempts are kept.
+type LoginHistoryConfig struct {
+	Retention string `yaml:"retention"`
+}
+
+// SigningConfig selects how access tokens are signed: "EdDSA" or "RS256"
+// with private keys kept as PEM files in KeyDir, or "HS256" with the shared
+// JWT_SECRET_KEY. An empty RotationInterval disables key rotation;
+// RetiredKeyLifetime needs to cover the access token lifetime.
+type SigningConfig struct {
+	Algorithm          string `yaml:"algorithm"`
+	KeyDir             string `yaml:"keyDir"`
+	RotationInterval   string `yaml:"rotationInterval"`
+	RetiredKeyLifetime string `yaml:"retiredKeyLifetime"`
+}
+
+// PasswordResetConfig sets how long reset tokens stay valid and how long a
+// user has to wait between two reset requests.
+type PasswordResetConfig struct {
+	TokenLifetime   string `yaml:"tokenLifetime"`
+	RequestCooldown string `yaml:"requestCooldown"`
+}
+
+// TwoFactorConfig sets up TOTP two-factor authentication. Accounts of the
+// RequiredForRoles roles are refused by role-protected endpoints until they
+// enable it.
+type TwoFactorConfig struct {
+	Issuer            string   `yaml:"issuer"`
+	RequiredForRoles  []string `yaml:"requiredForRoles"`
+	ChallengeLifetime string   `yaml:"challengeLifetime"`
+}
+
+// LockoutConfig limits failed logins. Every failure doubles the wait before
+// the next attempt, starting at BaseDelay and capped at MaxDelay; reaching
+// MaxUserFailures for a username or MaxIPFailures for a client locks it out
+// for Duration.
+type LockoutConfig struct {
+	MaxUserFailures int    `yaml:"maxUserFailures"`
+	MaxIPFailures   int    `yaml:"maxIPFailures"`
+	BaseDelay       string `yaml:"baseDelay"`
+	MaxDelay        string `yaml:"maxDelay"`
+	Duration        string `yaml:"duration"`
+}
+
+type CodeConfig struct {
+	Strategy string `yaml:"strategy"`
+	Length   int    `yaml:"length"`
+	Prefix   string `yaml:"prefix"`
+}
+
+type RecommendationConfig struct {
+	// RefreshInterval is an ISO8601 duration, e.g. PT1H
+	RefreshInterval string `yaml:"refreshInterval"`
+}
+
+type AvatarConfig struct {
+	MaxSize int64 `yaml:"maxSize"`
+}
+
+type CoverConfig struct {
+	Dir     string `yaml:"dir"`
+	MaxSize int64  `yaml:"maxSize"`
 }
 
 func GetConfig(path string) (cfg *Config, err error) {
 	data, err := os.ReadFile(path)
 	cfg = &Config{
 		Port: "4041",
+		Covers: CoverConfig{
+			Dir:     "../.data/covers",
+			MaxSize: 5 << 20,
+		},
+		Avatars: AvatarConfig{
+			MaxSize: 1 << 20,
+		},
+		Recommendations: RecommendationConfig{
+			RefreshInterval: "PT1H",
+		},
+		Auth: AuthConfig{
+			Mode:                 "jwt",
+			AccessTokenLifetime:  "PT30M",
+			RefreshTokenLifetime: "P30D",
+			SessionIdleTimeout:   "PT30M",
+			SessionMaxLifetime:   "P30D",
+			Lockout: LockoutConfig{
+				MaxUserFailures: 5,
+				MaxIPFailures:   20,
+				BaseDelay:       "PT1S",
+				MaxDelay:        "PT5M",
+				Duration:        "PT15M",
+			},
+			TwoFactor: TwoFactorConfig{
+				Issuer:            "tick_test",
+				ChallengeLifetime: "PT5M",
+			},
+			PasswordReset: PasswordResetConfig{
+				TokenLifetime:   "PT1H",
+				RequestCooldown: "PT1M",
+			},
+			Signing: SigningConfig{
+				Algorithm:          "EdDSA",
+				KeyDir:             "../.config/jwt-keys",
+				RotationInterval:   "P30D",
+				RetiredKeyLifetime: "P1D",
+			},
+			LoginHistory: LoginHistoryConfig{
+				Retention: "P90D",
+			},
+		},
+		PasswordPolicy: PasswordPolicyConfig{
+			MinLength:        8,
+			DisallowUsername: true,
+			History:          5,
+		},
+		PasswordHashing: PasswordHashingConfig{
+			Algorithm:   "argon2id",
+			Memory:      19456,
+			Iterations:  2,
+			Parallelism: 1,
+			SaltLength:  16,
+			KeyLength:   32,
+			BcryptCost:  12,
+		},
+		Erasure: ErasureConfig{
+			SentMessages:     "anonymize",
+			ReceivedMessages: "delete",
+			Reviews:          "anonymize",
+			Revisions:        "anonymize",
+		},
+		Registration: RegistrationConfig{
+			Mode:           "open",
+			InviteLifetime: "P7D",
+		},
 	}
 	if err != nil {
 		return
@@ -7,6 +7,7 @@ import (
 	"tick_test/go_gin_pages"
 	"tick_test/internal/config"
 	"tick_test/repository"
+	"tick_test/utils/codegen"
 	"tick_test/utils/jwt"
 
 	"github.com/gin-gonic/gin"
@@ -27,8 +28,20 @@ func setupRepository() (repo repository.Repository, err error) {
 	return repository.NewRepo(db), nil
 }
 
+func setupCodeGenerators(cfg *config.Config) error {
+	for kind, codeCfg := range cfg.Codes {
+		generator, err := codegen.New(codeCfg.Strategy, codeCfg.Length, codeCfg.Prefix)
+		if err != nil {
+			return fmt.Errorf("codes.%s: %w", kind, err)
+		}
+		codegen.Set(codegen.Kind(kind), generator)
+	}
+	return nil
+}
+
 func main() {
 	configPath := flag.String("c", "config.yaml", "Path to config file")
+	createAdmin := flag.String("create-admin", "", "Create the first admin with this username and the password in ADMIN_PASSWORD, then exit")
 	flag.Parse()
 	logrus.Debug("PATH", *configPath)
 	cfg, err := config.GetConfig(*configPath)
@@ -37,6 +50,10 @@ func main() {
 		os.Exit(2)
 	}
 	url := go_gin_pages.UseConfigToDetermineURL(cfg)
+	if err := setupCodeGenerators(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
 
 	jwt.SetSecretKey([]byte(os.Getenv("JWT_SECRET_KEY")))
 	repo, err := setupRepository()
@@ -44,10 +61,21 @@ func main() {
 		fmt.Fprintln(os.Stderr, "Repository setup failed:", err)
 		os.Exit(1)
 	}
+	if *createAdmin != "" {
+		if err := go_gin_pages.CreateFirstAdmin(repo, cfg, *createAdmin, os.Getenv("ADMIN_PASSWORD")); err != nil {
+			fmt.Fprintln(os.Stderr, "Creating admin failed:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Created admin", *createAdmin)
+		return
+	}
 
 	ginServer := gin.Default()
 	ginServer.UseRawPath = true
 
-	go_gin_pages.Prepare(ginServer, url, repo)
+	if err := go_gin_pages.Prepare(ginServer, url, repo, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
 	ginServer.Run(url)
 }
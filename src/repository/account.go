@@ -10,9 +10,9 @@ import (
 	"tick_test/types"
 	errDefs "tick_test/utils/errDefs"
 	"tick_test/utils/jwt"
+	"tick_test/utils/passhash"
 
 	"github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type AccountRepository interface {
@@ -24,6 +24,7 @@ type AccountRepository interface {
 	FindPaginatedAccounts(pageSize int, pageNumber int) (accounts []types.AccountGetData, err error)
 	ConfirmNoAdmins() (adminCount int, err error)
 	SaveAccount(obj *types.AccountPostData) (err error)
+	CreateFirstAdmin(obj *types.AccountPostData) (err error)
 	DeleteAccount(username string) error
 	UpdateExistingAccount(username string, obj *types.AccountPatchData) (rowsAffected int64, err error)
 	PromoteExistingAccount(obj *types.AccountPatchPromoteData) (err error)
@@ -31,6 +32,8 @@ type AccountRepository interface {
 	ValidateToken(token string) (jwt.Claims, error)
 	GenerateTokenForUser(username string) (token string, err error)
 	IsAdmin(token string) (bool, error)
+	SaveRefreshToken(username string, family string, clientId string, tokenHash string, device types.DeviceInfo, expiresAt time.Time) (err error)
+	RotateRefreshToken(oldHash string, newHash string, clientId string, expiresAt time.Time) (rotated RotatedRefreshToken, err error)
 }
 
 func validateCredential(cred string, credName string) (err error) {
@@ -43,19 +46,40 @@ func validateCredential(cred string, credName string) (err error) {
 	return
 }
 
+var passwordHasher passhash.Hasher = passhash.DefaultArgon2id
+
+// SetPasswordHasher installs the hasher for new passwords. Passwords stored
+// with another one keep working and are re-hashed at their next login.
+func SetPasswordHasher(hasher passhash.Hasher) {
+	passwordHasher = hasher
+}
+
 func hashPassword(password string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(hashedBytes), err
+	return passwordHasher.Hash(password)
 }
 
 func confirmPassword(password string, hash string) (err error) {
-	bycryptErr := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	if bycryptErr != nil {
-		return fmt.Errorf("%w: %v", errDefs.ErrUnauthorized, bycryptErr)
+	if verifyErr := passhash.Verify(password, hash); verifyErr != nil {
+		return fmt.Errorf("%w: %v", errDefs.ErrUnauthorized, verifyErr)
 	}
 	return nil
 }
 
+// rehashPassword replaces an outdated hash of the password that was just
+// confirmed. A password changed in the meantime is left alone, and failing
+// is only logged since the login itself succeeded.
+func (r *repo) rehashPassword(username string, password string, oldHash string) {
+	hashedPassword, err := hashPassword(password)
+	if err == nil {
+		_, err = r.DB.Conn.Exec(`
+			UPDATE account SET password = $1 WHERE username = $2 AND password = $3
+		`, hashedPassword, username, oldHash)
+	}
+	if err != nil {
+		logrus.Error("re-hashing password of ", username, ": ", err)
+	}
+}
+
 func (r *repo) UserExists(username string) (exists bool, err error) {
 	query := `SELECT EXISTS(SELECT 1 FROM account WHERE username = $1);`
 	err = r.DB.Conn.QueryRow(query, username).Scan(&exists)
@@ -136,8 +160,20 @@ func (r *repo) ConfirmAccount(username string, password string) (err error) {
 		if err = rows.Scan(&hash); err != nil {
 			return
 		}
-		err = confirmPassword(password, hash)
-		return
+		if err = confirmPassword(password, hash); err != nil {
+			return
+		}
+		rows.Close()
+		if err = r.checkSuspension(username); err != nil {
+			return
+		}
+		if err = r.checkApproval(username); err != nil {
+			return
+		}
+		if passwordHasher.NeedsRehash(hash) {
+			r.rehashPassword(username, password, hash)
+		}
+		return nil
 	}
 
 	if err = rows.Err(); err != nil {
@@ -243,16 +279,28 @@ func (r *repo) ConfirmNoAdmins() (adminCount int, err error) {
 	return
 }
 
-func (r *repo) SaveAccount(obj *types.AccountPostData) (err error) {
+// validateNewAccount checks the username and password of an account about
+// to be created.
+func validateNewAccount(obj *types.AccountPostData) (err error) {
 	if err = validateCredential(obj.Username, "Username"); err != nil {
 		return
 	}
 	if err = validateCredential(obj.Password, "Password"); err != nil {
 		return
 	}
+	if err = checkReservedUsername(obj.Username); err != nil {
+		return
+	}
 	if obj.Password != obj.SamePassword {
 		return fmt.Errorf("%w: field `Password` differs from field `SamePassword`", errDefs.ErrBadRequest)
 	}
+	return checkPasswordPolicy(obj.Username, obj.Password, nil)
+}
+
+func (r *repo) SaveAccount(obj *types.AccountPostData) (err error) {
+	if err = validateNewAccount(obj); err != nil {
+		return
+	}
 
 	var exists bool
 	checkQuery := `SELECT EXISTS(SELECT 1 FROM account WHERE username = $1);`
@@ -264,10 +312,7 @@ func (r *repo) SaveAccount(obj *types.AccountPostData) (err error) {
 		return fmt.Errorf("%w; user with username %s", errDefs.ErrDoesExist, obj.Username)
 	}
 	if obj.Role == "Admin" {
-		_, err = r.ConfirmNoAdmins()
-		if err != nil {
-			return
-		}
+		return fmt.Errorf("%w: admins are only created with CreateFirstAdmin or by promotion", errDefs.ErrForbidden)
 	}
 
 	query := `
@@ -288,12 +333,89 @@ func (r *repo) SaveAccount(obj *types.AccountPostData) (err error) {
 	return
 }
 
+// CreateFirstAdmin creates the first admin of a fresh installation. The
+// account table is locked against writes while checking that there is no
+// admin yet, so concurrent calls cannot both succeed.
+func (r *repo) CreateFirstAdmin(obj *types.AccountPostData) error {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	if err := validateNewAccount(obj); err != nil {
+		return err
+	}
+	hashedPassword, err := hashPassword(obj.Password)
+	if err != nil {
+		return err
+	}
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return fmt.Errorf("error creating admin: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`LOCK TABLE account IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("error creating admin: %w", err)
+	}
+	var adminExists, exists bool
+	err = tx.QueryRow(`
+		SELECT
+			EXISTS(SELECT 1 FROM account a JOIN role ro ON a.role_id = ro.id WHERE ro.name = 'Admin'),
+			EXISTS(SELECT 1 FROM account WHERE username = $1)
+	`, obj.Username).Scan(&adminExists, &exists)
+	if err != nil {
+		return fmt.Errorf("error creating admin: %w", err)
+	}
+	if adminExists {
+		return fmt.Errorf("%w: an admin already exists", errDefs.ErrDoesExist)
+	}
+	if exists {
+		return fmt.Errorf("%w; user with username %s", errDefs.ErrDoesExist, obj.Username)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO account (username, password, role_id)
+		VALUES ($1, $2, (SELECT id FROM role WHERE name = 'Admin'))
+	`, obj.Username, hashedPassword)
+	if err != nil {
+		return fmt.Errorf("error creating admin: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error creating admin: %w", err)
+	}
+	logrus.Info("new admin ", obj.Username)
+	return nil
+}
+
+// DeleteAccount erases the account. What becomes of the messages, reviews
+// and revisions it left is decided by the erasure policy; all other data of
+// the account is deleted with it.
 func (r *repo) DeleteAccount(username string) error {
-	_, err := r.DB.Conn.Exec(`DELETE FROM account WHERE username = $1`, username)
-	logrus.Info("deleted account ", username)
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	tx, err := r.DB.Conn.Begin()
 	if err != nil {
 		return fmt.Errorf("error deleting account: %w", err)
 	}
+	defer tx.Rollback()
+
+	var accountId int64
+	err = tx.QueryRow(`SELECT id FROM account WHERE username = $1 FOR UPDATE`, username).Scan(&accountId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error deleting account: %w", err)
+	}
+	if err = eraseReferences(tx, accountId, username); err != nil {
+		return fmt.Errorf("error deleting account: %w", err)
+	}
+	if _, err = tx.Exec(`DELETE FROM account WHERE id = $1`, accountId); err != nil {
+		return fmt.Errorf("error deleting account: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error deleting account: %w", err)
+	}
+	logrus.Info("deleted account ", username)
 	return nil
 }
 
@@ -305,21 +427,16 @@ func (r *repo) UpdateExistingAccount(username string, obj *types.AccountPatchDat
 	if err = validateCredential(obj.Password, "Password"); err != nil {
 		return 0, err
 	}
+	if err = checkReservedUsername(obj.Username); err != nil {
+		return 0, err
+	}
 	if obj.Password != obj.SamePassword {
 		return 0, fmt.Errorf("%w: field `Password` differs from field `SamePassword`", errDefs.ErrBadRequest)
 	}
-	if obj.Password != "" && len(obj.Password) < 8 {
-		return 0, fmt.Errorf("%w: field `Password` is too short; excepted lenght at least 8", errDefs.ErrBadRequest)
-	}
 
 	// apply changes
 	if obj.Password != "" {
-		hashedPassword, err := hashPassword(obj.Password)
-		if err != nil {
-			return 0, err
-		}
-		_, err = r.DB.Conn.Exec(`UPDATE account SET password = $1 WHERE username = $2`, hashedPassword, username)
-		if err != nil {
+		if err = r.changePassword(username, obj.Username, obj.Password); err != nil {
 			return 0, err
 		}
 	}
@@ -337,6 +454,43 @@ func (r *repo) UpdateExistingAccount(username string, obj *types.AccountPatchDat
 	return
 }
 
+func (r *repo) changePassword(username string, newUsername string, password string) (err error) {
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	history, err := r.findPasswordHistory(tx, username)
+	if err != nil {
+		return err
+	}
+	policyUsername := username
+	if newUsername != "" {
+		policyUsername = newUsername
+	}
+	if err = checkPasswordPolicy(policyUsername, password, history); err != nil {
+		return err
+	}
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err = r.replacePassword(tx, username, history[0], hashedPassword); err != nil {
+		return err
+	}
+	// whoever knew the old password may hold tokens issued with it
+	cutoff, err := r.revokeAllTokens(tx, username)
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	notifyTokensRevoked(cutoff)
+	return nil
+}
+
 func (r *repo) PromoteExistingAccount(obj *types.AccountPatchPromoteData) (err error) {
 	// verify valid input
 	var count int
@@ -6,6 +6,8 @@ import (
 	"testing"
 	"tick_test/repository"
 	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/passhash"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/require"
@@ -74,7 +76,8 @@ func TestUserExists(t *testing.T) {
 
 func TestConfirmAccount(t *testing.T) {
 	validPassword := "password123"
-	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(validPassword), bcrypt.DefaultCost)
+	hashedPassword, _ := passhash.DefaultArgon2id.Hash(validPassword)
+	bcryptPassword, _ := bcrypt.GenerateFromPassword([]byte(validPassword), bcrypt.MinCost)
 
 	tests := []struct {
 		name           string
@@ -82,6 +85,7 @@ func TestConfirmAccount(t *testing.T) {
 		inputPassword  string
 		dbPassword     string
 		dbRowsReturned bool
+		expectRehash   bool
 		expectError    bool
 		expectedErrMsg string
 	}{
@@ -89,10 +93,19 @@ func TestConfirmAccount(t *testing.T) {
 			name:           "Valid credentials",
 			username:       "john",
 			inputPassword:  validPassword,
-			dbPassword:     string(hashedPassword),
+			dbPassword:     hashedPassword,
 			dbRowsReturned: true,
 			expectError:    false,
 		},
+		{
+			name:           "Valid credentials with outdated hash",
+			username:       "john",
+			inputPassword:  validPassword,
+			dbPassword:     string(bcryptPassword),
+			dbRowsReturned: true,
+			expectRehash:   true,
+			expectError:    false,
+		},
 		{
 			name:           "Invalid password",
 			username:       "john",
@@ -127,6 +140,19 @@ func TestConfirmAccount(t *testing.T) {
 			} else {
 				expect.WillReturnRows(sqlmock.NewRows([]string{"password"}))
 			}
+			if tt.dbRowsReturned && !tt.expectError {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT s.reason, s.until FROM account_suspension s`)).
+					WithArgs(tt.username, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"reason", "until"}))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM pending_account p`)).
+					WithArgs(tt.username).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			}
+			if tt.expectRehash {
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE account SET password = $1 WHERE username = $2 AND password = $3`)).
+					WithArgs(sqlmock.AnyArg(), tt.username, tt.dbPassword).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
 
 			err := r.ConfirmAccount(tt.username, tt.inputPassword)
 
@@ -138,6 +164,7 @@ func TestConfirmAccount(t *testing.T) {
 				}
 			} else {
 				require.NoError(t, err)
+				require.NoError(t, mock.ExpectationsWereMet())
 			}
 		})
 	}
@@ -346,6 +373,65 @@ func TestSaveAccount(t *testing.T) {
 	}
 }
 
+func TestSaveAccountRefusesAdmin(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM account WHERE username = $1);`)).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	err := r.SaveAccount(&types.AccountPostData{Username: "john", Password: "password123", SamePassword: "password123", Role: "Admin"})
+	require.ErrorIs(t, err, errDefs.ErrForbidden)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateFirstAdmin(t *testing.T) {
+	tests := []struct {
+		name        string
+		adminExists bool
+		userExists  bool
+		expectedErr error
+	}{
+		{name: "Success"},
+		{name: "Admin exists", adminExists: true, expectedErr: errDefs.ErrDoesExist},
+		{name: "Username taken", userExists: true, expectedErr: errDefs.ErrDoesExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rMock, mock := setupMock(t)
+			r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+			defer r.DB.Conn.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(`LOCK TABLE account IN SHARE ROW EXCLUSIVE MODE`)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(`SELECT\s+EXISTS\(SELECT 1 FROM account a JOIN role ro`).
+				WithArgs("root").
+				WillReturnRows(sqlmock.NewRows([]string{"admin", "exists"}).AddRow(tt.adminExists, tt.userExists))
+			if tt.expectedErr == nil {
+				mock.ExpectExec(`INSERT INTO account \(username, password, role_id\)`).
+					WithArgs("root", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			err := r.CreateFirstAdmin(&types.AccountPostData{Username: "root", Password: "password123", SamePassword: "password123", Role: "Admin"})
+
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 func TestDeleteAccount(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -374,13 +460,36 @@ func TestDeleteAccount(t *testing.T) {
 			r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
 			defer r.DB.Conn.Close()
 
-			query := regexp.QuoteMeta(`DELETE FROM account WHERE username = $1`)
-			exec := mock.ExpectExec(query).WithArgs(tt.username)
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM account WHERE username = $1 FOR UPDATE`)).
+				WithArgs(tt.username).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			// the default policy anonymizes sent messages and deletes received ones
+			mock.ExpectExec(regexp.QuoteMeta(`UPDATE messages SET from_user = NULL WHERE from_user = $1`)).
+				WithArgs(int64(3)).
+				WillReturnResult(sqlmock.NewResult(0, 2))
+			mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM messages WHERE to_user = $1`)).
+				WithArgs(int64(3)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM messages WHERE from_user IS NULL AND to_user IS NULL`)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(`UPDATE review SET account_id = NULL WHERE account_id = $1`)).
+				WithArgs(int64(3)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(regexp.QuoteMeta(`UPDATE book_revision SET editor = $1 WHERE editor = $2`)).
+				WithArgs(types.ErasedUsername, tt.username).
+				WillReturnResult(sqlmock.NewResult(0, 4))
+			mock.ExpectExec(regexp.QuoteMeta(`UPDATE account_suspension SET suspended_by = $1 WHERE suspended_by = $2`)).
+				WithArgs(types.ErasedUsername, tt.username).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			exec := mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM account WHERE id = $1`)).WithArgs(int64(3))
 
 			if tt.mockError != nil {
 				exec.WillReturnError(tt.mockError)
+				mock.ExpectRollback()
 			} else {
 				exec.WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
 			}
 
 			err := r.DeleteAccount(tt.username)
@@ -390,8 +499,24 @@ func TestDeleteAccount(t *testing.T) {
 			} else {
 				require.NoError(t, err)
 			}
+			require.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
+
+	t.Run("Unknown account", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM account WHERE username = $1 FOR UPDATE`)).
+			WithArgs("ghost").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		require.NoError(t, r.DeleteAccount("ghost"))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestPromoteExistingAccount(t *testing.T) {
@@ -439,3 +564,56 @@ func TestFindUserRole(t *testing.T) {
 		require.Equal(t, types.Role("Admin"), role)
 	})
 }
+
+func TestUpdateExistingAccountRevokesTokens(t *testing.T) {
+	current, _ := bcrypt.GenerateFromPassword([]byte("current-password"), bcrypt.MinCost)
+
+	tests := []struct {
+		name        string
+		revokeErr   error
+		expectError bool
+	}{
+		{name: "Success"},
+		{name: "Failing revocation keeps the old password", revokeErr: errors.New("connection reset"), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rMock, mock := setupMock(t)
+			r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+			defer r.DB.Conn.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, password FROM account WHERE username = $1 FOR UPDATE`)).
+				WithArgs("john").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(1, string(current)))
+			mock.ExpectQuery(regexp.QuoteMeta(`UPDATE account SET password = $1 WHERE username = $2 RETURNING id`)).
+				WithArgs(sqlmock.AnyArg(), "john").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM password_history WHERE account_id = $1`)).
+				WithArgs(1).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			if tt.revokeErr != nil {
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO token_cutoff`)).WillReturnError(tt.revokeErr)
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO token_cutoff`)).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE refresh_token SET revoked_at = $2`)).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE session SET revoked_at = $2`)).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			}
+
+			_, err := r.UpdateExistingAccount("john", &types.AccountPatchData{
+				Password:     "brand-new-password",
+				SamePassword: "brand-new-password",
+			})
+
+			if tt.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/lib/pq"
+)
+
+type ApiKeyRepository interface {
+	CreateApiKey(username string, key types.ApiKey, keyHash string) (id int64, err error)
+	FindApiKeys(username string) (keys []types.ApiKey, err error)
+	AuthenticateApiKey(keyHash string, now time.Time) (username string, role types.Role, scopes []string, err error)
+	RevokeApiKey(username string, id int64) (err error)
+}
+
+func (r *repo) CreateApiKey(username string, key types.ApiKey, keyHash string) (id int64, err error) {
+	if r.DB.Conn == nil {
+		return 0, errDefs.ErrDatabaseOffline
+	}
+	err = r.DB.Conn.QueryRow(`
+		INSERT INTO api_key (account_id, name, prefix, key_hash, scopes, created_at, expires_at)
+		VALUES ((SELECT id FROM account WHERE username = $1), $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, username, key.Name, key.Prefix, keyHash, pq.Array(key.Scopes), key.CreatedAt, key.ExpiresAt).Scan(&id)
+	return
+}
+
+func (r *repo) FindApiKeys(username string) (keys []types.ApiKey, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	rows, err := r.DB.Conn.Query(`
+		SELECT k.id, k.name, k.prefix, k.scopes, k.created_at, k.expires_at, k.last_used_at
+		FROM api_key k JOIN account a ON a.id = k.account_id
+		WHERE a.username = $1 AND k.revoked_at IS NULL
+		ORDER BY k.id
+	`, username)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	keys = make([]types.ApiKey, 0)
+	for rows.Next() {
+		var key types.ApiKey
+		var expiresAt, lastUsedAt sql.NullString
+		if err = rows.Scan(&key.Id, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedAt, &expiresAt, &lastUsedAt); err != nil {
+			return nil, err
+		}
+		if expiresAt.Valid {
+			key.ExpiresAt = &expiresAt.String
+		}
+		if lastUsedAt.Valid {
+			key.LastUsedAt = &lastUsedAt.String
+		}
+		keys = append(keys, key)
+	}
+	err = rows.Err()
+	return
+}
+
+// AuthenticateApiKey finds the owner of a valid key and records its use.
+func (r *repo) AuthenticateApiKey(keyHash string, now time.Time) (username string, role types.Role, scopes []string, err error) {
+	if r.DB.Conn == nil {
+		return "", "", nil, errDefs.ErrDatabaseOffline
+	}
+	timestamp := now.UTC().Format(time.RFC3339)
+	err = r.DB.Conn.QueryRow(`
+		UPDATE api_key k
+		SET last_used_at = $2
+		FROM account a JOIN role ro ON a.role_id = ro.id
+		WHERE k.account_id = a.id AND k.key_hash = $1 AND k.revoked_at IS NULL
+			AND (k.expires_at IS NULL OR k.expires_at > $2)
+		RETURNING a.username, ro.name, k.scopes
+	`, keyHash, timestamp).Scan(&username, &role, pq.Array(&scopes))
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil, fmt.Errorf("%w: API key invalid, expired or revoked", errDefs.ErrUnauthorized)
+	}
+	return
+}
+
+func (r *repo) RevokeApiKey(username string, id int64) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	result, err := r.DB.Conn.Exec(`
+		UPDATE api_key SET revoked_at = $3
+		WHERE id = $2 AND revoked_at IS NULL AND account_id = (SELECT id FROM account WHERE username = $1)
+	`, username, id, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: no API key %d", errDefs.ErrEntityNotFound, id)
+	}
+	return
+}
+
+func (r *repo) doPostgresPreparationForApiKey() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS api_key (
+				id SERIAL PRIMARY KEY,
+				account_id INTEGER NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+				name varchar(100) NOT NULL,
+				prefix varchar(20) NOT NULL,
+				key_hash char(64) UNIQUE NOT NULL,
+				scopes TEXT[] NOT NULL,
+				created_at varchar(30) NOT NULL,
+				expires_at varchar(30),
+				last_used_at varchar(30),
+				revoked_at varchar(30)
+			);
+		`)
+		logPossibleError(err)
+	}
+}
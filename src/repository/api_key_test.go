@@ -0,0 +1,61 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticateApiKey(t *testing.T) {
+	update := regexp.QuoteMeta(`UPDATE api_key k SET last_used_at = $2`)
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectQuery(update).
+			WithArgs("hash", "2025-03-07T12:00:00Z").
+			WillReturnRows(sqlmock.NewRows([]string{"username", "name", "scopes"}).
+				AddRow("robot", "BookKeeper", "{books:read,books:write}"))
+
+		username, role, scopes, err := r.AuthenticateApiKey("hash", now)
+		require.NoError(t, err)
+		require.Equal(t, "robot", username)
+		require.Equal(t, types.BookKeeperRole, role)
+		require.Equal(t, []string{types.BooksReadScope, types.BooksWriteScope}, scopes)
+	})
+
+	t.Run("Invalid, expired or revoked", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectQuery(update).
+			WithArgs("hash", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"username", "name", "scopes"}))
+
+		_, _, _, err := r.AuthenticateApiKey("hash", now)
+		require.ErrorIs(t, err, errDefs.ErrUnauthorized)
+	})
+}
+
+func TestRevokeApiKeyNotFound(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE api_key SET revoked_at = $3`)).
+		WithArgs("robot", int64(3), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.ErrorIs(t, r.RevokeApiKey("robot", 3), errDefs.ErrEntityNotFound)
+}
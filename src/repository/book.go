@@ -1,17 +1,23 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
 	"tick_test/types"
+	"tick_test/utils/codegen"
 	errDefs "tick_test/utils/errDefs"
 )
 
 type BookRepository interface {
 	FindAllBooks() (books []types.Book, err error)
+	FindAllBooksByRating() (books []types.RatedBook, err error)
+	FindBooksInSubject(subjectId int64) (books []types.Book, err error)
+	FindBooksInSubjectByRating(subjectId int64) (books []types.RatedBook, err error)
 	FindPaginatedBooks(pageSize int, pageNumber int) (books []types.Book, err error)
+	FindPaginatedBooksByRating(pageSize int, pageNumber int) (books []types.RatedBook, err error)
 	FindBookByCode(code string) (book types.Book, err error)
 	CreateBook(book *types.Book) (err error)
-	UpdateBookByCode(code string, updates types.Book) (book types.Book, err error)
+	UpdateBookByCode(code string, updates types.Book, editor string) (book types.Book, err error)
 	RemoveBookByCode(code string) (n int64, err error)
 }
 
@@ -20,7 +26,7 @@ func (r *repo) FindAllBooks() (books []types.Book, err error) {
 		err = errDefs.ErrDatabaseOffline
 		return
 	}
-	query := `SELECT code, title, author FROM book`
+	query := `SELECT code, title, author, COALESCE(isbn, '') FROM book`
 	rows, err := r.DB.Conn.Query(query)
 	if err != nil {
 		return nil, err
@@ -30,7 +36,7 @@ func (r *repo) FindAllBooks() (books []types.Book, err error) {
 	books = make([]types.Book, 0)
 	for rows.Next() {
 		var book types.Book
-		if err := rows.Scan(&book.Code, &book.Title, &book.Author); err != nil {
+		if err := rows.Scan(&book.Code, &book.Title, &book.Author, &book.Isbn); err != nil {
 			return nil, err
 		}
 		books = append(books, book)
@@ -38,6 +44,62 @@ func (r *repo) FindAllBooks() (books []types.Book, err error) {
 	return books, nil
 }
 
+// ratedBooksQuery selects books with their average rating, highest first;
+// where filters the books and suffix is appended after the ordering.
+func ratedBooksQuery(where string, suffix string) string {
+	return `
+		SELECT b.code, b.title, b.author, COALESCE(AVG(rv.stars), 0) AS average, COUNT(rv.id)
+		FROM book b
+		LEFT JOIN review rv ON rv.book_id = b.id
+		` + where + `
+		GROUP BY b.id
+		ORDER BY average DESC, COUNT(rv.id) DESC, b.id
+		` + suffix
+}
+
+func scanRatedBooks(rows *sql.Rows) (books []types.RatedBook, err error) {
+	defer rows.Close()
+	books = make([]types.RatedBook, 0)
+	for rows.Next() {
+		var book types.RatedBook
+		if err := rows.Scan(&book.Code, &book.Title, &book.Author, &book.AverageRating, &book.RatingCount); err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+	return books, rows.Err()
+}
+
+func (r *repo) FindAllBooksByRating() (books []types.RatedBook, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	rows, err := r.DB.Conn.Query(ratedBooksQuery("", ""))
+	if err != nil {
+		return nil, err
+	}
+	return scanRatedBooks(rows)
+}
+
+func (r *repo) FindPaginatedBooksByRating(pageSize int, pageNumber int) (books []types.RatedBook, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	if pageNumber < 1 {
+		return nil, fmt.Errorf("%w: parameter pageNumbers needs to be 1 or greater but it is %v", errDefs.ErrBadRequest, pageNumber)
+	}
+	if pageSize < 1 {
+		return nil, fmt.Errorf("%w: parameter pageSize needs to be 1 or greater but it is %v", errDefs.ErrBadRequest, pageSize)
+	}
+	rows, err := r.DB.Conn.Query(ratedBooksQuery("", "LIMIT $1 OFFSET $2"), pageSize, (pageNumber-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return scanRatedBooks(rows)
+}
+
 func (r *repo) FindPaginatedBooks(pageSize int, pageNumber int) (books []types.Book, err error) {
 	if r.DB.Conn == nil {
 		err = errDefs.ErrDatabaseOffline
@@ -52,7 +114,7 @@ func (r *repo) FindPaginatedBooks(pageSize int, pageNumber int) (books []types.B
 		return nil, fmt.Errorf("%w: parameter pageSize needs to be 1 or greater but it is %v", errDefs.ErrBadRequest, pageSize)
 	}
 
-	query := `SELECT code, title, author FROM book ORDER BY id LIMIT $1 OFFSET $2`
+	query := `SELECT code, title, author, COALESCE(isbn, '') FROM book ORDER BY id LIMIT $1 OFFSET $2`
 	rows, err := r.DB.Conn.Query(query, pageSize, offset)
 	if err != nil {
 		return nil, err
@@ -62,7 +124,7 @@ func (r *repo) FindPaginatedBooks(pageSize int, pageNumber int) (books []types.B
 	books = make([]types.Book, 0)
 	for rows.Next() {
 		var book types.Book
-		if err := rows.Scan(&book.Code, &book.Title, &book.Author); err != nil {
+		if err := rows.Scan(&book.Code, &book.Title, &book.Author, &book.Isbn); err != nil {
 			return nil, err
 		}
 		books = append(books, book)
@@ -76,9 +138,9 @@ func (r *repo) FindBookByCode(code string) (book types.Book, err error) {
 		return
 	}
 	err = r.DB.Conn.QueryRow(
-		`SELECT code, title, author FROM book WHERE code = $1`,
+		`SELECT code, title, author, COALESCE(isbn, '') FROM book WHERE code = $1`,
 		code,
-	).Scan(&book.Code, &book.Title, &book.Author)
+	).Scan(&book.Code, &book.Title, &book.Author, &book.Isbn)
 
 	if err != nil {
 		return types.Book{}, err
@@ -91,55 +153,64 @@ func (r *repo) CreateBook(book *types.Book) (err error) {
 		err = errDefs.ErrDatabaseOffline
 		return
 	}
-	_, err = r.DB.Conn.Exec(
-		`INSERT INTO book (code, title, author) VALUES ($1, $2, $3)`,
-		book.Code, book.Title, book.Author,
-	)
-	return err
+	for attempt := 1; ; attempt++ {
+		if book.Code == "" {
+			if book.Code, err = codegen.Generate(codegen.BookCodes); err != nil {
+				return
+			}
+		}
+		_, err = r.DB.Conn.Exec(
+			`INSERT INTO book (code, title, author, isbn) VALUES ($1, $2, $3, NULLIF($4, ''))`,
+			book.Code, book.Title, book.Author, book.Isbn,
+		)
+		if !isUniqueViolation(err) {
+			return err
+		}
+		if attempt >= maxCodeAttempts {
+			return fmt.Errorf("%w; book with code %s", errDefs.ErrDoesExist, book.Code)
+		}
+		book.Code = ""
+	}
 }
 
-func (r *repo) UpdateBookByCode(code string, updates types.Book) (book types.Book, err error) {
+func (r *repo) UpdateBookByCode(code string, updates types.Book, editor string) (book types.Book, err error) {
 	if r.DB.Conn == nil {
 		err = errDefs.ErrDatabaseOffline
 		return
 	}
-	var queryFields string
-	params := []interface{}{}
-	paramCount := 1
-
-	if updates.Title != "" {
-		queryFields += fmt.Sprintf("title = $%d, ", paramCount)
-		params = append(params, updates.Title)
-		paramCount++
-	}
-	if updates.Author != "" {
-		queryFields += fmt.Sprintf("author = $%d, ", paramCount)
-		params = append(params, updates.Author)
-		paramCount++
-	}
-
-	if len(params) == 0 {
+	if updates.Title == "" && updates.Author == "" && updates.Isbn == "" {
 		return types.Book{}, fmt.Errorf("%w: no fields to update", errDefs.ErrBadRequest)
 	}
 
-	query := fmt.Sprintf("UPDATE book SET %s WHERE code = $%d", queryFields[:len(queryFields)-2], paramCount)
-	params = append(params, code)
-
-	_, err = r.DB.Conn.Exec(query, params...)
+	tx, err := r.DB.Conn.Begin()
 	if err != nil {
 		return types.Book{}, err
 	}
+	defer tx.Rollback()
 
-	var updatedBook types.Book
-	err = r.DB.Conn.QueryRow(
-		`SELECT code, title, author FROM book WHERE code = $1`,
-		code,
-	).Scan(&updatedBook.Code, &updatedBook.Title, &updatedBook.Author)
+	bookId, before, err := findBookForUpdate(tx, code)
 	if err != nil {
 		return types.Book{}, err
 	}
 
-	return updatedBook, nil
+	book = before
+	if updates.Title != "" {
+		book.Title = updates.Title
+	}
+	if updates.Author != "" {
+		book.Author = updates.Author
+	}
+	if updates.Isbn != "" {
+		book.Isbn = updates.Isbn
+	}
+
+	if err = saveBookRevision(tx, bookId, before, book, editor, nil); err != nil {
+		return types.Book{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return types.Book{}, err
+	}
+	return book, nil
 }
 
 func (r *repo) RemoveBookByCode(code string) (n int64, err error) {
@@ -169,5 +240,10 @@ func (r *repo) doPostgresPreparationForBook() {
 			);
 		`)
 		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`ALTER TABLE book ADD COLUMN IF NOT EXISTS isbn varchar(13)`)
+		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`CREATE INDEX IF NOT EXISTS book_isbn ON book (isbn)`)
+		logPossibleError(err)
+		r.useSequenceForCodes(codegen.BookCodes, "book_code_seq")
 	}
 }
@@ -6,8 +6,10 @@ import (
 	"testing"
 	"tick_test/repository"
 	"tick_test/types"
+	"tick_test/utils/codegen"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
@@ -21,9 +23,9 @@ func TestFindAllBooks(t *testing.T) {
 	}{
 		{
 			name: "Success with multiple books",
-			mockRows: sqlmock.NewRows([]string{"code", "title", "author"}).
-				AddRow("123", "Title 1", "Author 1").
-				AddRow("456", "Title 2", "Author 2"),
+			mockRows: sqlmock.NewRows([]string{"code", "title", "author", "isbn"}).
+				AddRow("123", "Title 1", "Author 1", "").
+				AddRow("456", "Title 2", "Author 2", ""),
 			expectedBooks: []types.Book{
 				{Code: "123", Title: "Title 1", Author: "Author 1"},
 				{Code: "456", Title: "Title 2", Author: "Author 2"},
@@ -32,7 +34,7 @@ func TestFindAllBooks(t *testing.T) {
 		},
 		{
 			name:          "Success with no books",
-			mockRows:      sqlmock.NewRows([]string{"code", "title", "author"}),
+			mockRows:      sqlmock.NewRows([]string{"code", "title", "author", "isbn"}),
 			expectedBooks: []types.Book{},
 			expectError:   false,
 		},
@@ -49,7 +51,7 @@ func TestFindAllBooks(t *testing.T) {
 			r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
 			defer r.DB.Conn.Close()
 
-			query := regexp.QuoteMeta(`SELECT code, title, author FROM book`)
+			query := regexp.QuoteMeta(`SELECT code, title, author, COALESCE(isbn, '') FROM book`)
 			expect := mock.ExpectQuery(query)
 
 			if tt.mockError != nil {
@@ -85,9 +87,9 @@ func TestFindPaginatedBooks(t *testing.T) {
 			name:       "Success with valid page and size",
 			pageSize:   2,
 			pageNumber: 1,
-			mockRows: sqlmock.NewRows([]string{"code", "title", "author"}).
-				AddRow("1", "Book 1", "Author A").
-				AddRow("2", "Book 2", "Author B"),
+			mockRows: sqlmock.NewRows([]string{"code", "title", "author", "isbn"}).
+				AddRow("1", "Book 1", "Author A", "").
+				AddRow("2", "Book 2", "Author B", ""),
 			expectedBooks: []types.Book{
 				{Code: "1", Title: "Book 1", Author: "Author A"},
 				{Code: "2", Title: "Book 2", Author: "Author B"},
@@ -98,7 +100,7 @@ func TestFindPaginatedBooks(t *testing.T) {
 			name:          "Success with empty result",
 			pageSize:      2,
 			pageNumber:    2,
-			mockRows:      sqlmock.NewRows([]string{"code", "title", "author"}),
+			mockRows:      sqlmock.NewRows([]string{"code", "title", "author", "isbn"}),
 			expectedBooks: []types.Book{},
 			expectError:   false,
 		},
@@ -134,7 +136,7 @@ func TestFindPaginatedBooks(t *testing.T) {
 			r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
 			defer r.DB.Conn.Close()
 
-			query := regexp.QuoteMeta(`SELECT code, title, author FROM book ORDER BY id LIMIT $1 OFFSET $2`)
+			query := regexp.QuoteMeta(`SELECT code, title, author, COALESCE(isbn, '') FROM book ORDER BY id LIMIT $1 OFFSET $2`)
 			expect := mock.ExpectQuery(query).WithArgs(tt.pageSize, (tt.pageNumber-1)*tt.pageSize)
 
 			if tt.mockError != nil {
@@ -171,8 +173,8 @@ func TestFindBookByCode(t *testing.T) {
 		{
 			name: "Success",
 			code: "123",
-			mockRow: sqlmock.NewRows([]string{"code", "title", "author"}).
-				AddRow("123", "Title 1", "Author 1"),
+			mockRow: sqlmock.NewRows([]string{"code", "title", "author", "isbn"}).
+				AddRow("123", "Title 1", "Author 1", ""),
 			expectedBook: types.Book{Code: "123", Title: "Title 1", Author: "Author 1"},
 			expectError:  false,
 		},
@@ -198,7 +200,7 @@ func TestFindBookByCode(t *testing.T) {
 			r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
 			defer r.DB.Conn.Close()
 
-			query := regexp.QuoteMeta(`SELECT code, title, author FROM book WHERE code = $1`)
+			query := regexp.QuoteMeta(`SELECT code, title, author, COALESCE(isbn, '') FROM book WHERE code = $1`)
 			expect := mock.ExpectQuery(query).WithArgs(tt.code)
 
 			if tt.mockError != nil {
@@ -258,9 +260,9 @@ func TestCreateBook(t *testing.T) {
 			r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
 			defer r.DB.Conn.Close()
 
-			query := regexp.QuoteMeta(`INSERT INTO book (code, title, author) VALUES ($1, $2, $3)`)
+			query := regexp.QuoteMeta(`INSERT INTO book (code, title, author, isbn) VALUES ($1, $2, $3, NULLIF($4, ''))`)
 			expect := mock.ExpectExec(query).
-				WithArgs(tt.book.Code, tt.book.Title, tt.book.Author)
+				WithArgs(tt.book.Code, tt.book.Title, tt.book.Author, tt.book.Isbn)
 
 			if tt.mockError != nil {
 				expect.WillReturnError(tt.mockError)
@@ -282,6 +284,28 @@ func TestCreateBook(t *testing.T) {
 	}
 }
 
+func TestCreateBookRetriesOnCodeConflict(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	query := regexp.QuoteMeta(`INSERT INTO book (code, title, author, isbn) VALUES ($1, $2, $3, NULLIF($4, ''))`)
+	mock.ExpectExec(query).
+		WithArgs("TAKEN", "Title 1", "Author 1", "").
+		WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectExec(query).
+		WithArgs(sqlmock.AnyArg(), "Title 1", "Author 1", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	book := &types.Book{Code: "TAKEN", Title: "Title 1", Author: "Author 1"}
+	err := r.CreateBook(book)
+
+	require.NoError(t, err)
+	require.NotEqual(t, "TAKEN", book.Code)
+	require.NoError(t, codegen.ValidateCrockford(book.Code))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestRemoveBookByCode(t *testing.T) {
 	tests := []struct {
 		name                  string
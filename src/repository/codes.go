@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"errors"
+
+	"tick_test/utils/codegen"
+
+	"github.com/lib/pq"
+)
+
+// maxCodeAttempts bounds how many freshly generated codes are tried when an
+// insert collides with an existing one.
+const maxCodeAttempts = 5
+
+const uniqueViolation = "23505"
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
+
+func (r *repo) useSequenceForCodes(kind codegen.Kind, sequence string) {
+	_, err := r.DB.Conn.Exec(`CREATE SEQUENCE IF NOT EXISTS ` + sequence)
+	logPossibleError(err)
+	codegen.UseSequence(kind, func() (n uint64, err error) {
+		err = r.DB.Conn.QueryRow(`SELECT nextval($1)`, sequence).Scan(&n)
+		return
+	})
+}
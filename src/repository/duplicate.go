@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"tick_test/utils/errDefs"
+
+	"github.com/lib/pq"
+)
+
+type DuplicateRepository interface {
+	MergeBooks(target string, sources []string) (err error)
+}
+
+func (r *repo) MergeBooks(target string, sources []string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var targetId int64
+	err = tx.QueryRow(`SELECT id FROM book WHERE code = $1`, target).Scan(&targetId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: book %s", errDefs.ErrEntityNotFound, target)
+	} else if err != nil {
+		return err
+	}
+
+	sourceIds := make([]int64, 0, len(sources))
+	for _, source := range sources {
+		if source == target {
+			return fmt.Errorf("%w: book %s cannot be merged into itself", errDefs.ErrBadRequest, target)
+		}
+		var sourceId int64
+		err = tx.QueryRow(`SELECT id FROM book WHERE code = $1`, source).Scan(&sourceId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: book %s", errDefs.ErrEntityNotFound, source)
+		} else if err != nil {
+			return err
+		}
+		sourceIds = append(sourceIds, sourceId)
+	}
+
+	for _, sourceId := range sourceIds {
+		// an account keeps its review of the target over one of a duplicate
+		if _, err = tx.Exec(`
+			DELETE FROM review WHERE book_id = $1
+			AND account_id IN (SELECT account_id FROM review WHERE book_id = $2)
+		`, sourceId, targetId); err != nil {
+			return err
+		}
+		if _, err = tx.Exec(`UPDATE review SET book_id = $1 WHERE book_id = $2`, targetId, sourceId); err != nil {
+			return err
+		}
+
+		if _, err = tx.Exec(`
+			DELETE FROM shelf_item WHERE book_id = $1
+			AND shelf_id IN (SELECT shelf_id FROM shelf_item WHERE book_id = $2)
+		`, sourceId, targetId); err != nil {
+			return err
+		}
+		if _, err = tx.Exec(`UPDATE shelf_item SET book_id = $1 WHERE book_id = $2`, targetId, sourceId); err != nil {
+			return err
+		}
+
+		if _, err = tx.Exec(`
+			INSERT INTO book_subject (book_id, subject_id)
+			SELECT $1, subject_id FROM book_subject WHERE book_id = $2
+			ON CONFLICT DO NOTHING
+		`, targetId, sourceId); err != nil {
+			return err
+		}
+	}
+
+	// close the gaps left by dropped shelf entries
+	if _, err = tx.Exec(`
+		UPDATE shelf_item si SET position = ranked.position
+		FROM (
+			SELECT shelf_id, book_id, ROW_NUMBER() OVER (PARTITION BY shelf_id ORDER BY position) - 1 AS position
+			FROM shelf_item
+		) ranked
+		WHERE si.shelf_id = ranked.shelf_id AND si.book_id = ranked.book_id AND si.position <> ranked.position
+	`); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`
+		UPDATE book SET isbn = (SELECT isbn FROM book WHERE id = ANY($1) AND isbn IS NOT NULL LIMIT 1)
+		WHERE id = $2 AND isbn IS NULL
+	`, pq.Array(sourceIds), targetId); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM book WHERE id = ANY($1)`, pq.Array(sourceIds)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
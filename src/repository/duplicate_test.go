@@ -0,0 +1,65 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"tick_test/repository"
+	"tick_test/utils/errDefs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeBooks(t *testing.T) {
+	findBook := regexp.QuoteMeta(`SELECT id FROM book WHERE code = $1`)
+
+	t.Run("Success", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(findBook).WithArgs("KEEP").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(findBook).WithArgs("DROP").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM review WHERE book_id = $1`)).WithArgs(int64(2), int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE review SET book_id = $1 WHERE book_id = $2`)).WithArgs(int64(1), int64(2)).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM shelf_item WHERE book_id = $1`)).WithArgs(int64(2), int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE shelf_item SET book_id = $1 WHERE book_id = $2`)).WithArgs(int64(1), int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO book_subject (book_id, subject_id)`)).WithArgs(int64(1), int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE shelf_item si SET position = ranked.position`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE book SET isbn =`)).WithArgs(sqlmock.AnyArg(), int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM book WHERE id = ANY($1)`)).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, r.MergeBooks("KEEP", []string{"DROP"}))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown source", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(findBook).WithArgs("KEEP").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(findBook).WithArgs("GONE").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		err := r.MergeBooks("KEEP", []string{"GONE"})
+		require.ErrorIs(t, err, errDefs.ErrEntityNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Merge into itself", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(findBook).WithArgs("KEEP").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectRollback()
+
+		err := r.MergeBooks("KEEP", []string{"KEEP"})
+		require.ErrorIs(t, err, errDefs.ErrBadRequest)
+	})
+}
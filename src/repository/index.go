@@ -51,7 +51,24 @@ func (r *repo) DoPostgresPreparation() (db *sql.DB, err error) {
 
 	r.doPostgresPreparationForMessages()
 	r.doPostgresPreparationForAccount()
+	r.doPostgresPreparationForRole()
+	r.doPostgresPreparationForPasswordHistory()
+	r.doPostgresPreparationForPasswordReset()
+	r.doPostgresPreparationForSuspension()
+	r.doPostgresPreparationForRegistration()
+	r.doPostgresPreparationForProfile()
+	r.doPostgresPreparationForRefreshToken()
+	r.doPostgresPreparationForRevocation()
+	r.doPostgresPreparationForSession()
+	r.doPostgresPreparationForLoginHistory()
+	r.doPostgresPreparationForTwoFactor()
+	r.doPostgresPreparationForApiKey()
+	r.doPostgresPreparationForOAuthClient()
 	r.doPostgresPreparationForBook()
+	r.doPostgresPreparationForRevision()
+	r.doPostgresPreparationForReview()
+	r.doPostgresPreparationForSubject()
+	r.doPostgresPreparationForShelf()
 	r.doPostgresPreparationForManipulator()
 	r.loadIterationManipulators()
 	LoadIteration()
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"time"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+type LoginHistoryRepository interface {
+	SaveLoginEvent(username string, event types.LoginEvent) (err error)
+	FindLoginEvents(username string, limit int) (events []types.LoginEvent, err error)
+	DeleteLoginEventsBefore(before time.Time) (err error)
+}
+
+// SaveLoginEvent records a login attempt. Attempts on usernames without an
+// account are not recorded.
+func (r *repo) SaveLoginEvent(username string, event types.LoginEvent) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	_, err = r.DB.Conn.Exec(`
+		INSERT INTO login_event (account_id, succeeded, reason, user_agent, ip, created_at)
+		SELECT id, $2, $3, $4, $5, $6 FROM account WHERE username = $1
+	`, username, event.Succeeded, event.Reason, event.Device.UserAgent, event.Device.IP, event.CreatedAt)
+	return
+}
+
+// FindLoginEvents returns the latest limit login attempts of the account,
+// newest first.
+func (r *repo) FindLoginEvents(username string, limit int) (events []types.LoginEvent, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	rows, err := r.DB.Conn.Query(`
+		SELECT e.succeeded, e.reason, e.user_agent, e.ip, e.created_at
+		FROM login_event e JOIN account a ON e.account_id = a.id
+		WHERE a.username = $1
+		ORDER BY e.id DESC
+		LIMIT $2
+	`, username, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events = make([]types.LoginEvent, 0)
+	for rows.Next() {
+		var event types.LoginEvent
+		if err := rows.Scan(&event.Succeeded, &event.Reason, &event.Device.UserAgent, &event.Device.IP, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (r *repo) DeleteLoginEventsBefore(before time.Time) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	_, err = r.DB.Conn.Exec(`DELETE FROM login_event WHERE created_at < $1`, before.UTC().Format(time.RFC3339))
+	return
+}
+
+func (r *repo) doPostgresPreparationForLoginHistory() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS login_event (
+				id SERIAL PRIMARY KEY,
+				account_id INTEGER NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+				succeeded BOOLEAN NOT NULL,
+				reason varchar(200) NOT NULL DEFAULT '',
+				user_agent varchar(500) NOT NULL DEFAULT '',
+				ip varchar(45) NOT NULL DEFAULT '',
+				created_at varchar(30) NOT NULL
+			);
+		`)
+		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`CREATE INDEX IF NOT EXISTS login_event_account ON login_event (account_id, id)`)
+		logPossibleError(err)
+	}
+}
@@ -0,0 +1,38 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+
+	"tick_test/repository"
+	"tick_test/types"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginEvents(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	event := types.LoginEvent{
+		Succeeded: false,
+		Reason:    "unauthorized: invalid credentials",
+		Device:    types.DeviceInfo{UserAgent: "curl/8.0", IP: "10.0.0.1"},
+		CreatedAt: "2025-03-07T12:00:00Z",
+	}
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO login_event (account_id, succeeded, reason, user_agent, ip, created_at)`)).
+		WithArgs("john", false, event.Reason, "curl/8.0", "10.0.0.1", "2025-03-07T12:00:00Z").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	require.NoError(t, r.SaveLoginEvent("john", event))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT e.succeeded, e.reason, e.user_agent, e.ip, e.created_at FROM login_event e`)).
+		WithArgs("john", 20).
+		WillReturnRows(sqlmock.NewRows([]string{"succeeded", "reason", "user_agent", "ip", "created_at"}).
+			AddRow(false, event.Reason, "curl/8.0", "10.0.0.1", "2025-03-07T12:00:00Z"))
+	events, err := r.FindLoginEvents("john", 20)
+	require.NoError(t, err)
+	require.Equal(t, []types.LoginEvent{event}, events)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"sync"
 	"tick_test/types"
+	"tick_test/utils/codegen"
 	"time"
 )
 
@@ -190,8 +191,15 @@ func (r *repo) SaveIterationManipulatorToDatabase(obj *IterationManipulator) (er
 		return
 	}
 	query := `INSERT INTO manipulator (code, duration, value) VALUES ($1, $2, $3)`
-	_, err = r.DB.Conn.Exec(query, obj.Code, obj.Data.Duration, obj.Data.Value)
-	return
+	for attempt := 1; ; attempt++ {
+		_, err = r.DB.Conn.Exec(query, obj.Code, obj.Data.Duration, obj.Data.Value)
+		if !isUniqueViolation(err) || attempt >= maxCodeAttempts {
+			return
+		}
+		if obj.Code, err = codegen.Generate(codegen.ManipulatorCodes); err != nil {
+			return
+		}
+	}
 }
 
 func (r *repo) UpdateManipulatorInDatabase(code string, duration types.ISO8601Duration, value int) error {
@@ -216,5 +224,6 @@ func (r *repo) doPostgresPreparationForManipulator() {
 			);
 		`)
 		logPossibleError(err)
+		r.useSequenceForCodes(codegen.ManipulatorCodes, "manipulator_code_seq")
 	}
 }
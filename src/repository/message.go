@@ -42,42 +42,41 @@ func (r *repo) FindMessages(username string, sent bool, recv bool) (msgs []types
 		return nil, fmt.Errorf("could not resolve user id: %w", err)
 	}
 
-	var query string
+	var condition string
 	switch {
 	case sent && recv:
-		query = `SELECT from_user, to_user, content, created_at FROM messages WHERE to_user = $1 OR from_user = $1`
+		condition = `m.to_user = $1 OR m.from_user = $1`
 	case sent:
-		query = `SELECT from_user, to_user, content, created_at FROM messages WHERE from_user = $1`
+		condition = `m.from_user = $1`
 	case recv:
-		query = `SELECT from_user, to_user, content, created_at FROM messages WHERE to_user = $1`
+		condition = `m.to_user = $1`
 	default:
 		return []types.Message{}, nil
 	}
 
-	rows, err := r.DB.Conn.Query(query, userId)
+	// the other side of a message is NULL once its account has been erased
+	rows, err := r.DB.Conn.Query(`
+		SELECT COALESCE(f.username, $2), COALESCE(t.username, $2), m.content, m.created_at
+		FROM messages m
+		LEFT JOIN account f ON m.from_user = f.id
+		LEFT JOIN account t ON m.to_user = t.id
+		WHERE `+condition+`
+		ORDER BY m.id
+	`, userId, types.ErasedUsername)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var fromId, toId int
 	msgs = make([]types.Message, 0)
 	for rows.Next() {
 		var msg types.Message
-		if err := rows.Scan(&fromId, &toId, &msg.Content, &msg.When); err != nil {
-			return nil, err
-		}
-		err = r.DB.Conn.QueryRow(`SELECT username FROM account WHERE id = $1`, fromId).Scan(&msg.From)
-		if err := rows.Scan(&fromId, &toId, &msg.Content, &msg.When); err != nil {
-			return nil, err
-		}
-		err = r.DB.Conn.QueryRow(`SELECT username FROM account WHERE id = $1`, toId).Scan(&msg.To)
-		if err := rows.Scan(&fromId, &toId, &msg.Content, &msg.When); err != nil {
+		if err := rows.Scan(&msg.From, &msg.To, &msg.Content, &msg.When); err != nil {
 			return nil, err
 		}
 		msgs = append(msgs, msg)
 	}
-	return msgs, nil
+	return msgs, rows.Err()
 }
 
 func (r *repo) doPostgresPreparationForMessages() {
@@ -85,8 +84,8 @@ func (r *repo) doPostgresPreparationForMessages() {
 		_, err := r.DB.Conn.Exec(`
 			CREATE TABLE IF NOT EXISTS messages (
 				id SERIAL PRIMARY KEY,
-				from_user BIGINT NOT NULL,
-				to_user BIGINT NOT NULL,
+				from_user BIGINT,
+				to_user BIGINT,
 				content TEXT NOT NULL, 
 				created_at varchar(30) NOT NULL,
 				FOREIGN KEY (from_user) REFERENCES account(id),
@@ -94,5 +93,12 @@ func (r *repo) doPostgresPreparationForMessages() {
 			);
 		`)
 		logPossibleError(err)
+		// erased accounts leave their messages behind without a sender or recipient
+		_, err = r.DB.Conn.Exec(`
+			ALTER TABLE messages
+				ALTER COLUMN from_user DROP NOT NULL,
+				ALTER COLUMN to_user DROP NOT NULL
+		`)
+		logPossibleError(err)
 	}
 }
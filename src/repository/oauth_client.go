@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/lib/pq"
+)
+
+// OAuthClientCredentials describes a client together with the account it
+// acts for and the hash of its secret.
+type OAuthClientCredentials struct {
+	Client     types.OAuthClient
+	Owner      string
+	Role       types.Role
+	SecretHash string
+}
+
+type OAuthClientRepository interface {
+	CreateOAuthClient(username string, client types.OAuthClient, secretHash string) (err error)
+	FindOAuthClients(username string) (clients []types.OAuthClient, err error)
+	FindOAuthClientCredentials(clientId string) (credentials OAuthClientCredentials, err error)
+	DeleteOAuthClient(username string, clientId string) (err error)
+}
+
+func (r *repo) CreateOAuthClient(username string, client types.OAuthClient, secretHash string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	_, err = r.DB.Conn.Exec(`
+		INSERT INTO oauth_client (client_id, account_id, name, secret_hash, scopes, created_at)
+		VALUES ($1, (SELECT id FROM account WHERE username = $2), $3, $4, $5, $6)
+	`, client.ClientId, username, client.Name, secretHash, pq.Array(client.Scopes), client.CreatedAt)
+	return
+}
+
+func (r *repo) FindOAuthClients(username string) (clients []types.OAuthClient, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	rows, err := r.DB.Conn.Query(`
+		SELECT c.client_id, c.name, c.scopes, c.created_at
+		FROM oauth_client c JOIN account a ON a.id = c.account_id
+		WHERE a.username = $1
+		ORDER BY c.id
+	`, username)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	clients = make([]types.OAuthClient, 0)
+	for rows.Next() {
+		var client types.OAuthClient
+		if err = rows.Scan(&client.ClientId, &client.Name, pq.Array(&client.Scopes), &client.CreatedAt); err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	err = rows.Err()
+	return
+}
+
+func (r *repo) FindOAuthClientCredentials(clientId string) (credentials OAuthClientCredentials, err error) {
+	if r.DB.Conn == nil {
+		return credentials, errDefs.ErrDatabaseOffline
+	}
+	client := &credentials.Client
+	err = r.DB.Conn.QueryRow(`
+		SELECT c.client_id, c.name, c.scopes, c.created_at, a.username, ro.name, c.secret_hash
+		FROM oauth_client c
+		JOIN account a ON a.id = c.account_id
+		JOIN role ro ON ro.id = a.role_id
+		WHERE c.client_id = $1
+	`, clientId).Scan(&client.ClientId, &client.Name, pq.Array(&client.Scopes), &client.CreatedAt,
+		&credentials.Owner, &credentials.Role, &credentials.SecretHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return credentials, fmt.Errorf("%w: no OAuth client %s", errDefs.ErrEntityNotFound, clientId)
+	}
+	return
+}
+
+func (r *repo) DeleteOAuthClient(username string, clientId string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	result, err := r.DB.Conn.Exec(`
+		DELETE FROM oauth_client
+		WHERE client_id = $2 AND account_id = (SELECT id FROM account WHERE username = $1)
+	`, username, clientId)
+	if err != nil {
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: no OAuth client %s", errDefs.ErrEntityNotFound, clientId)
+	}
+	return
+}
+
+func (r *repo) doPostgresPreparationForOAuthClient() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS oauth_client (
+				id SERIAL PRIMARY KEY,
+				client_id varchar(40) UNIQUE NOT NULL,
+				account_id INTEGER NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+				name varchar(100) NOT NULL,
+				secret_hash char(64) NOT NULL,
+				scopes TEXT[] NOT NULL,
+				created_at varchar(30) NOT NULL
+			);
+		`)
+		logPossibleError(err)
+	}
+}
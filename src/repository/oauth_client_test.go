@@ -0,0 +1,67 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindOAuthClientCredentials(t *testing.T) {
+	query := regexp.QuoteMeta(`SELECT c.client_id, c.name, c.scopes, c.created_at, a.username, ro.name, c.secret_hash FROM oauth_client c`)
+
+	t.Run("Success", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectQuery(query).
+			WithArgs("client").
+			WillReturnRows(sqlmock.NewRows([]string{"client_id", "name", "scopes", "created_at", "username", "role", "secret_hash"}).
+				AddRow("client", "Importer", "{books:read}", "2025-03-07T12:00:00Z", "robot", "BookKeeper", "hash"))
+
+		credentials, err := r.FindOAuthClientCredentials("client")
+		require.NoError(t, err)
+		require.Equal(t, repository.OAuthClientCredentials{
+			Client: types.OAuthClient{
+				ClientId:  "client",
+				Name:      "Importer",
+				Scopes:    []string{types.BooksReadScope},
+				CreatedAt: "2025-03-07T12:00:00Z",
+			},
+			Owner:      "robot",
+			Role:       types.BookKeeperRole,
+			SecretHash: "hash",
+		}, credentials)
+	})
+
+	t.Run("Unknown client", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectQuery(query).
+			WithArgs("client").
+			WillReturnRows(sqlmock.NewRows([]string{"client_id", "name", "scopes", "created_at", "username", "role", "secret_hash"}))
+
+		_, err := r.FindOAuthClientCredentials("client")
+		require.ErrorIs(t, err, errDefs.ErrEntityNotFound)
+	})
+}
+
+func TestDeleteOAuthClientNotFound(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM oauth_client WHERE client_id = $2`)).
+		WithArgs("robot", "client").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.ErrorIs(t, r.DeleteOAuthClient("robot", "client"), errDefs.ErrEntityNotFound)
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	errDefs "tick_test/utils/errDefs"
+	"tick_test/utils/passhash"
+)
+
+// PasswordPolicy decides whether a password may be set for an account.
+// previousHashes holds the hashes of the passwords the account used last,
+// newest first; HistorySize says how many of them the policy wants to see.
+type PasswordPolicy interface {
+	Violations(username string, password string, previousHashes []string) []string
+	HistorySize() int
+}
+
+// DefaultMinPasswordLength is enforced until SetPasswordPolicy installs
+// the configured policy, so that no password is ever left unchecked.
+const DefaultMinPasswordLength = 8
+
+// minLengthPolicy only demands a minimum number of characters.
+type minLengthPolicy int
+
+func (p minLengthPolicy) Violations(username string, password string, previousHashes []string) []string {
+	if utf8.RuneCountInString(password) < int(p) {
+		return []string{fmt.Sprintf("password must be at least %d characters long", int(p))}
+	}
+	return nil
+}
+
+func (p minLengthPolicy) HistorySize() int {
+	return 0
+}
+
+var passwordPolicy PasswordPolicy = minLengthPolicy(DefaultMinPasswordLength)
+
+// SetPasswordPolicy installs the policy enforced by SaveAccount and
+// UpdateExistingAccount; nil restores the default of
+// DefaultMinPasswordLength characters.
+func SetPasswordPolicy(policy PasswordPolicy) {
+	if policy == nil {
+		policy = minLengthPolicy(DefaultMinPasswordLength)
+	}
+	passwordPolicy = policy
+}
+
+// PasswordMatches reports whether password hashes to hash.
+func PasswordMatches(password string, hash string) bool {
+	return passhash.Verify(password, hash) == nil
+}
+
+func checkPasswordPolicy(username string, password string, previousHashes []string) error {
+	violations := passwordPolicy.Violations(username, password, previousHashes)
+	if len(violations) > 0 {
+		return &errDefs.ViolationsError{Message: "password does not satisfy the password policy", Violations: violations}
+	}
+	return nil
+}
+
+// findPasswordHistory returns the current password hash of the account
+// followed by the ones it replaced, newest first.
+func (r *repo) findPasswordHistory(tx *sql.Tx, username string) (hashes []string, err error) {
+	var accountId int64
+	var current string
+	err = tx.QueryRow(`SELECT id, password FROM account WHERE username = $1 FOR UPDATE`, username).Scan(&accountId, &current)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: no account found with the specified username", errDefs.ErrEntityNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	hashes = append(hashes, current)
+	if passwordPolicy.HistorySize() <= 1 {
+		return hashes, nil
+	}
+	rows, err := tx.Query(`
+		SELECT password FROM password_history WHERE account_id = $1 ORDER BY id DESC LIMIT $2;
+	`, accountId, passwordPolicy.HistorySize()-1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var hash string
+		if err = rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
+
+// replacePassword stores the new hash and moves the old one into the
+// history, keeping only as many entries as the policy looks at.
+func (r *repo) replacePassword(tx *sql.Tx, username string, oldHash string, newHash string) (err error) {
+	var accountId int64
+	err = tx.QueryRow(`UPDATE account SET password = $1 WHERE username = $2 RETURNING id`, newHash, username).Scan(&accountId)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: no account found with the specified username", errDefs.ErrEntityNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	keep := passwordPolicy.HistorySize() - 1
+	if keep <= 0 {
+		_, err = tx.Exec(`DELETE FROM password_history WHERE account_id = $1`, accountId)
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO password_history (account_id, password, created_at) VALUES ($1, $2, $3);
+	`, accountId, oldHash, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		DELETE FROM password_history
+		WHERE account_id = $1 AND id NOT IN (
+			SELECT id FROM password_history WHERE account_id = $1 ORDER BY id DESC LIMIT $2
+		);
+	`, accountId, keep)
+	return err
+}
+
+func (r *repo) doPostgresPreparationForPasswordHistory() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS password_history (
+				id SERIAL PRIMARY KEY,
+				account_id INTEGER NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+				password varchar(500) NOT NULL,
+				created_at varchar(30) NOT NULL
+			);
+		`)
+		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`
+			CREATE INDEX IF NOT EXISTS password_history_account_idx ON password_history (account_id);
+		`)
+		logPossibleError(err)
+	}
+}
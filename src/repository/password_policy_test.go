@@ -0,0 +1,109 @@
+package repository_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"tick_test/repository"
+	"tick_test/types"
+	errDefs "tick_test/utils/errDefs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type reusePolicy struct{}
+
+func (reusePolicy) HistorySize() int { return 3 }
+
+func (reusePolicy) Violations(username string, password string, previousHashes []string) []string {
+	violations := make([]string, 0)
+	if len(password) < 8 {
+		violations = append(violations, "too short")
+	}
+	for _, hash := range previousHashes {
+		if repository.PasswordMatches(password, hash) {
+			violations = append(violations, "reused")
+		}
+	}
+	return violations
+}
+
+func TestSaveAccountPasswordPolicy(t *testing.T) {
+	repository.SetPasswordPolicy(reusePolicy{})
+	defer repository.SetPasswordPolicy(nil)
+
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	err := r.SaveAccount(&types.AccountPostData{
+		Username:     "john",
+		Password:     "short",
+		SamePassword: "short",
+		Role:         "User",
+	})
+
+	var violationsErr *errDefs.ViolationsError
+	require.True(t, errors.As(err, &violationsErr))
+	require.ErrorIs(t, err, errDefs.ErrBadRequest)
+	require.Equal(t, []string{"too short"}, violationsErr.Violations)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateExistingAccountPasswordHistory(t *testing.T) {
+	repository.SetPasswordPolicy(reusePolicy{})
+	defer repository.SetPasswordPolicy(nil)
+
+	current, _ := bcrypt.GenerateFromPassword([]byte("current-password"), bcrypt.MinCost)
+	previous, _ := bcrypt.GenerateFromPassword([]byte("previous-password"), bcrypt.MinCost)
+
+	tests := []struct {
+		name     string
+		password string
+	}{
+		{name: "current password", password: "current-password"},
+		{name: "previous password", password: "previous-password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rMock, mock := setupMock(t)
+			r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+			defer r.DB.Conn.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, password FROM account WHERE username = $1 FOR UPDATE`)).
+				WithArgs("john").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(1, string(current)))
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT password FROM password_history WHERE account_id = $1 ORDER BY id DESC LIMIT $2;`)).
+				WithArgs(1, 2).
+				WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow(string(previous)))
+			mock.ExpectRollback()
+
+			_, err := r.UpdateExistingAccount("john", &types.AccountPatchData{
+				Password:     tt.password,
+				SamePassword: tt.password,
+			})
+
+			var violationsErr *errDefs.ViolationsError
+			require.True(t, errors.As(err, &violationsErr))
+			require.Equal(t, []string{"reused"}, violationsErr.Violations)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSaveAccountDefaultPasswordPolicy(t *testing.T) {
+	rMock, _ := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+	repository.SetPasswordPolicy(nil)
+
+	err := r.SaveAccount(&types.AccountPostData{Username: "john", Password: "x", SamePassword: "x", Role: "User"})
+	var violations *errDefs.ViolationsError
+	require.True(t, errors.As(err, &violations))
+	require.Equal(t, []string{"password must be at least 8 characters long"}, violations.Violations)
+}
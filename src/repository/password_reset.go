@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"tick_test/utils/errDefs"
+)
+
+type PasswordResetRepository interface {
+	SavePasswordReset(username string, tokenHash string, expiresAt time.Time) (err error)
+	ResetPassword(tokenHash string, password string, now time.Time) (username string, err error)
+	ClearPassword(username string) (err error)
+}
+
+// unusablePassword is no password hash, so no password ever matches it.
+const unusablePassword = "!"
+
+// SavePasswordReset stores a new reset token for the account. Tokens issued
+// before it can no longer be used.
+func (r *repo) SavePasswordReset(username string, tokenHash string, expiresAt time.Time) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var accountId int64
+	err = tx.QueryRow(`SELECT id FROM account WHERE username = $1`, username).Scan(&accountId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: no account found with the specified username", errDefs.ErrEntityNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	if _, err = tx.Exec(`
+		UPDATE password_reset SET used_at = $2 WHERE account_id = $1 AND used_at IS NULL
+	`, accountId, now); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`
+		INSERT INTO password_reset (account_id, token_hash, created_at, expires_at) VALUES ($1, $2, $3, $4)
+	`, accountId, tokenHash, now, expiresAt.UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ResetPassword sets the password of the account a valid reset token belongs
+// to and uses the token up. The password policy applies as for any other
+// password change; when it refuses the password the token stays valid.
+// Afterwards all tokens and sessions of the account are revoked.
+func (r *repo) ResetPassword(tokenHash string, password string, now time.Time) (username string, err error) {
+	if r.DB.Conn == nil {
+		return "", errDefs.ErrDatabaseOffline
+	}
+	if err = validateCredential(password, "Password"); err != nil {
+		return "", err
+	}
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var resetId int64
+	err = tx.QueryRow(`
+		SELECT p.id, a.username
+		FROM password_reset p JOIN account a ON a.id = p.account_id
+		WHERE p.token_hash = $1 AND p.used_at IS NULL AND p.expires_at > $2
+		FOR UPDATE OF p
+	`, tokenHash, now.UTC().Format(time.RFC3339)).Scan(&resetId, &username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: reset token invalid, expired or already used", errDefs.ErrUnauthorized)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	history, err := r.findPasswordHistory(tx, username)
+	if err != nil {
+		return "", err
+	}
+	if err = checkPasswordPolicy(username, password, history); err != nil {
+		return "", err
+	}
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return "", err
+	}
+	if err = r.replacePassword(tx, username, history[0], hashedPassword); err != nil {
+		return "", err
+	}
+	if _, err = tx.Exec(`
+		UPDATE password_reset SET used_at = $2 WHERE id = $1
+	`, resetId, now.UTC().Format(time.RFC3339)); err != nil {
+		return "", err
+	}
+	cutoff, err := r.revokeAllTokens(tx, username)
+	if err != nil {
+		return "", err
+	}
+	if err = tx.Commit(); err != nil {
+		return "", err
+	}
+	notifyTokensRevoked(cutoff)
+	return username, nil
+}
+
+// ClearPassword makes the current password of the account stop working, so
+// that only a reset token lets the holder back in, and revokes all of its
+// tokens and sessions. The old password moves into the history.
+func (r *repo) ClearPassword(username string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	history, err := r.findPasswordHistory(tx, username)
+	if err != nil {
+		return err
+	}
+	if history[0] != unusablePassword {
+		if err = r.replacePassword(tx, username, history[0], unusablePassword); err != nil {
+			return err
+		}
+	}
+	cutoff, err := r.revokeAllTokens(tx, username)
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	notifyTokensRevoked(cutoff)
+	return nil
+}
+
+func (r *repo) doPostgresPreparationForPasswordReset() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS password_reset (
+				id SERIAL PRIMARY KEY,
+				account_id INTEGER NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+				token_hash char(64) UNIQUE NOT NULL,
+				created_at varchar(30) NOT NULL,
+				expires_at varchar(30) NOT NULL,
+				used_at varchar(30)
+			);
+		`)
+		logPossibleError(err)
+	}
+}
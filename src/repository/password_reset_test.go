@@ -0,0 +1,171 @@
+package repository_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"tick_test/repository"
+	"tick_test/utils/errDefs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSavePasswordResetUnknownAccount(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM account WHERE username = $1`)).
+		WithArgs("nobody").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	err := r.SavePasswordReset("nobody", "hash", time.Now().Add(time.Hour))
+	require.ErrorIs(t, err, errDefs.ErrEntityNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResetPassword(t *testing.T) {
+	selectReset := regexp.QuoteMeta(`SELECT p.id, a.username FROM password_reset p JOIN account a ON a.id = p.account_id`)
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Invalid, expired or used token", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectReset).
+			WithArgs("hash", "2025-03-07T12:00:00Z").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username"}))
+		mock.ExpectRollback()
+
+		_, err := r.ResetPassword("hash", "new-password", now)
+		require.ErrorIs(t, err, errDefs.ErrUnauthorized)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Password policy keeps the token valid", func(t *testing.T) {
+		repository.SetPasswordPolicy(reusePolicy{})
+		defer repository.SetPasswordPolicy(nil)
+		current, _ := bcrypt.GenerateFromPassword([]byte("current-password"), bcrypt.MinCost)
+
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectReset).
+			WithArgs("hash", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(4, "john"))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, password FROM account WHERE username = $1 FOR UPDATE`)).
+			WithArgs("john").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(1, string(current)))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT password FROM password_history WHERE account_id = $1`)).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"password"}))
+		mock.ExpectRollback()
+
+		_, err := r.ResetPassword("hash", "current-password", now)
+		var violationsErr *errDefs.ViolationsError
+		require.True(t, errors.As(err, &violationsErr))
+		require.Equal(t, []string{"reused"}, violationsErr.Violations)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		current, _ := bcrypt.GenerateFromPassword([]byte("current-password"), bcrypt.MinCost)
+
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectReset).
+			WithArgs("hash", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(4, "john"))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, password FROM account WHERE username = $1 FOR UPDATE`)).
+			WithArgs("john").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(1, string(current)))
+		mock.ExpectQuery(regexp.QuoteMeta(`UPDATE account SET password = $1 WHERE username = $2 RETURNING id`)).
+			WithArgs(sqlmock.AnyArg(), "john").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM password_history WHERE account_id = $1`)).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE password_reset SET used_at = $2 WHERE id = $1`)).
+			WithArgs(4, "2025-03-07T12:00:00Z").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO token_cutoff`)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE refresh_token SET revoked_at = $2`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE session SET revoked_at = $2`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		username, err := r.ResetPassword("hash", "brand-new-password", now)
+		require.NoError(t, err)
+		require.Equal(t, "john", username)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failing revocation keeps the old password", func(t *testing.T) {
+		current, _ := bcrypt.GenerateFromPassword([]byte("current-password"), bcrypt.MinCost)
+
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectReset).
+			WithArgs("hash", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(4, "john"))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, password FROM account WHERE username = $1 FOR UPDATE`)).
+			WithArgs("john").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(1, string(current)))
+		mock.ExpectQuery(regexp.QuoteMeta(`UPDATE account SET password = $1 WHERE username = $2 RETURNING id`)).
+			WithArgs(sqlmock.AnyArg(), "john").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM password_history WHERE account_id = $1`)).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE password_reset SET used_at = $2 WHERE id = $1`)).
+			WithArgs(4, "2025-03-07T12:00:00Z").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO token_cutoff`)).WillReturnError(errors.New("connection reset"))
+		mock.ExpectRollback()
+
+		_, err := r.ResetPassword("hash", "brand-new-password", now)
+		require.Error(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestClearPassword(t *testing.T) {
+	current, _ := bcrypt.GenerateFromPassword([]byte("current-password"), bcrypt.MinCost)
+
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, password FROM account WHERE username = $1 FOR UPDATE`)).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(1, string(current)))
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE account SET password = $1 WHERE username = $2 RETURNING id`)).
+		WithArgs(sqlmock.AnyArg(), "john").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM password_history WHERE account_id = $1`)).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO token_cutoff`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE refresh_token SET revoked_at = $2`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE session SET revoked_at = $2`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	require.NoError(t, r.ClearPassword("john"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
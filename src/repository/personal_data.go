@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+type PersonalDataRepository interface {
+	FindPersonalData(username string) (data types.PersonalData, err error)
+}
+
+var erasurePolicy = types.DefaultErasurePolicy
+
+// SetErasurePolicy decides what DeleteAccount does with the messages,
+// reviews and revisions of the accounts it deletes.
+func SetErasurePolicy(policy types.ErasurePolicy) {
+	erasurePolicy = policy
+}
+
+func checkReservedUsername(username string) error {
+	if username == types.ErasedUsername {
+		return fmt.Errorf("%w: username %q is reserved", errDefs.ErrBadRequest, username)
+	}
+	return nil
+}
+
+// FindPersonalData collects what is stored about the account: its profile,
+// the messages it sent and received, its reviews, its own shelves and the
+// book revisions it made.
+func (r *repo) FindPersonalData(username string) (data types.PersonalData, err error) {
+	if r.DB.Conn == nil {
+		return types.PersonalData{}, errDefs.ErrDatabaseOffline
+	}
+	if data.Profile, err = r.FindProfile(username); err != nil {
+		return types.PersonalData{}, err
+	}
+	if data.MessagesSent, err = r.FindMessages(username, true, false); err != nil {
+		return types.PersonalData{}, err
+	}
+	if data.MessagesReceived, err = r.FindMessages(username, false, true); err != nil {
+		return types.PersonalData{}, err
+	}
+	if data.Reviews, err = r.findReviewsByAccount(username); err != nil {
+		return types.PersonalData{}, err
+	}
+	if data.Shelves, err = r.findShelves(`
+		SELECT s.id FROM shelf s
+		JOIN account a ON s.account_id = a.id
+		WHERE a.username = $1
+		ORDER BY s.id
+	`, username); err != nil {
+		return types.PersonalData{}, err
+	}
+	if data.Revisions, err = r.findRevisionsByEditor(username); err != nil {
+		return types.PersonalData{}, err
+	}
+	return data, nil
+}
+
+func (r *repo) findReviewsByAccount(username string) (reviews []types.Review, err error) {
+	rows, err := r.DB.Conn.Query(`
+		SELECT b.code, a.username, rv.stars, rv.content, rv.created_at, rv.updated_at
+		FROM review rv
+		JOIN book b ON rv.book_id = b.id
+		JOIN account a ON rv.account_id = a.id
+		WHERE a.username = $1
+		ORDER BY rv.id
+	`, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews = make([]types.Review, 0)
+	for rows.Next() {
+		var review types.Review
+		if err := rows.Scan(&review.Book, &review.Username, &review.Stars, &review.Content, &review.CreatedAt, &review.UpdatedAt); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+func (r *repo) findRevisionsByEditor(username string) (revisions []types.EditedRevision, err error) {
+	rows, err := r.DB.Conn.Query(`
+		SELECT b.code, br.number, br.created_at
+		FROM book_revision br
+		JOIN book b ON br.book_id = b.id
+		WHERE br.editor = $1
+		ORDER BY br.id
+	`, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions = make([]types.EditedRevision, 0)
+	for rows.Next() {
+		var revision types.EditedRevision
+		if err := rows.Scan(&revision.Book, &revision.Number, &revision.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// eraseReferences applies the erasure policy to the data of the account
+// that would otherwise keep it from being deleted or would keep naming it.
+func eraseReferences(tx *sql.Tx, accountId int64, username string) (err error) {
+	if err = eraseMessages(tx, "from_user", accountId, erasurePolicy.SentMessages); err != nil {
+		return err
+	}
+	if err = eraseMessages(tx, "to_user", accountId, erasurePolicy.ReceivedMessages); err != nil {
+		return err
+	}
+	// nobody is left to read a message both of whose accounts are gone
+	if _, err = tx.Exec(`DELETE FROM messages WHERE from_user IS NULL AND to_user IS NULL`); err != nil {
+		return err
+	}
+
+	switch erasurePolicy.Reviews {
+	case types.EraseAnonymize:
+		_, err = tx.Exec(`UPDATE review SET account_id = NULL WHERE account_id = $1`, accountId)
+	default:
+		_, err = tx.Exec(`DELETE FROM review WHERE account_id = $1`, accountId)
+	}
+	if err != nil {
+		return err
+	}
+
+	if erasurePolicy.Revisions != types.EraseKeep {
+		if _, err = tx.Exec(`UPDATE book_revision SET editor = $1 WHERE editor = $2`, types.ErasedUsername, username); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(`UPDATE account_suspension SET suspended_by = $1 WHERE suspended_by = $2`, types.ErasedUsername, username)
+	return err
+}
+
+// eraseMessages deletes or anonymizes the messages whose column, from_user
+// or to_user, refers to the account.
+func eraseMessages(tx *sql.Tx, column string, accountId int64, action types.ErasureAction) (err error) {
+	if action == types.EraseAnonymize {
+		_, err = tx.Exec(`UPDATE messages SET `+column+` = NULL WHERE `+column+` = $1`, accountId)
+	} else {
+		_, err = tx.Exec(`DELETE FROM messages WHERE `+column+` = $1`, accountId)
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteAccountErasurePolicy(t *testing.T) {
+	repository.SetErasurePolicy(types.ErasurePolicy{
+		SentMessages:     types.EraseDelete,
+		ReceivedMessages: types.EraseAnonymize,
+		Reviews:          types.EraseDelete,
+		Revisions:        types.EraseKeep,
+	})
+	defer repository.SetErasurePolicy(types.DefaultErasurePolicy)
+
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM account WHERE username = $1 FOR UPDATE`)).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM messages WHERE from_user = $1`)).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE messages SET to_user = NULL WHERE to_user = $1`)).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM messages WHERE from_user IS NULL AND to_user IS NULL`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM review WHERE account_id = $1`)).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE account_suspension SET suspended_by = $1 WHERE suspended_by = $2`)).
+		WithArgs(types.ErasedUsername, "john").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM account WHERE id = $1`)).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, r.DeleteAccount("john"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindMessagesOfErasedAccount(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM account WHERE username = $1`)).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(f.username, $2), COALESCE(t.username, $2), m.content, m.created_at FROM messages m`)).
+		WithArgs(3, types.ErasedUsername).
+		WillReturnRows(sqlmock.NewRows([]string{"from", "to", "content", "created_at"}).
+			AddRow(types.ErasedUsername, "john", "hello", "2025-03-01T10:00:00Z").
+			AddRow("john", "jane", "hi", "2025-03-01T11:00:00Z"))
+
+	msgs, err := r.FindMessages("john", true, true)
+	require.NoError(t, err)
+	require.Equal(t, []types.Message{
+		{From: types.ErasedUsername, To: "john", Content: "hello", When: "2025-03-01T10:00:00Z"},
+		{From: "john", To: "jane", Content: "hi", When: "2025-03-01T11:00:00Z"},
+	}, msgs)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveAccountReservedUsername(t *testing.T) {
+	rMock, _ := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	err := r.SaveAccount(&types.AccountPostData{
+		Username:     types.ErasedUsername,
+		Password:     "correct horse battery",
+		SamePassword: "correct horse battery",
+	})
+	require.ErrorIs(t, err, errDefs.ErrBadRequest)
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+type ProfileRepository interface {
+	FindProfile(username string) (profile types.Profile, err error)
+	SaveProfile(profile types.Profile) (err error)
+	SetAvatar(username string, hasAvatar bool) (err error)
+}
+
+// FindProfile returns the profile of the account, with empty fields if it
+// never saved one.
+func (r *repo) FindProfile(username string) (profile types.Profile, err error) {
+	if r.DB.Conn == nil {
+		return types.Profile{}, errDefs.ErrDatabaseOffline
+	}
+	var updatedAt sql.NullString
+	err = r.DB.Conn.QueryRow(`
+		SELECT a.username, r.name,
+			COALESCE(p.display_name, ''), COALESCE(p.email, ''), COALESCE(p.bio, ''),
+			COALESCE(p.has_avatar, FALSE), COALESCE(p.timezone, ''), COALESCE(p.locale, ''), p.updated_at
+		FROM account a
+		JOIN role r ON a.role_id = r.id
+		LEFT JOIN account_profile p ON p.account_id = a.id
+		WHERE a.username = $1
+	`, username).Scan(
+		&profile.Username, &profile.Role,
+		&profile.DisplayName, &profile.Email, &profile.Bio,
+		&profile.HasAvatar, &profile.Timezone, &profile.Locale, &updatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Profile{}, fmt.Errorf("%w: no account found with the specified username", errDefs.ErrEntityNotFound)
+	}
+	if err != nil {
+		return types.Profile{}, err
+	}
+	if updatedAt.Valid {
+		profile.UpdatedAt = &updatedAt.String
+	}
+	return profile, nil
+}
+
+// SaveProfile stores the editable fields of the profile; HasAvatar is only
+// changed by SetAvatar.
+func (r *repo) SaveProfile(profile types.Profile) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	result, err := r.DB.Conn.Exec(`
+		INSERT INTO account_profile (account_id, display_name, email, bio, timezone, locale, updated_at)
+		SELECT id, $2, $3, $4, $5, $6, $7 FROM account WHERE username = $1
+		ON CONFLICT (account_id) DO UPDATE SET
+			display_name = EXCLUDED.display_name,
+			email = EXCLUDED.email,
+			bio = EXCLUDED.bio,
+			timezone = EXCLUDED.timezone,
+			locale = EXCLUDED.locale,
+			updated_at = EXCLUDED.updated_at
+	`, profile.Username, profile.DisplayName, profile.Email, profile.Bio, profile.Timezone, profile.Locale,
+		time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: no account found with the specified username", errDefs.ErrEntityNotFound)
+	}
+	return nil
+}
+
+func (r *repo) SetAvatar(username string, hasAvatar bool) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	result, err := r.DB.Conn.Exec(`
+		INSERT INTO account_profile (account_id, has_avatar, updated_at)
+		SELECT id, $2, $3 FROM account WHERE username = $1
+		ON CONFLICT (account_id) DO UPDATE SET
+			has_avatar = EXCLUDED.has_avatar,
+			updated_at = EXCLUDED.updated_at
+	`, username, hasAvatar, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: no account found with the specified username", errDefs.ErrEntityNotFound)
+	}
+	return nil
+}
+
+func (r *repo) doPostgresPreparationForProfile() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS account_profile (
+				account_id INTEGER PRIMARY KEY REFERENCES account(id) ON DELETE CASCADE,
+				display_name varchar(100) NOT NULL DEFAULT '',
+				email varchar(254) NOT NULL DEFAULT '',
+				bio varchar(1000) NOT NULL DEFAULT '',
+				has_avatar BOOLEAN NOT NULL DEFAULT FALSE,
+				timezone varchar(64) NOT NULL DEFAULT '',
+				locale varchar(35) NOT NULL DEFAULT '',
+				updated_at varchar(30) NOT NULL
+			);
+		`)
+		logPossibleError(err)
+	}
+}
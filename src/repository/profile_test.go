@@ -0,0 +1,55 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindProfile(t *testing.T) {
+	query := regexp.QuoteMeta(`SELECT a.username, r.name,`)
+	columns := []string{"username", "name", "display_name", "email", "bio", "has_avatar", "timezone", "locale", "updated_at"}
+
+	t.Run("Never saved", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectQuery(query).
+			WithArgs("john").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("john", "User", "", "", "", false, "", "", nil))
+
+		profile, err := r.FindProfile("john")
+		require.NoError(t, err)
+		require.Equal(t, types.Profile{Username: "john", Role: types.UserRole}, profile)
+	})
+
+	t.Run("Unknown account", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectQuery(query).WithArgs("nobody").WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := r.FindProfile("nobody")
+		require.ErrorIs(t, err, errDefs.ErrEntityNotFound)
+	})
+}
+
+func TestSaveProfileUnknownAccount(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO account_profile`)).
+		WithArgs("nobody", "", "", "", "", "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.ErrorIs(t, r.SaveProfile(types.Profile{Username: "nobody"}), errDefs.ErrEntityNotFound)
+}
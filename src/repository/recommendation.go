@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/lib/pq"
+)
+
+type RecommendationRepository interface {
+	FindInteractions() (interactions []types.Interaction, err error)
+	FindRelatedBooks(code string, limit int) (books []types.Book, err error)
+	FindBooksByCodes(codes []string) (books []types.Book, err error)
+}
+
+// reviewWeight maps 1..5 stars onto -1..1 so that poor ratings push books apart.
+const reviewWeight = `(rv.stars - 3) / 2.0`
+
+func (r *repo) FindInteractions() (interactions []types.Interaction, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	query := `
+		SELECT username, code, SUM(weight) FROM (
+			SELECT a.username, b.code, 1.0 AS weight
+			FROM shelf_item si
+			JOIN shelf s ON si.shelf_id = s.id
+			JOIN account a ON s.account_id = a.id
+			JOIN book b ON si.book_id = b.id
+			UNION ALL
+			SELECT a.username, b.code, ` + reviewWeight + ` AS weight
+			FROM review rv
+			JOIN account a ON rv.account_id = a.id
+			JOIN book b ON rv.book_id = b.id
+		) interaction
+		GROUP BY username, code
+	`
+	rows, err := r.DB.Conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	interactions = make([]types.Interaction, 0)
+	for rows.Next() {
+		var interaction types.Interaction
+		if err := rows.Scan(&interaction.Username, &interaction.Book, &interaction.Weight); err != nil {
+			return nil, err
+		}
+		interactions = append(interactions, interaction)
+	}
+	return interactions, rows.Err()
+}
+
+func (r *repo) FindRelatedBooks(code string, limit int) (books []types.Book, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	query := `
+		SELECT b.code, b.title, b.author
+		FROM book b, book origin
+		WHERE origin.code = $1 AND b.id <> origin.id
+		AND (
+			lower(b.author) = lower(origin.author)
+			OR EXISTS (
+				SELECT 1 FROM book_subject bs
+				JOIN book_subject obs ON obs.subject_id = bs.subject_id
+				WHERE bs.book_id = b.id AND obs.book_id = origin.id
+			)
+		)
+		ORDER BY lower(b.author) = lower(origin.author) DESC, b.id
+		LIMIT $2
+	`
+	rows, err := r.DB.Conn.Query(query, code, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books = make([]types.Book, 0)
+	for rows.Next() {
+		var book types.Book
+		if err := rows.Scan(&book.Code, &book.Title, &book.Author); err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+	return books, rows.Err()
+}
+
+func (r *repo) FindBooksByCodes(codes []string) (books []types.Book, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	rows, err := r.DB.Conn.Query(`SELECT code, title, author FROM book WHERE code = ANY($1)`, pq.Array(codes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books = make([]types.Book, 0)
+	for rows.Next() {
+		var book types.Book
+		if err := rows.Scan(&book.Code, &book.Title, &book.Author); err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+	return books, rows.Err()
+}
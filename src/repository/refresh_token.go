@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+// RotatedRefreshToken describes the account a refresh token was rotated for.
+type RotatedRefreshToken struct {
+	Username string
+	Role     types.Role
+	Family   string
+}
+
+// SaveRefreshToken stores the hash of the refresh token that starts a new
+// family for a login from device. Tokens issued by rotation share the family
+// of the token they replace; the device is only kept on the first. clientId
+// is the OAuth2 client the family is issued to, or empty for logins through
+// the account endpoints.
+func (r *repo) SaveRefreshToken(username string, family string, clientId string, tokenHash string, device types.DeviceInfo, expiresAt time.Time) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	_, err = r.DB.Conn.Exec(`
+		INSERT INTO refresh_token (account_id, family, client_id, token_hash, created_at, expires_at, user_agent, ip)
+		VALUES ((SELECT id FROM account WHERE username = $1), $2, $3, $4, $5, $6, $7, $8)
+	`, username, family, clientId, tokenHash, time.Now().UTC().Format(time.RFC3339), expiresAt.UTC().Format(time.RFC3339),
+		device.UserAgent, device.IP)
+	return
+}
+
+// RotateRefreshToken marks the token with oldHash as used and stores newHash
+// in the same family. Presenting a token that was already rotated out revokes
+// its whole family, since either the client or an attacker holds a stolen copy.
+// Tokens are only rotated for the client they were issued to.
+func (r *repo) RotateRefreshToken(oldHash string, newHash string, clientId string, expiresAt time.Time) (rotated RotatedRefreshToken, err error) {
+	if r.DB.Conn == nil {
+		return rotated, errDefs.ErrDatabaseOffline
+	}
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return rotated, err
+	}
+	defer tx.Rollback()
+
+	var id, accountId int64
+	var tokenClientId, tokenExpiresAt string
+	var usedAt, revokedAt sql.NullString
+	err = tx.QueryRow(`
+		SELECT rt.id, rt.account_id, rt.family, rt.client_id, rt.expires_at, rt.used_at, rt.revoked_at, a.username, ro.name
+		FROM refresh_token rt
+		JOIN account a ON rt.account_id = a.id
+		JOIN role ro ON a.role_id = ro.id
+		WHERE rt.token_hash = $1
+		FOR UPDATE OF rt
+	`, oldHash).Scan(&id, &accountId, &rotated.Family, &tokenClientId, &tokenExpiresAt, &usedAt, &revokedAt, &rotated.Username, &rotated.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return RotatedRefreshToken{}, fmt.Errorf("%w: unknown refresh token", errDefs.ErrUnauthorized)
+	} else if err != nil {
+		return RotatedRefreshToken{}, err
+	}
+
+	if tokenClientId != clientId {
+		return RotatedRefreshToken{}, fmt.Errorf("%w: refresh token was issued to another client", errDefs.ErrUnauthorized)
+	}
+
+	now := time.Now().UTC()
+	switch {
+	case revokedAt.Valid:
+		return RotatedRefreshToken{}, fmt.Errorf("%w: refresh token revoked", errDefs.ErrUnauthorized)
+	case usedAt.Valid:
+		if _, err = tx.Exec(
+			`UPDATE refresh_token SET revoked_at = $1 WHERE family = $2 AND revoked_at IS NULL`,
+			now.Format(time.RFC3339), rotated.Family,
+		); err != nil {
+			return RotatedRefreshToken{}, err
+		}
+		if err = tx.Commit(); err != nil {
+			return RotatedRefreshToken{}, err
+		}
+		return RotatedRefreshToken{}, fmt.Errorf("%w: refresh token reuse detected; all tokens of this login were revoked", errDefs.ErrUnauthorized)
+	}
+	if expiry, err := time.Parse(time.RFC3339, tokenExpiresAt); err != nil || !now.Before(expiry) {
+		return RotatedRefreshToken{}, fmt.Errorf("%w: refresh token expired", errDefs.ErrUnauthorized)
+	}
+
+	if _, err = tx.Exec(`UPDATE refresh_token SET used_at = $1 WHERE id = $2`, now.Format(time.RFC3339), id); err != nil {
+		return RotatedRefreshToken{}, err
+	}
+	if _, err = tx.Exec(`
+		INSERT INTO refresh_token (account_id, family, client_id, token_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, accountId, rotated.Family, clientId, newHash, now.Format(time.RFC3339), expiresAt.UTC().Format(time.RFC3339)); err != nil {
+		return RotatedRefreshToken{}, err
+	}
+	return rotated, tx.Commit()
+}
+
+func (r *repo) doPostgresPreparationForRefreshToken() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS refresh_token (
+				id SERIAL PRIMARY KEY,
+				account_id INTEGER NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+				family varchar(64) NOT NULL,
+				token_hash char(64) UNIQUE NOT NULL,
+				created_at varchar(30) NOT NULL,
+				expires_at varchar(30) NOT NULL,
+				used_at varchar(30),
+				revoked_at varchar(30)
+			);
+		`)
+		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`CREATE INDEX IF NOT EXISTS refresh_token_family ON refresh_token (family)`)
+		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`
+			ALTER TABLE refresh_token
+				ADD COLUMN IF NOT EXISTS user_agent varchar(500) NOT NULL DEFAULT '',
+				ADD COLUMN IF NOT EXISTS ip varchar(45) NOT NULL DEFAULT ''
+		`)
+		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`
+			ALTER TABLE refresh_token ADD COLUMN IF NOT EXISTS client_id varchar(40) NOT NULL DEFAULT ''
+		`)
+		logPossibleError(err)
+	}
+}
@@ -0,0 +1,89 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"tick_test/repository"
+	"tick_test/utils/errDefs"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateRefreshToken(t *testing.T) {
+	findToken := regexp.QuoteMeta(`FROM refresh_token rt JOIN account a ON rt.account_id = a.id`)
+	columns := []string{"id", "account_id", "family", "client_id", "expires_at", "used_at", "revoked_at", "username", "name"}
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	t.Run("Success", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(findToken).WithArgs("old").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 4, "fam", "", future, nil, nil, "john", "User"))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE refresh_token SET used_at = $1 WHERE id = $2`)).
+			WithArgs(sqlmock.AnyArg(), int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO refresh_token (account_id, family, client_id, token_hash, created_at, expires_at)`)).
+			WithArgs(int64(4), "fam", "", "new", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		rotated, err := r.RotateRefreshToken("old", "new", "", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, repository.RotatedRefreshToken{Username: "john", Role: "User", Family: "fam"}, rotated)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Reuse revokes family", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(findToken).WithArgs("old").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 4, "fam", "", future, past, nil, "john", "User"))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE refresh_token SET revoked_at = $1 WHERE family = $2 AND revoked_at IS NULL`)).
+			WithArgs(sqlmock.AnyArg(), "fam").
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+
+		_, err := r.RotateRefreshToken("old", "new", "", time.Now().Add(time.Hour))
+		require.ErrorIs(t, err, errDefs.ErrUnauthorized)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Other client", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(findToken).WithArgs("old").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 4, "fam", "client-a", future, nil, nil, "john", "User"))
+		mock.ExpectRollback()
+
+		_, err := r.RotateRefreshToken("old", "new", "client-b", time.Now().Add(time.Hour))
+		require.ErrorIs(t, err, errDefs.ErrUnauthorized)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(findToken).WithArgs("old").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 4, "fam", "", past, nil, nil, "john", "User"))
+		mock.ExpectRollback()
+
+		_, err := r.RotateRefreshToken("old", "new", "", time.Now().Add(time.Hour))
+		require.ErrorIs(t, err, errDefs.ErrUnauthorized)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/sirupsen/logrus"
+)
+
+type RegistrationRepository interface {
+	CreateInvite(invite types.Invite, codeHash string) (id int64, err error)
+	FindInvites() (invites []types.Invite, err error)
+	DeleteInvite(id int64) (err error)
+	SaveInvitedAccount(obj *types.AccountPostData, codeHash string, now time.Time) (role types.Role, err error)
+	SavePendingAccount(obj *types.AccountPostData, now time.Time) (err error)
+	FindPendingAccounts() (accounts []types.PendingAccount, err error)
+	FindPendingAccount(username string) (account types.PendingAccount, err error)
+	ApprovePendingAccount(username string) (err error)
+}
+
+func (r *repo) CreateInvite(invite types.Invite, codeHash string) (id int64, err error) {
+	if r.DB.Conn == nil {
+		return 0, errDefs.ErrDatabaseOffline
+	}
+	err = r.DB.Conn.QueryRow(`
+		INSERT INTO invite (code_hash, role_id, created_by, created_at, expires_at)
+		VALUES ($1, (SELECT id FROM role WHERE name = $2), (SELECT id FROM account WHERE username = $3), $4, $5)
+		RETURNING id
+	`, codeHash, invite.Role, invite.CreatedBy, invite.CreatedAt, invite.ExpiresAt).Scan(&id)
+	return
+}
+
+// FindInvites lists all invites, newest first.
+func (r *repo) FindInvites() (invites []types.Invite, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	rows, err := r.DB.Conn.Query(`
+		SELECT i.id, ro.name, COALESCE(c.username, $1), i.created_at, i.expires_at,
+			CASE WHEN i.used_at IS NULL THEN NULL ELSE COALESCE(u.username, $1) END, i.used_at
+		FROM invite i
+		JOIN role ro ON ro.id = i.role_id
+		LEFT JOIN account c ON c.id = i.created_by
+		LEFT JOIN account u ON u.id = i.used_by
+		ORDER BY i.id DESC
+	`, types.ErasedUsername)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	invites = make([]types.Invite, 0)
+	for rows.Next() {
+		var invite types.Invite
+		var usedBy, usedAt sql.NullString
+		if err = rows.Scan(&invite.Id, &invite.Role, &invite.CreatedBy, &invite.CreatedAt, &invite.ExpiresAt, &usedBy, &usedAt); err != nil {
+			return nil, err
+		}
+		if usedBy.Valid {
+			invite.UsedBy = &usedBy.String
+		}
+		if usedAt.Valid {
+			invite.UsedAt = &usedAt.String
+		}
+		invites = append(invites, invite)
+	}
+	err = rows.Err()
+	return
+}
+
+// DeleteInvite revokes an unused invite.
+func (r *repo) DeleteInvite(id int64) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	result, err := r.DB.Conn.Exec(`DELETE FROM invite WHERE id = $1 AND used_at IS NULL`, id)
+	if err != nil {
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: no unused invite %d", errDefs.ErrEntityNotFound, id)
+	}
+	return
+}
+
+// insertNewAccount creates the account after validateNewAccount passed and
+// returns its id.
+func insertNewAccount(tx *sql.Tx, obj *types.AccountPostData) (id int64, err error) {
+	var exists bool
+	if err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM account WHERE username = $1);`, obj.Username).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("error checking user existence: %w", err)
+	}
+	if exists {
+		return 0, fmt.Errorf("%w; user with username %s", errDefs.ErrDoesExist, obj.Username)
+	}
+	hashedPassword, err := hashPassword(obj.Password)
+	if err != nil {
+		return
+	}
+	err = tx.QueryRow(`
+		INSERT INTO account (username, password, role_id)
+		VALUES ($1, $2, (SELECT id FROM role WHERE name = $3))
+		RETURNING id
+	`, obj.Username, hashedPassword, obj.Role).Scan(&id)
+	return
+}
+
+// SaveInvitedAccount creates the account with the role of the invite whose
+// code hashes to codeHash and uses the invite up, both or neither.
+func (r *repo) SaveInvitedAccount(obj *types.AccountPostData, codeHash string, now time.Time) (role types.Role, err error) {
+	if r.DB.Conn == nil {
+		return "", errDefs.ErrDatabaseOffline
+	}
+	if err = validateNewAccount(obj); err != nil {
+		return
+	}
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// the row lock keeps a second registration with the same code waiting
+	// until this one has used it up
+	var inviteId int64
+	err = tx.QueryRow(`
+		SELECT i.id, ro.name
+		FROM invite i JOIN role ro ON ro.id = i.role_id
+		WHERE i.code_hash = $1 AND i.used_at IS NULL AND i.expires_at > $2
+		FOR UPDATE OF i
+	`, codeHash, now.UTC().Format(time.RFC3339)).Scan(&inviteId, &role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: invite code invalid, expired or already used", errDefs.ErrForbidden)
+	}
+	if err != nil {
+		return
+	}
+	obj.Role = string(role)
+	accountId, err := insertNewAccount(tx, obj)
+	if err != nil {
+		return
+	}
+	if _, err = tx.Exec(`
+		UPDATE invite SET used_by = $2, used_at = $3 WHERE id = $1
+	`, inviteId, accountId, now.UTC().Format(time.RFC3339)); err != nil {
+		return
+	}
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	logrus.Info("new account ", obj.Username, " invited as ", role)
+	return role, nil
+}
+
+// SavePendingAccount creates the account, which cannot log in until it is
+// approved.
+func (r *repo) SavePendingAccount(obj *types.AccountPostData, now time.Time) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	if err = validateNewAccount(obj); err != nil {
+		return
+	}
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	accountId, err := insertNewAccount(tx, obj)
+	if err != nil {
+		return
+	}
+	if _, err = tx.Exec(`
+		INSERT INTO pending_account (account_id, requested_at) VALUES ($1, $2)
+	`, accountId, now.UTC().Format(time.RFC3339)); err != nil {
+		return
+	}
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	logrus.Info("new account ", obj.Username, " awaiting approval")
+	return
+}
+
+// FindPendingAccounts lists the registrations awaiting approval, oldest
+// first.
+func (r *repo) FindPendingAccounts() (accounts []types.PendingAccount, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	rows, err := r.DB.Conn.Query(`
+		SELECT a.username, p.requested_at
+		FROM pending_account p JOIN account a ON a.id = p.account_id
+		ORDER BY p.requested_at, a.username
+	`)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	accounts = make([]types.PendingAccount, 0)
+	for rows.Next() {
+		var account types.PendingAccount
+		if err = rows.Scan(&account.Username, &account.RequestedAt); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	err = rows.Err()
+	return
+}
+
+func (r *repo) FindPendingAccount(username string) (account types.PendingAccount, err error) {
+	if r.DB.Conn == nil {
+		return account, errDefs.ErrDatabaseOffline
+	}
+	err = r.DB.Conn.QueryRow(`
+		SELECT a.username, p.requested_at
+		FROM pending_account p JOIN account a ON a.id = p.account_id
+		WHERE a.username = $1
+	`, username).Scan(&account.Username, &account.RequestedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return account, fmt.Errorf("%w: no registration of %s awaits approval", errDefs.ErrEntityNotFound, username)
+	}
+	return
+}
+
+func (r *repo) ApprovePendingAccount(username string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	result, err := r.DB.Conn.Exec(`
+		DELETE FROM pending_account
+		WHERE account_id = (SELECT id FROM account WHERE username = $1)
+	`, username)
+	if err != nil {
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: no registration of %s awaits approval", errDefs.ErrEntityNotFound, username)
+	}
+	return
+}
+
+// checkApproval refuses accounts whose registration awaits approval.
+func (r *repo) checkApproval(username string) (err error) {
+	var pending bool
+	err = r.DB.Conn.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM pending_account p JOIN account a ON a.id = p.account_id WHERE a.username = $1)
+	`, username).Scan(&pending)
+	if err != nil {
+		return
+	}
+	if pending {
+		return fmt.Errorf("%w: account is awaiting approval", errDefs.ErrForbidden)
+	}
+	return nil
+}
+
+func (r *repo) doPostgresPreparationForRegistration() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS invite (
+				id SERIAL PRIMARY KEY,
+				code_hash char(64) UNIQUE NOT NULL,
+				role_id INTEGER NOT NULL REFERENCES role(id) ON DELETE CASCADE,
+				created_by INTEGER REFERENCES account(id) ON DELETE SET NULL,
+				created_at varchar(30) NOT NULL,
+				expires_at varchar(30) NOT NULL,
+				used_by INTEGER REFERENCES account(id) ON DELETE SET NULL,
+				used_at varchar(30)
+			);
+		`)
+		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS pending_account (
+				account_id INTEGER PRIMARY KEY REFERENCES account(id) ON DELETE CASCADE,
+				requested_at varchar(30) NOT NULL
+			);
+		`)
+		logPossibleError(err)
+	}
+}
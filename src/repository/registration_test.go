@@ -0,0 +1,101 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/passhash"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveInvitedAccount(t *testing.T) {
+	selectInvite := regexp.QuoteMeta(`SELECT i.id, ro.name FROM invite i JOIN role ro ON ro.id = i.role_id`)
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	account := func() *types.AccountPostData {
+		return &types.AccountPostData{Username: "jane.doe", Password: "correct horse battery", SamePassword: "correct horse battery", InviteCode: "code"}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectInvite).
+			WithArgs("hash", "2025-03-07T12:00:00Z").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(4, "BookKeeper"))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM account WHERE username = $1);`)).
+			WithArgs("jane.doe").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO account (username, password, role_id)`)).
+			WithArgs("jane.doe", sqlmock.AnyArg(), "BookKeeper").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE invite SET used_by = $2, used_at = $3 WHERE id = $1`)).
+			WithArgs(int64(4), int64(9), "2025-03-07T12:00:00Z").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		obj := account()
+		role, err := r.SaveInvitedAccount(obj, "hash", now)
+		require.NoError(t, err)
+		require.Equal(t, types.BookKeeperRole, role)
+		require.Equal(t, "BookKeeper", obj.Role)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid, expired or used code", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectInvite).
+			WithArgs("hash", "2025-03-07T12:00:00Z").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+		mock.ExpectRollback()
+
+		_, err := r.SaveInvitedAccount(account(), "hash", now)
+		require.ErrorIs(t, err, errDefs.ErrForbidden)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestConfirmAccountPending(t *testing.T) {
+	hashedPassword, _ := passhash.DefaultArgon2id.Hash("password123")
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT password FROM account WHERE $1 = username`)).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow(hashedPassword))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT s.reason, s.until FROM account_suspension s`)).
+		WithArgs("john", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"reason", "until"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM pending_account p`)).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	err := r.ConfirmAccount("john", "password123")
+	require.ErrorIs(t, err, errDefs.ErrForbidden)
+	require.EqualError(t, err, "forbidden: account is awaiting approval")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApprovePendingAccountNotFound(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM pending_account`)).
+		WithArgs("john").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.ErrorIs(t, r.ApprovePendingAccount("john"), errDefs.ErrEntityNotFound)
+}
@@ -15,5 +15,23 @@ type Repository interface {
 	BookRepository
 	ManipulatorRepository
 	MessageRepository
+	ReviewRepository
+	SubjectRepository
+	ShelfRepository
+	RecommendationRepository
+	DuplicateRepository
+	RevisionRepository
+	RevocationRepository
+	SessionRepository
+	TwoFactorRepository
+	ApiKeyRepository
+	RoleRepository
+	PasswordResetRepository
+	SuspensionRepository
+	ProfileRepository
+	PersonalDataRepository
+	LoginHistoryRepository
+	OAuthClientRepository
+	RegistrationRepository
 	DoPostgresPreparation() (db *sql.DB, err error)
 }
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+type ReviewRepository interface {
+	FindReviewsForBook(code string) (reviews []types.Review, err error)
+	FindReview(code string, username string) (review types.Review, err error)
+	SaveReview(code string, username string, data *types.ReviewPostData) (review types.Review, err error)
+	UpdateReview(code string, username string, data *types.ReviewPatchData) (review types.Review, err error)
+	RemoveReview(code string, username string) (n int64, err error)
+	FindBookRating(code string) (rating types.BookRating, err error)
+}
+
+func (r *repo) findBookIdByCode(code string) (id int64, err error) {
+	err = r.DB.Conn.QueryRow(`SELECT id FROM book WHERE code = $1`, code).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: book with code %q not found", errDefs.ErrEntityNotFound, code)
+	}
+	return
+}
+
+func (r *repo) FindReviewsForBook(code string) (reviews []types.Review, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	if _, err = r.findBookIdByCode(code); err != nil {
+		return nil, err
+	}
+
+	// reviews stay when their author is erased, with account_id set to NULL
+	query := `
+		SELECT b.code, COALESCE(a.username, $2), rv.stars, rv.content, rv.created_at, rv.updated_at
+		FROM review rv
+		JOIN book b ON rv.book_id = b.id
+		LEFT JOIN account a ON rv.account_id = a.id
+		WHERE b.code = $1
+		ORDER BY rv.id
+	`
+	rows, err := r.DB.Conn.Query(query, code, types.ErasedUsername)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews = make([]types.Review, 0)
+	for rows.Next() {
+		var review types.Review
+		if err := rows.Scan(&review.Book, &review.Username, &review.Stars, &review.Content, &review.CreatedAt, &review.UpdatedAt); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+func (r *repo) FindReview(code string, username string) (review types.Review, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	query := `
+		SELECT b.code, a.username, rv.stars, rv.content, rv.created_at, rv.updated_at
+		FROM review rv
+		JOIN book b ON rv.book_id = b.id
+		JOIN account a ON rv.account_id = a.id
+		WHERE b.code = $1 AND a.username = $2
+	`
+	err = r.DB.Conn.QueryRow(query, code, username).Scan(
+		&review.Book, &review.Username, &review.Stars, &review.Content, &review.CreatedAt, &review.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Review{}, fmt.Errorf("%w: review by %q for book %q not found", errDefs.ErrEntityNotFound, username, code)
+	}
+	return
+}
+
+func (r *repo) SaveReview(code string, username string, data *types.ReviewPostData) (review types.Review, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	if data.Stars < 1 || data.Stars > 5 {
+		return review, fmt.Errorf("%w: field `Stars` must be between 1 and 5", errDefs.ErrBadRequest)
+	}
+	bookId, err := r.findBookIdByCode(code)
+	if err != nil {
+		return
+	}
+	accountId, err := r.FindAccountIdByUsername(username)
+	if err != nil {
+		return
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	result, err := r.DB.Conn.Exec(`
+		INSERT INTO review (book_id, account_id, stars, content, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (book_id, account_id) DO NOTHING
+	`, bookId, accountId, data.Stars, data.Content, now)
+	if err != nil {
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return review, fmt.Errorf("%w; review by %s for book %s", errDefs.ErrDoesExist, username, code)
+	}
+
+	return types.Review{
+		Book:      code,
+		Username:  username,
+		Stars:     data.Stars,
+		Content:   data.Content,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+func (r *repo) UpdateReview(code string, username string, data *types.ReviewPatchData) (review types.Review, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	review, err = r.FindReview(code, username)
+	if err != nil {
+		return
+	}
+
+	if data.Stars != nil {
+		if *data.Stars < 1 || *data.Stars > 5 {
+			return types.Review{}, fmt.Errorf("%w: field `Stars` must be between 1 and 5", errDefs.ErrBadRequest)
+		}
+		review.Stars = *data.Stars
+	}
+	if data.Content != nil {
+		review.Content = *data.Content
+	}
+	if data.Stars == nil && data.Content == nil {
+		return types.Review{}, fmt.Errorf("%w: no fields to update", errDefs.ErrBadRequest)
+	}
+	review.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	_, err = r.DB.Conn.Exec(`
+		UPDATE review SET stars = $1, content = $2, updated_at = $3
+		WHERE book_id = (SELECT id FROM book WHERE code = $4)
+		AND account_id = (SELECT id FROM account WHERE username = $5)
+	`, review.Stars, review.Content, review.UpdatedAt, code, username)
+	if err != nil {
+		return types.Review{}, err
+	}
+	return review, nil
+}
+
+func (r *repo) RemoveReview(code string, username string) (n int64, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	result, err := r.DB.Conn.Exec(`
+		DELETE FROM review
+		WHERE book_id = (SELECT id FROM book WHERE code = $1)
+		AND account_id = (SELECT id FROM account WHERE username = $2)
+	`, code, username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *repo) FindBookRating(code string) (rating types.BookRating, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	bookId, err := r.findBookIdByCode(code)
+	if err != nil {
+		return
+	}
+	rating.Code = code
+	err = r.DB.Conn.QueryRow(
+		`SELECT COALESCE(AVG(stars), 0), COUNT(*) FROM review WHERE book_id = $1`,
+		bookId,
+	).Scan(&rating.AverageRating, &rating.RatingCount)
+	return
+}
+
+func (r *repo) doPostgresPreparationForReview() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS review (
+				id SERIAL PRIMARY KEY,
+				book_id INTEGER NOT NULL REFERENCES book(id) ON DELETE CASCADE,
+				account_id INTEGER REFERENCES account(id) ON DELETE CASCADE,
+				stars SMALLINT NOT NULL CHECK (stars BETWEEN 1 AND 5),
+				content TEXT NOT NULL DEFAULT '',
+				created_at varchar(30) NOT NULL,
+				updated_at varchar(30) NOT NULL,
+				UNIQUE (book_id, account_id)
+			);
+		`)
+		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`ALTER TABLE review ALTER COLUMN account_id DROP NOT NULL`)
+		logPossibleError(err)
+	}
+}
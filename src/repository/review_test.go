@@ -0,0 +1,138 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveReview(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         *types.ReviewPostData
+		bookExists   bool
+		rowsAffected int64
+		expectedErr  error
+	}{
+		{
+			name:         "Success",
+			data:         &types.ReviewPostData{Stars: 4, Content: "Good read"},
+			bookExists:   true,
+			rowsAffected: 1,
+		},
+		{
+			name:         "Already reviewed",
+			data:         &types.ReviewPostData{Stars: 4, Content: "Good read"},
+			bookExists:   true,
+			rowsAffected: 0,
+			expectedErr:  errDefs.ErrConflict,
+		},
+		{
+			name:        "Book not found",
+			data:        &types.ReviewPostData{Stars: 4},
+			bookExists:  false,
+			expectedErr: errDefs.ErrEntityNotFound,
+		},
+		{
+			name:        "Stars out of range",
+			data:        &types.ReviewPostData{Stars: 6},
+			expectedErr: errDefs.ErrBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rMock, mock := setupMock(t)
+			r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+			defer r.DB.Conn.Close()
+
+			if tt.data.Stars >= 1 && tt.data.Stars <= 5 {
+				bookRows := sqlmock.NewRows([]string{"id"})
+				if tt.bookExists {
+					bookRows.AddRow(7)
+				}
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM book WHERE code = $1`)).
+					WithArgs("CODE").
+					WillReturnRows(bookRows)
+			}
+			if tt.bookExists {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM account WHERE username = $1`)).
+					WithArgs("john").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO review (book_id, account_id, stars, content, created_at, updated_at)`)).
+					WithArgs(int64(7), int64(3), tt.data.Stars, tt.data.Content, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, tt.rowsAffected))
+			}
+
+			review, err := r.SaveReview("CODE", "john", tt.data)
+
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, "CODE", review.Book)
+				require.Equal(t, "john", review.Username)
+				require.Equal(t, tt.data.Stars, review.Stars)
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestFindBookRating(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM book WHERE code = $1`)).
+		WithArgs("CODE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(AVG(stars), 0), COUNT(*) FROM review WHERE book_id = $1`)).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"avg", "count"}).AddRow(3.5, 2))
+
+	rating, err := r.FindBookRating("CODE")
+	require.NoError(t, err)
+	require.Equal(t, types.BookRating{Code: "CODE", AverageRating: 3.5, RatingCount: 2}, rating)
+}
+
+func TestFindAllBooksByRating(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM book b LEFT JOIN review rv ON rv.book_id = b.id`)).
+		WillReturnRows(sqlmock.NewRows([]string{"code", "title", "author", "average", "count"}).
+			AddRow("456", "Title 2", "Author 2", 4.5, 2).
+			AddRow("123", "Title 1", "Author 1", 0, 0))
+
+	books, err := r.FindAllBooksByRating()
+	require.NoError(t, err)
+	require.Equal(t, []types.RatedBook{
+		{Book: types.Book{Code: "456", Title: "Title 2", Author: "Author 2"}, AverageRating: 4.5, RatingCount: 2},
+		{Book: types.Book{Code: "123", Title: "Title 1", Author: "Author 1"}},
+	}, books)
+}
+
+func TestFindPaginatedBooksByRating(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM book b LEFT JOIN review rv ON rv.book_id = b.id`)).
+		WithArgs(2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"code", "title", "author", "average", "count"}).
+			AddRow("123", "Title 1", "Author 1", 0, 0))
+
+	books, err := r.FindPaginatedBooksByRating(2, 2)
+	require.NoError(t, err)
+	require.Equal(t, []types.RatedBook{{Book: types.Book{Code: "123", Title: "Title 1", Author: "Author 1"}}}, books)
+
+	_, err = r.FindPaginatedBooksByRating(0, 1)
+	require.ErrorIs(t, err, errDefs.ErrBadRequest)
+}
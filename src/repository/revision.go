@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+type RevisionRepository interface {
+	FindBookRevisions(code string) (revisions []types.BookRevision, err error)
+	RevertBook(code string, revision int, editor string) (book types.Book, err error)
+}
+
+func findBookForUpdate(tx *sql.Tx, code string) (bookId int64, book types.Book, err error) {
+	err = tx.QueryRow(
+		`SELECT id, code, title, author, COALESCE(isbn, '') FROM book WHERE code = $1 FOR UPDATE`,
+		code,
+	).Scan(&bookId, &book.Code, &book.Title, &book.Author, &book.Isbn)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = fmt.Errorf("%w: book with code %q not found", errDefs.ErrEntityNotFound, code)
+	}
+	return
+}
+
+func diffBook(before types.Book, after types.Book) []types.FieldChange {
+	changes := make([]types.FieldChange, 0)
+	for _, field := range []struct{ name, old, new string }{
+		{"title", before.Title, after.Title},
+		{"author", before.Author, after.Author},
+		{"isbn", before.Isbn, after.Isbn},
+	} {
+		if field.old != field.new {
+			changes = append(changes, types.FieldChange{Field: field.name, Old: field.old, New: field.new})
+		}
+	}
+	return changes
+}
+
+// saveBookRevision writes after to the book and records the fields that
+// differ from before. Nothing is written if no field changed.
+func saveBookRevision(tx *sql.Tx, bookId int64, before types.Book, after types.Book, editor string, revertedTo *int) (err error) {
+	changes := diffBook(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if _, err = tx.Exec(
+		`UPDATE book SET title = $1, author = $2, isbn = NULLIF($3, '') WHERE id = $4`,
+		after.Title, after.Author, after.Isbn, bookId,
+	); err != nil {
+		return err
+	}
+
+	var revisionId int64
+	err = tx.QueryRow(`
+		INSERT INTO book_revision (book_id, number, editor, created_at, reverted_to)
+		SELECT $1, COALESCE(MAX(number), 0) + 1, $2, $3, $4 FROM book_revision WHERE book_id = $1
+		RETURNING id
+	`, bookId, editor, time.Now().UTC().Format(time.RFC3339), revertedTo).Scan(&revisionId)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		if _, err = tx.Exec(
+			`INSERT INTO book_revision_change (revision_id, field, old_value, new_value) VALUES ($1, $2, $3, $4)`,
+			revisionId, change.Field, change.Old, change.New,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *repo) FindBookRevisions(code string) (revisions []types.BookRevision, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	bookId, err := r.findBookIdByCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Conn.Query(`
+		SELECT br.number, br.editor, br.created_at, br.reverted_to, brc.field, brc.old_value, brc.new_value
+		FROM book_revision br
+		JOIN book_revision_change brc ON brc.revision_id = br.id
+		WHERE br.book_id = $1
+		ORDER BY br.number DESC, brc.field
+	`, bookId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions = make([]types.BookRevision, 0)
+	for rows.Next() {
+		var revision types.BookRevision
+		var revertedTo sql.NullInt64
+		var change types.FieldChange
+		if err := rows.Scan(&revision.Number, &revision.Editor, &revision.CreatedAt, &revertedTo, &change.Field, &change.Old, &change.New); err != nil {
+			return nil, err
+		}
+		if last := len(revisions) - 1; last >= 0 && revisions[last].Number == revision.Number {
+			revisions[last].Changes = append(revisions[last].Changes, change)
+			continue
+		}
+		if revertedTo.Valid {
+			number := int(revertedTo.Int64)
+			revision.RevertedTo = &number
+		}
+		revision.Changes = []types.FieldChange{change}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// RevertBook restores the book to its state right after the given revision by
+// undoing every later revision, and records that as a new revision.
+func (r *repo) RevertBook(code string, revision int, editor string) (book types.Book, err error) {
+	if r.DB.Conn == nil {
+		return types.Book{}, errDefs.ErrDatabaseOffline
+	}
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return types.Book{}, err
+	}
+	defer tx.Rollback()
+
+	bookId, before, err := findBookForUpdate(tx, code)
+	if err != nil {
+		return types.Book{}, err
+	}
+
+	var latest int
+	if err = tx.QueryRow(`SELECT COALESCE(MAX(number), 0) FROM book_revision WHERE book_id = $1`, bookId).Scan(&latest); err != nil {
+		return types.Book{}, err
+	}
+	if revision < 0 || revision > latest {
+		return types.Book{}, fmt.Errorf("%w: revision %d of book %s", errDefs.ErrEntityNotFound, revision, code)
+	}
+
+	rows, err := tx.Query(`
+		SELECT brc.field, brc.old_value
+		FROM book_revision br
+		JOIN book_revision_change brc ON brc.revision_id = br.id
+		WHERE br.book_id = $1 AND br.number > $2
+		ORDER BY br.number DESC
+	`, bookId, revision)
+	if err != nil {
+		return types.Book{}, err
+	}
+	book = before
+	for rows.Next() {
+		var field, old string
+		if err = rows.Scan(&field, &old); err != nil {
+			rows.Close()
+			return types.Book{}, err
+		}
+		switch field {
+		case "title":
+			book.Title = old
+		case "author":
+			book.Author = old
+		case "isbn":
+			book.Isbn = old
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return types.Book{}, err
+	}
+
+	if err = saveBookRevision(tx, bookId, before, book, editor, &revision); err != nil {
+		return types.Book{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return types.Book{}, err
+	}
+	return book, nil
+}
+
+func (r *repo) doPostgresPreparationForRevision() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS book_revision (
+				id SERIAL PRIMARY KEY,
+				book_id INTEGER NOT NULL REFERENCES book(id) ON DELETE CASCADE,
+				number INTEGER NOT NULL,
+				editor varchar(100) NOT NULL,
+				created_at varchar(30) NOT NULL,
+				reverted_to INTEGER,
+				UNIQUE (book_id, number)
+			);
+		`)
+		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS book_revision_change (
+				revision_id INTEGER NOT NULL REFERENCES book_revision(id) ON DELETE CASCADE,
+				field varchar(20) NOT NULL,
+				old_value TEXT NOT NULL,
+				new_value TEXT NOT NULL,
+				PRIMARY KEY (revision_id, field)
+			);
+		`)
+		logPossibleError(err)
+	}
+}
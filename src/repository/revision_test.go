@@ -0,0 +1,118 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func expectBookForUpdate(mock sqlmock.Sqlmock, book types.Book) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, code, title, author, COALESCE(isbn, '') FROM book WHERE code = $1 FOR UPDATE`)).
+		WithArgs(book.Code).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "code", "title", "author", "isbn"}).
+			AddRow(7, book.Code, book.Title, book.Author, book.Isbn))
+}
+
+func TestUpdateBookByCodeRecordsRevision(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectBegin()
+	expectBookForUpdate(mock, types.Book{Code: "ABC", Title: "Old title", Author: "Author"})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE book SET title = $1, author = $2, isbn = NULLIF($3, '') WHERE id = $4`)).
+		WithArgs("New title", "Author", "", int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO book_revision (book_id, number, editor, created_at, reverted_to)`)).
+		WithArgs(int64(7), "keeper", sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO book_revision_change (revision_id, field, old_value, new_value) VALUES ($1, $2, $3, $4)`)).
+		WithArgs(int64(3), "title", "Old title", "New title").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	book, err := r.UpdateBookByCode("ABC", types.Book{Title: "New title"}, "keeper")
+	require.NoError(t, err)
+	require.Equal(t, types.Book{Code: "ABC", Title: "New title", Author: "Author"}, book)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindBookRevisions(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM book WHERE code = $1`)).
+		WithArgs("ABC").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM book_revision br JOIN book_revision_change brc`)).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"number", "editor", "created_at", "reverted_to", "field", "old_value", "new_value"}).
+			AddRow(2, "admin", "2025-03-08T12:00:00Z", 0, "author", "B", "A").
+			AddRow(2, "admin", "2025-03-08T12:00:00Z", 0, "title", "Y", "X").
+			AddRow(1, "keeper", "2025-03-07T12:00:00Z", nil, "author", "A", "B"))
+
+	revisions, err := r.FindBookRevisions("ABC")
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	require.Equal(t, 2, revisions[0].Number)
+	require.Equal(t, 0, *revisions[0].RevertedTo)
+	require.Len(t, revisions[0].Changes, 2)
+	require.Nil(t, revisions[1].RevertedTo)
+	require.Equal(t, []types.FieldChange{{Field: "author", Old: "A", New: "B"}}, revisions[1].Changes)
+}
+
+func TestRevertBook(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		expectBookForUpdate(mock, types.Book{Code: "ABC", Title: "Third", Author: "Author"})
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(number), 0) FROM book_revision WHERE book_id = $1`)).
+			WithArgs(int64(7)).
+			WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(2))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT brc.field, brc.old_value`)).
+			WithArgs(int64(7), 0).
+			WillReturnRows(sqlmock.NewRows([]string{"field", "old_value"}).
+				AddRow("title", "Second").
+				AddRow("title", "First"))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE book SET title = $1, author = $2, isbn = NULLIF($3, '') WHERE id = $4`)).
+			WithArgs("First", "Author", "", int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO book_revision (book_id, number, editor, created_at, reverted_to)`)).
+			WithArgs(int64(7), "admin", sqlmock.AnyArg(), 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO book_revision_change`)).
+			WithArgs(int64(9), "title", "Third", "First").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		book, err := r.RevertBook("ABC", 0, "admin")
+		require.NoError(t, err)
+		require.Equal(t, "First", book.Title)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown revision", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectBegin()
+		expectBookForUpdate(mock, types.Book{Code: "ABC", Title: "Title", Author: "Author"})
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(number), 0) FROM book_revision WHERE book_id = $1`)).
+			WithArgs(int64(7)).
+			WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(2))
+		mock.ExpectRollback()
+
+		_, err := r.RevertBook("ABC", 5, "admin")
+		require.ErrorIs(t, err, errDefs.ErrEntityNotFound)
+	})
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+type RevocationRepository interface {
+	RevokeToken(jti string, username string, expiresAt time.Time) (err error)
+	RevokeAllTokens(username string) (err error)
+	RevokeRefreshTokenFamily(username string, tokenHash string) (err error)
+	RevokeAccountSession(username string, kind types.SessionKind, key string) (err error)
+	FindRevocations() (tokens []types.RevokedToken, cutoffs []types.TokenCutoff, err error)
+	DeleteExpiredRevocations(now time.Time, cutoffsBefore time.Time) (err error)
+}
+
+// tokenCutoffLayout keeps milliseconds at a fixed width so that cutoffs
+// compare correctly as strings.
+const tokenCutoffLayout = "2006-01-02T15:04:05.000Z07:00"
+
+var tokensRevokedHook func(cutoff types.TokenCutoff)
+
+// SetTokensRevokedHook registers fn to be told whenever all tokens of an
+// account get revoked, e.g. by a password change.
+func SetTokensRevokedHook(fn func(cutoff types.TokenCutoff)) {
+	tokensRevokedHook = fn
+}
+
+func (r *repo) RevokeToken(jti string, username string, expiresAt time.Time) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	_, err = r.DB.Conn.Exec(`
+		INSERT INTO revoked_token (jti, account_id, expires_at)
+		VALUES ($1, (SELECT id FROM account WHERE username = $2), $3)
+		ON CONFLICT (jti) DO NOTHING
+	`, jti, username, expiresAt.UTC().Format(time.RFC3339))
+	return
+}
+
+// RevokeAllTokens invalidates every access token issued to the account so far
+// and all of its refresh tokens and sessions.
+func (r *repo) RevokeAllTokens(username string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	cutoff, err := r.revokeAllTokens(tx, username)
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	notifyTokensRevoked(cutoff)
+	return nil
+}
+
+// revokeAllTokens does the work of RevokeAllTokens inside tx, so that
+// callers changing credentials revoke in the same transaction. They pass the
+// returned cutoff to notifyTokensRevoked once tx is committed.
+func (r *repo) revokeAllTokens(tx *sql.Tx, username string) (cutoff types.TokenCutoff, err error) {
+	now := time.Now().UTC()
+	if _, err = tx.Exec(`
+		INSERT INTO token_cutoff (account_id, revoked_before)
+		SELECT id, $2 FROM account WHERE username = $1
+		ON CONFLICT (account_id) DO UPDATE SET revoked_before = EXCLUDED.revoked_before
+	`, username, now.Format(tokenCutoffLayout)); err != nil {
+		return
+	}
+	if _, err = tx.Exec(`
+		UPDATE refresh_token SET revoked_at = $2
+		WHERE revoked_at IS NULL AND account_id = (SELECT id FROM account WHERE username = $1)
+	`, username, now.Format(time.RFC3339)); err != nil {
+		return
+	}
+	if _, err = tx.Exec(`
+		UPDATE session SET revoked_at = $2
+		WHERE revoked_at IS NULL AND account_id = (SELECT id FROM account WHERE username = $1)
+	`, username, now.Format(time.RFC3339)); err != nil {
+		return
+	}
+	return types.TokenCutoff{Username: username, RevokedBefore: now}, nil
+}
+
+func notifyTokensRevoked(cutoff types.TokenCutoff) {
+	if tokensRevokedHook != nil {
+		tokensRevokedHook(cutoff)
+	}
+}
+
+func (r *repo) RevokeRefreshTokenFamily(username string, tokenHash string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	_, err = r.DB.Conn.Exec(`
+		UPDATE refresh_token SET revoked_at = $3
+		WHERE revoked_at IS NULL AND family = (
+			SELECT rt.family FROM refresh_token rt
+			JOIN account a ON rt.account_id = a.id
+			WHERE rt.token_hash = $2 AND a.username = $1
+		)
+	`, username, tokenHash, time.Now().UTC().Format(time.RFC3339))
+	return
+}
+
+// RevokeAccountSession ends one login of the account: a server-side session
+// by its id or the refresh tokens of a JWT login by their family.
+func (r *repo) RevokeAccountSession(username string, kind types.SessionKind, key string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	var result sql.Result
+	if kind == types.ServerSession {
+		id, parseErr := strconv.ParseInt(key, 10, 64)
+		if parseErr != nil {
+			return fmt.Errorf("%w: session not found", errDefs.ErrEntityNotFound)
+		}
+		result, err = r.DB.Conn.Exec(`
+			UPDATE session SET revoked_at = $3
+			WHERE id = $2 AND revoked_at IS NULL AND account_id = (SELECT id FROM account WHERE username = $1)
+		`, username, id, now)
+	} else {
+		result, err = r.DB.Conn.Exec(`
+			UPDATE refresh_token SET revoked_at = $3
+			WHERE family = $2 AND revoked_at IS NULL AND account_id = (SELECT id FROM account WHERE username = $1)
+		`, username, key, now)
+	}
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: session not found", errDefs.ErrEntityNotFound)
+	}
+	return nil
+}
+
+func (r *repo) FindRevocations() (tokens []types.RevokedToken, cutoffs []types.TokenCutoff, err error) {
+	if r.DB.Conn == nil {
+		return nil, nil, errDefs.ErrDatabaseOffline
+	}
+	rows, err := r.DB.Conn.Query(`SELECT jti, expires_at FROM revoked_token`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	tokens = make([]types.RevokedToken, 0)
+	for rows.Next() {
+		var token types.RevokedToken
+		var expiresAt string
+		if err := rows.Scan(&token.Jti, &expiresAt); err != nil {
+			return nil, nil, err
+		}
+		if token.ExpiresAt, err = time.Parse(time.RFC3339, expiresAt); err != nil {
+			return nil, nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	cutoffRows, err := r.DB.Conn.Query(`
+		SELECT a.username, tc.revoked_before
+		FROM token_cutoff tc JOIN account a ON tc.account_id = a.id
+	`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cutoffRows.Close()
+
+	cutoffs = make([]types.TokenCutoff, 0)
+	for cutoffRows.Next() {
+		var cutoff types.TokenCutoff
+		var revokedBefore string
+		if err := cutoffRows.Scan(&cutoff.Username, &revokedBefore); err != nil {
+			return nil, nil, err
+		}
+		if cutoff.RevokedBefore, err = time.Parse(tokenCutoffLayout, revokedBefore); err != nil {
+			return nil, nil, err
+		}
+		cutoffs = append(cutoffs, cutoff)
+	}
+	return tokens, cutoffs, cutoffRows.Err()
+}
+
+// DeleteExpiredRevocations drops denylist entries of tokens that expired
+// anyway, and cutoffs older than any token that could still be valid.
+func (r *repo) DeleteExpiredRevocations(now time.Time, cutoffsBefore time.Time) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	if _, err = r.DB.Conn.Exec(`DELETE FROM revoked_token WHERE expires_at < $1`, now.UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	_, err = r.DB.Conn.Exec(`DELETE FROM token_cutoff WHERE revoked_before < $1`, cutoffsBefore.UTC().Format(tokenCutoffLayout))
+	return
+}
+
+func (r *repo) doPostgresPreparationForRevocation() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS revoked_token (
+				jti varchar(64) PRIMARY KEY,
+				account_id INTEGER REFERENCES account(id) ON DELETE CASCADE,
+				expires_at varchar(30) NOT NULL
+			);
+		`)
+		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS token_cutoff (
+				account_id INTEGER PRIMARY KEY REFERENCES account(id) ON DELETE CASCADE,
+				revoked_before varchar(40) NOT NULL
+			);
+		`)
+		logPossibleError(err)
+	}
+}
@@ -0,0 +1,58 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindRoles(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM role ro LEFT JOIN role_permission rp ON rp.role_id = ro.id`)).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "permissions"}).
+			AddRow("User", "{}").
+			AddRow("BookKeeper", "{book.manage,subject.manage}"))
+
+	roles, err := r.FindRoles()
+	require.NoError(t, err)
+	require.Equal(t, []types.RoleInfo{
+		{Name: types.UserRole, Permissions: []string{}},
+		{Name: types.BookKeeperRole, Permissions: []string{types.BookManagePermission, types.SubjectManagePermission}},
+	}, roles)
+}
+
+func TestDeleteRole(t *testing.T) {
+	count := regexp.QuoteMeta(`SELECT COUNT(*) FROM account a JOIN role ro ON a.role_id = ro.id WHERE ro.name = $1`)
+	remove := regexp.QuoteMeta(`DELETE FROM role WHERE name = $1`)
+
+	t.Run("Success", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectQuery(count).WithArgs(types.Role("Auditor")).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(remove).WithArgs(types.Role("Auditor")).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, r.DeleteRole("Auditor"))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Role in use", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectQuery(count).WithArgs(types.Role("Auditor")).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+		require.ErrorIs(t, r.DeleteRole("Auditor"), errDefs.ErrConflict)
+	})
+}
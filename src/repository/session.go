@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+type SessionRepository interface {
+	CreateSession(username string, tokenHash string, device types.DeviceInfo, idleExpiresAt time.Time, absoluteExpiresAt time.Time) (err error)
+	TouchSession(tokenHash string, now time.Time, idleExpiresAt time.Time) (id int64, username string, role types.Role, err error)
+	RevokeSession(tokenHash string) (err error)
+	FindActiveSessions(username string, now time.Time) (sessions []types.Session, err error)
+	DeleteExpiredSessions(now time.Time) (err error)
+}
+
+func (r *repo) CreateSession(username string, tokenHash string, device types.DeviceInfo, idleExpiresAt time.Time, absoluteExpiresAt time.Time) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err = r.DB.Conn.Exec(`
+		INSERT INTO session (account_id, token_hash, user_agent, ip, created_at, last_used_at, expires_at, absolute_expires_at)
+		VALUES ((SELECT id FROM account WHERE username = $1), $2, $3, $4, $5, $5, $6, $7)
+	`, username, tokenHash, device.UserAgent, device.IP, now,
+		idleExpiresAt.UTC().Format(time.RFC3339), absoluteExpiresAt.UTC().Format(time.RFC3339))
+	return
+}
+
+// TouchSession validates a session and slides its expiry forward, capped at
+// the absolute expiry fixed when the session was created.
+func (r *repo) TouchSession(tokenHash string, now time.Time, idleExpiresAt time.Time) (id int64, username string, role types.Role, err error) {
+	if r.DB.Conn == nil {
+		return 0, "", "", errDefs.ErrDatabaseOffline
+	}
+	err = r.DB.Conn.QueryRow(`
+		UPDATE session s
+		SET last_used_at = $2, expires_at = LEAST($3, s.absolute_expires_at)
+		FROM account a JOIN role ro ON a.role_id = ro.id
+		WHERE s.account_id = a.id AND s.token_hash = $1 AND s.revoked_at IS NULL AND s.expires_at > $2
+		RETURNING s.id, a.username, ro.name
+	`, tokenHash, now.UTC().Format(time.RFC3339), idleExpiresAt.UTC().Format(time.RFC3339)).Scan(&id, &username, &role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", "", fmt.Errorf("%w: session expired or revoked", errDefs.ErrUnauthorized)
+	}
+	return
+}
+
+func (r *repo) RevokeSession(tokenHash string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	_, err = r.DB.Conn.Exec(
+		`UPDATE session SET revoked_at = $2 WHERE token_hash = $1 AND revoked_at IS NULL`,
+		tokenHash, time.Now().UTC().Format(time.RFC3339),
+	)
+	return
+}
+
+// FindActiveSessions lists the server-side sessions of the account and the
+// refresh token families of its JWT logins that can still be used, oldest
+// first. A family was last used when its newest token was issued.
+func (r *repo) FindActiveSessions(username string, now time.Time) (sessions []types.Session, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	rows, err := r.DB.Conn.Query(`
+		SELECT 'session', s.id::text, s.user_agent, s.ip, s.created_at, s.last_used_at, s.expires_at
+		FROM session s JOIN account a ON s.account_id = a.id
+		WHERE a.username = $1 AND s.revoked_at IS NULL AND s.expires_at > $2
+		UNION ALL
+		SELECT 'token', f.family, f.user_agent, f.ip, f.created_at, l.created_at, l.expires_at
+		FROM (
+			SELECT DISTINCT ON (rt.family) rt.family, rt.user_agent, rt.ip, rt.created_at
+			FROM refresh_token rt JOIN account a ON rt.account_id = a.id
+			WHERE a.username = $1
+			ORDER BY rt.family, rt.id
+		) f
+		JOIN refresh_token l ON l.family = f.family
+		WHERE l.used_at IS NULL AND l.revoked_at IS NULL AND l.expires_at > $2
+		ORDER BY 5
+	`, username, now.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions = make([]types.Session, 0)
+	for rows.Next() {
+		var session types.Session
+		var key string
+		if err := rows.Scan(&session.Kind, &key, &session.Device.UserAgent, &session.Device.IP,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt); err != nil {
+			return nil, err
+		}
+		session.Id = types.SessionId(session.Kind, key)
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// DeleteExpiredSessions drops the sessions that can no longer be used
+// because they expired or were revoked.
+func (r *repo) DeleteExpiredSessions(now time.Time) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	_, err = r.DB.Conn.Exec(
+		`DELETE FROM session WHERE expires_at <= $1 OR revoked_at IS NOT NULL`,
+		now.UTC().Format(time.RFC3339),
+	)
+	return
+}
+
+func (r *repo) doPostgresPreparationForSession() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS session (
+				id SERIAL PRIMARY KEY,
+				account_id INTEGER NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+				token_hash char(64) UNIQUE NOT NULL,
+				user_agent varchar(500) NOT NULL DEFAULT '',
+				ip varchar(45) NOT NULL DEFAULT '',
+				created_at varchar(30) NOT NULL,
+				last_used_at varchar(30) NOT NULL,
+				expires_at varchar(30) NOT NULL,
+				absolute_expires_at varchar(30) NOT NULL,
+				revoked_at varchar(30)
+			);
+		`)
+		logPossibleError(err)
+	}
+}
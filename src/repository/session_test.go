@@ -0,0 +1,121 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTouchSession(t *testing.T) {
+	touch := regexp.QuoteMeta(`UPDATE session s SET last_used_at = $2, expires_at = LEAST($3, s.absolute_expires_at)`)
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectQuery(touch).
+			WithArgs("hash", "2025-03-07T12:00:00Z", "2025-03-07T12:30:00Z").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "name"}).AddRow(12, "john", "User"))
+
+		id, username, role, err := r.TouchSession("hash", now, now.Add(30*time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, int64(12), id)
+		require.Equal(t, "john", username)
+		require.Equal(t, types.UserRole, role)
+	})
+
+	t.Run("Expired or revoked", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectQuery(touch).
+			WithArgs("hash", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "name"}))
+
+		_, _, _, err := r.TouchSession("hash", now, now.Add(30*time.Minute))
+		require.ErrorIs(t, err, errDefs.ErrUnauthorized)
+	})
+}
+
+func TestFindActiveSessions(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT 'session', s.id::text, s.user_agent, s.ip, s.created_at, s.last_used_at, s.expires_at FROM session s`)).
+		WithArgs("john", "2025-03-07T12:00:00Z").
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "key", "user_agent", "ip", "created_at", "last_used_at", "expires_at"}).
+			AddRow("session", "12", "Firefox", "10.0.0.1", "2025-03-01T08:00:00Z", "2025-03-07T11:50:00Z", "2025-03-07T12:20:00Z").
+			AddRow("token", "fam-1", "curl/8.0", "10.0.0.2", "2025-03-02T08:00:00Z", "2025-03-07T10:00:00Z", "2025-04-06T10:00:00Z"))
+
+	sessions, err := r.FindActiveSessions("john", now)
+	require.NoError(t, err)
+	require.Equal(t, []types.Session{
+		{
+			Id: "session-12", Kind: types.ServerSession,
+			Device:    types.DeviceInfo{UserAgent: "Firefox", IP: "10.0.0.1"},
+			CreatedAt: "2025-03-01T08:00:00Z", LastUsedAt: "2025-03-07T11:50:00Z", ExpiresAt: "2025-03-07T12:20:00Z",
+		},
+		{
+			Id: "token-fam-1", Kind: types.TokenSession,
+			Device:    types.DeviceInfo{UserAgent: "curl/8.0", IP: "10.0.0.2"},
+			CreatedAt: "2025-03-02T08:00:00Z", LastUsedAt: "2025-03-07T10:00:00Z", ExpiresAt: "2025-04-06T10:00:00Z",
+		},
+	}, sessions)
+}
+
+func TestRevokeAccountSession(t *testing.T) {
+	t.Run("Server session", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE session SET revoked_at = $3 WHERE id = $2 AND revoked_at IS NULL`)).
+			WithArgs("john", int64(12), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, r.RevokeAccountSession("john", types.ServerSession, "12"))
+	})
+
+	t.Run("Token family of another account", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE refresh_token SET revoked_at = $3 WHERE family = $2 AND revoked_at IS NULL`)).
+			WithArgs("john", "fam-1", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		require.ErrorIs(t, r.RevokeAccountSession("john", types.TokenSession, "fam-1"), errDefs.ErrEntityNotFound)
+	})
+
+	t.Run("Malformed id", func(t *testing.T) {
+		rMock, _ := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		require.ErrorIs(t, r.RevokeAccountSession("john", types.ServerSession, "abc"), errDefs.ErrEntityNotFound)
+	})
+}
+
+func TestDeleteExpiredSessions(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM session WHERE expires_at <= $1 OR revoked_at IS NOT NULL`)).
+		WithArgs("2025-03-07T12:00:00Z").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	require.NoError(t, r.DeleteExpiredSessions(time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,536 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+type ShelfRepository interface {
+	EnsureDefaultShelves(username string) (err error)
+	FindShelvesForUser(username string) (shelves []types.Shelf, err error)
+	FindPublicShelves() (shelves []types.Shelf, err error)
+	FindShelf(id int64) (shelf types.Shelf, err error)
+	CreateShelf(username string, data *types.ShelfPostData) (shelf types.Shelf, err error)
+	UpdateShelf(id int64, data *types.ShelfPatchData) (shelf types.Shelf, err error)
+	RemoveShelf(id int64) (n int64, err error)
+	AddBookToShelf(id int64, data *types.ShelfItemPostData) (shelf types.Shelf, err error)
+	UpdateShelfItem(id int64, code string, data *types.ShelfItemPatchData) (shelf types.Shelf, err error)
+	RemoveBookFromShelf(id int64, code string) (n int64, err error)
+	ReorderShelf(id int64, codes []string) (shelf types.Shelf, err error)
+}
+
+func validateShelfVisibility(visibility types.ShelfVisibility) error {
+	switch visibility {
+	case types.PrivateShelf, types.SharedShelf, types.PublicShelf:
+		return nil
+	}
+	return fmt.Errorf(
+		"%w: field `Visibility` needs to be one of %q, %q or %q",
+		errDefs.ErrBadRequest, types.PrivateShelf, types.SharedShelf, types.PublicShelf,
+	)
+}
+
+func (r *repo) EnsureDefaultShelves(username string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	accountId, err := r.FindAccountIdByUsername(username)
+	if err != nil {
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for kind, name := range types.DefaultShelves {
+		_, err = r.DB.Conn.Exec(`
+			INSERT INTO shelf (account_id, name, kind, visibility, created_at)
+			SELECT $1, $2, $3, $4, $5
+			WHERE NOT EXISTS (SELECT 1 FROM shelf WHERE account_id = $1 AND kind = $3)
+			ON CONFLICT DO NOTHING
+		`, accountId, name, kind, types.PrivateShelf, now)
+		if err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+func (r *repo) findShelves(query string, args ...any) (shelves []types.Shelf, err error) {
+	rows, err := r.DB.Conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	shelves = make([]types.Shelf, 0, len(ids))
+	for _, id := range ids {
+		shelf, err := r.FindShelf(id)
+		if err != nil {
+			return nil, err
+		}
+		shelves = append(shelves, shelf)
+	}
+	return shelves, nil
+}
+
+func (r *repo) FindShelvesForUser(username string) (shelves []types.Shelf, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	return r.findShelves(`
+		SELECT s.id FROM shelf s
+		JOIN account a ON s.account_id = a.id
+		WHERE a.username = $1
+		OR (s.visibility = 'shared' AND EXISTS (
+			SELECT 1 FROM shelf_share ss JOIN account sa ON ss.account_id = sa.id
+			WHERE ss.shelf_id = s.id AND sa.username = $1
+		))
+		ORDER BY s.id
+	`, username)
+}
+
+func (r *repo) FindPublicShelves() (shelves []types.Shelf, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	return r.findShelves(`SELECT id FROM shelf WHERE visibility = 'public' ORDER BY id`)
+}
+
+func (r *repo) FindShelf(id int64) (shelf types.Shelf, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	err = r.DB.Conn.QueryRow(`
+		SELECT s.id, a.username, s.name, s.kind, s.visibility, s.created_at
+		FROM shelf s JOIN account a ON s.account_id = a.id
+		WHERE s.id = $1
+	`, id).Scan(&shelf.Id, &shelf.Owner, &shelf.Name, &shelf.Kind, &shelf.Visibility, &shelf.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return shelf, fmt.Errorf("%w: shelf with id %d not found", errDefs.ErrEntityNotFound, id)
+	}
+	if err != nil {
+		return
+	}
+
+	shelf.SharedWith = make([]string, 0)
+	rows, err := r.DB.Conn.Query(`
+		SELECT a.username FROM shelf_share ss
+		JOIN account a ON ss.account_id = a.id
+		WHERE ss.shelf_id = $1 ORDER BY a.username
+	`, id)
+	if err != nil {
+		return
+	}
+	for rows.Next() {
+		var username string
+		if err = rows.Scan(&username); err != nil {
+			rows.Close()
+			return
+		}
+		shelf.SharedWith = append(shelf.SharedWith, username)
+	}
+	rows.Close()
+
+	shelf.Items = make([]types.ShelfItem, 0)
+	rows, err = r.DB.Conn.Query(`
+		SELECT b.code, b.title, b.author, si.position, si.note, si.added_at
+		FROM shelf_item si JOIN book b ON si.book_id = b.id
+		WHERE si.shelf_id = $1 ORDER BY si.position
+	`, id)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item types.ShelfItem
+		if err = rows.Scan(&item.Book, &item.Title, &item.Author, &item.Position, &item.Note, &item.AddedAt); err != nil {
+			return
+		}
+		shelf.Items = append(shelf.Items, item)
+	}
+	return shelf, rows.Err()
+}
+
+func setShelfShares(tx *sql.Tx, shelfId int64, usernames []string) (err error) {
+	if _, err = tx.Exec(`DELETE FROM shelf_share WHERE shelf_id = $1`, shelfId); err != nil {
+		return
+	}
+	for _, username := range usernames {
+		var accountId int64
+		err = tx.QueryRow(`SELECT id FROM account WHERE username = $1`, username).Scan(&accountId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: username %q not found", errDefs.ErrEntityNotFound, username)
+		}
+		if err != nil {
+			return
+		}
+		_, err = tx.Exec(
+			`INSERT INTO shelf_share (shelf_id, account_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			shelfId, accountId,
+		)
+		if err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+func (r *repo) CreateShelf(username string, data *types.ShelfPostData) (shelf types.Shelf, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	name := strings.TrimSpace(data.Name)
+	if name == "" {
+		return shelf, fmt.Errorf("%w; field Name", errDefs.ErrMissingField)
+	}
+	if data.Visibility == "" {
+		data.Visibility = types.PrivateShelf
+	}
+	if err = validateShelfVisibility(data.Visibility); err != nil {
+		return
+	}
+	accountId, err := r.FindAccountIdByUsername(username)
+	if err != nil {
+		return
+	}
+
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(`
+		INSERT INTO shelf (account_id, name, kind, visibility, created_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id
+	`, accountId, name, types.CustomShelf, data.Visibility, time.Now().UTC().Format(time.RFC3339)).Scan(&id)
+	if isUniqueViolation(err) {
+		return shelf, fmt.Errorf("%w; shelf named %q", errDefs.ErrDoesExist, name)
+	}
+	if err != nil {
+		return
+	}
+	if err = setShelfShares(tx, id, data.SharedWith); err != nil {
+		return
+	}
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	return r.FindShelf(id)
+}
+
+func (r *repo) UpdateShelf(id int64, data *types.ShelfPatchData) (shelf types.Shelf, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	if data.Name == nil && data.Visibility == nil && data.SharedWith == nil {
+		return shelf, fmt.Errorf("%w: no fields to update", errDefs.ErrBadRequest)
+	}
+	if shelf, err = r.FindShelf(id); err != nil {
+		return
+	}
+
+	name := shelf.Name
+	if data.Name != nil {
+		if name = strings.TrimSpace(*data.Name); name == "" {
+			return shelf, fmt.Errorf("%w; field Name", errDefs.ErrMissingField)
+		}
+	}
+	visibility := shelf.Visibility
+	if data.Visibility != nil {
+		visibility = *data.Visibility
+		if err = validateShelfVisibility(visibility); err != nil {
+			return
+		}
+	}
+
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE shelf SET name = $1, visibility = $2 WHERE id = $3`, name, visibility, id)
+	if isUniqueViolation(err) {
+		return shelf, fmt.Errorf("%w; shelf named %q", errDefs.ErrDoesExist, name)
+	}
+	if err != nil {
+		return
+	}
+	if data.SharedWith != nil {
+		if err = setShelfShares(tx, id, *data.SharedWith); err != nil {
+			return
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	return r.FindShelf(id)
+}
+
+func (r *repo) RemoveShelf(id int64) (n int64, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	shelf, err := r.FindShelf(id)
+	if errors.Is(err, errDefs.ErrEntityNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return
+	}
+	if shelf.Kind != types.CustomShelf {
+		return 0, fmt.Errorf("%w: shelf %q cannot be deleted", errDefs.ErrBadRequest, shelf.Name)
+	}
+	result, err := r.DB.Conn.Exec(`DELETE FROM shelf WHERE id = $1`, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func clampPosition(position int, last int) int {
+	return min(last, max(0, position))
+}
+
+func (r *repo) AddBookToShelf(id int64, data *types.ShelfItemPostData) (shelf types.Shelf, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	if shelf, err = r.FindShelf(id); err != nil {
+		return
+	}
+	bookId, err := r.findBookIdByCode(data.Book)
+	if err != nil {
+		return
+	}
+	for _, item := range shelf.Items {
+		if item.Book == data.Book {
+			return shelf, fmt.Errorf("%w; book %s on shelf %q", errDefs.ErrDoesExist, data.Book, shelf.Name)
+		}
+	}
+
+	position := len(shelf.Items)
+	if data.Position != nil {
+		position = clampPosition(*data.Position, len(shelf.Items))
+	}
+
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE shelf_item SET position = position + 1 WHERE shelf_id = $1 AND position >= $2`, id, position)
+	if err != nil {
+		return
+	}
+	_, err = tx.Exec(`
+		INSERT INTO shelf_item (shelf_id, book_id, position, note, added_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, id, bookId, position, data.Note, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return
+	}
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	return r.FindShelf(id)
+}
+
+func (r *repo) UpdateShelfItem(id int64, code string, data *types.ShelfItemPatchData) (shelf types.Shelf, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	if data.Note == nil && data.Position == nil {
+		return shelf, fmt.Errorf("%w: no fields to update", errDefs.ErrBadRequest)
+	}
+	if shelf, err = r.FindShelf(id); err != nil {
+		return
+	}
+	current := -1
+	for _, item := range shelf.Items {
+		if item.Book == code {
+			current = item.Position
+		}
+	}
+	if current < 0 {
+		return shelf, fmt.Errorf("%w: book %s is not on shelf %q", errDefs.ErrEntityNotFound, code, shelf.Name)
+	}
+
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	if data.Note != nil {
+		_, err = tx.Exec(`
+			UPDATE shelf_item SET note = $1
+			WHERE shelf_id = $2 AND book_id = (SELECT id FROM book WHERE code = $3)
+		`, *data.Note, id, code)
+		if err != nil {
+			return
+		}
+	}
+	if data.Position != nil {
+		target := clampPosition(*data.Position, len(shelf.Items)-1)
+		if target < current {
+			_, err = tx.Exec(`
+				UPDATE shelf_item SET position = position + 1
+				WHERE shelf_id = $1 AND position >= $2 AND position < $3
+			`, id, target, current)
+		} else if target > current {
+			_, err = tx.Exec(`
+				UPDATE shelf_item SET position = position - 1
+				WHERE shelf_id = $1 AND position > $2 AND position <= $3
+			`, id, current, target)
+		}
+		if err != nil {
+			return
+		}
+		_, err = tx.Exec(`
+			UPDATE shelf_item SET position = $1
+			WHERE shelf_id = $2 AND book_id = (SELECT id FROM book WHERE code = $3)
+		`, target, id, code)
+		if err != nil {
+			return
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	return r.FindShelf(id)
+}
+
+func (r *repo) RemoveBookFromShelf(id int64, code string) (n int64, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var position int
+	err = tx.QueryRow(`
+		DELETE FROM shelf_item
+		WHERE shelf_id = $1 AND book_id = (SELECT id FROM book WHERE code = $2)
+		RETURNING position
+	`, id, code).Scan(&position)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return
+	}
+	_, err = tx.Exec(`UPDATE shelf_item SET position = position - 1 WHERE shelf_id = $1 AND position > $2`, id, position)
+	if err != nil {
+		return
+	}
+	return 1, tx.Commit()
+}
+
+func (r *repo) ReorderShelf(id int64, codes []string) (shelf types.Shelf, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	if shelf, err = r.FindShelf(id); err != nil {
+		return
+	}
+	onShelf := make(map[string]bool, len(shelf.Items))
+	for _, item := range shelf.Items {
+		onShelf[item.Book] = true
+	}
+	if len(codes) != len(onShelf) {
+		return shelf, fmt.Errorf("%w: field `Books` needs to list every book on the shelf exactly once", errDefs.ErrBadRequest)
+	}
+	for _, code := range codes {
+		if !onShelf[code] {
+			return shelf, fmt.Errorf("%w: field `Books` needs to list every book on the shelf exactly once", errDefs.ErrBadRequest)
+		}
+		delete(onShelf, code)
+	}
+
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	for position, code := range codes {
+		_, err = tx.Exec(`
+			UPDATE shelf_item SET position = $1
+			WHERE shelf_id = $2 AND book_id = (SELECT id FROM book WHERE code = $3)
+		`, position, id, code)
+		if err != nil {
+			return
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	return r.FindShelf(id)
+}
+
+func (r *repo) doPostgresPreparationForShelf() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS shelf (
+				id SERIAL PRIMARY KEY,
+				account_id INTEGER NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+				name varchar(100) NOT NULL,
+				kind varchar(20) NOT NULL,
+				visibility varchar(20) NOT NULL,
+				created_at varchar(30) NOT NULL,
+				UNIQUE (account_id, name)
+			);
+		`)
+		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS shelf_item (
+				shelf_id INTEGER NOT NULL REFERENCES shelf(id) ON DELETE CASCADE,
+				book_id INTEGER NOT NULL REFERENCES book(id) ON DELETE CASCADE,
+				position INTEGER NOT NULL,
+				note TEXT NOT NULL DEFAULT '',
+				added_at varchar(30) NOT NULL,
+				PRIMARY KEY (shelf_id, book_id)
+			);
+		`)
+		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS shelf_share (
+				shelf_id INTEGER NOT NULL REFERENCES shelf(id) ON DELETE CASCADE,
+				account_id INTEGER NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+				PRIMARY KEY (shelf_id, account_id)
+			);
+		`)
+		logPossibleError(err)
+	}
+}
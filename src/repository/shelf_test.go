@@ -0,0 +1,84 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"tick_test/repository"
+	"tick_test/utils/errDefs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func expectFindShelf(mock sqlmock.Sqlmock, id int64, codes ...string) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT s.id, a.username, s.name, s.kind, s.visibility, s.created_at`)).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "name", "kind", "visibility", "created_at"}).
+			AddRow(id, "john", "Favourites", "custom", "private", "2025-03-07T12:00:00Z"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT a.username FROM shelf_share ss`)).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"username"}))
+	items := sqlmock.NewRows([]string{"code", "title", "author", "position", "note", "added_at"})
+	for position, code := range codes {
+		items.AddRow(code, "Title "+code, "Author", position, "", "2025-03-07T12:00:00Z")
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM shelf_item si JOIN book b ON si.book_id = b.id`)).
+		WithArgs(id).
+		WillReturnRows(items)
+}
+
+func TestReorderShelf(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		expectFindShelf(mock, 5, "A", "B")
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE shelf_item SET position = $1`)).
+			WithArgs(0, int64(5), "B").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE shelf_item SET position = $1`)).
+			WithArgs(1, int64(5), "A").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		expectFindShelf(mock, 5, "B", "A")
+
+		shelf, err := r.ReorderShelf(5, []string{"B", "A"})
+		require.NoError(t, err)
+		require.Equal(t, "B", shelf.Items[0].Book)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Fail - not a permutation", func(t *testing.T) {
+		rMock, mock := setupMock(t)
+		r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+		defer r.DB.Conn.Close()
+
+		expectFindShelf(mock, 5, "A", "B")
+
+		_, err := r.ReorderShelf(5, []string{"A", "A"})
+		require.ErrorIs(t, err, errDefs.ErrBadRequest)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRemoveBookFromShelf(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM shelf_item`)).
+		WithArgs(int64(5), "A").
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(0))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE shelf_item SET position = position - 1 WHERE shelf_id = $1 AND position > $2`)).
+		WithArgs(int64(5), 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	n, err := r.RemoveBookFromShelf(5, "A")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
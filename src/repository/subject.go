@@ -0,0 +1,367 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+type SubjectRepository interface {
+	FindSubjectTree() (roots []*types.Subject, err error)
+	FindSubject(id int64) (subject types.Subject, err error)
+	CreateSubject(data *types.SubjectPostData) (subject types.Subject, err error)
+	UpdateSubject(id int64, data *types.SubjectPatchData) (subject types.Subject, err error)
+	RemoveSubject(id int64) (n int64, err error)
+	FindSubjectsForBook(code string) (subjects []types.Subject, err error)
+	AssignSubjectToBook(code string, subjectId int64) (err error)
+	UnassignSubjectFromBook(code string, subjectId int64) (n int64, err error)
+}
+
+const subjectPathSeparator = " > "
+
+const subjectDescendantsQuery = `
+	WITH RECURSIVE tree AS (
+		SELECT id FROM subject WHERE id = $1
+		UNION ALL
+		SELECT s.id FROM subject s JOIN tree t ON s.parent_id = t.id
+	)
+`
+
+// findAllSubjects loads every subject keyed by id with Path filled in.
+func (r *repo) findAllSubjects() (subjects map[int64]*types.Subject, order []int64, err error) {
+	rows, err := r.DB.Conn.Query(`SELECT id, name, parent_id FROM subject ORDER BY name, id`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	subjects = make(map[int64]*types.Subject)
+	for rows.Next() {
+		var subject types.Subject
+		var parentId sql.NullInt64
+		if err := rows.Scan(&subject.Id, &subject.Name, &parentId); err != nil {
+			return nil, nil, err
+		}
+		if parentId.Valid {
+			subject.ParentId = &parentId.Int64
+		}
+		subjects[subject.Id] = &subject
+		order = append(order, subject.Id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	for _, subject := range subjects {
+		subject.Path = subjectPath(subjects, subject)
+	}
+	return subjects, order, nil
+}
+
+func subjectPath(subjects map[int64]*types.Subject, subject *types.Subject) string {
+	names := []string{subject.Name}
+	seen := map[int64]bool{subject.Id: true}
+	for subject.ParentId != nil {
+		parent, ok := subjects[*subject.ParentId]
+		if !ok || seen[parent.Id] {
+			break
+		}
+		seen[parent.Id] = true
+		names = append([]string{parent.Name}, names...)
+		subject = parent
+	}
+	return strings.Join(names, subjectPathSeparator)
+}
+
+func (r *repo) FindSubjectTree() (roots []*types.Subject, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	subjects, order, err := r.findAllSubjects()
+	if err != nil {
+		return nil, err
+	}
+
+	roots = make([]*types.Subject, 0)
+	for _, id := range order {
+		subject := subjects[id]
+		if subject.ParentId == nil {
+			roots = append(roots, subject)
+			continue
+		}
+		if parent, ok := subjects[*subject.ParentId]; ok {
+			parent.Children = append(parent.Children, subject)
+		}
+	}
+	return roots, nil
+}
+
+func (r *repo) FindSubject(id int64) (subject types.Subject, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	subjects, _, err := r.findAllSubjects()
+	if err != nil {
+		return
+	}
+	found, ok := subjects[id]
+	if !ok {
+		return subject, fmt.Errorf("%w: subject with id %d not found", errDefs.ErrEntityNotFound, id)
+	}
+	return *found, nil
+}
+
+func (r *repo) CreateSubject(data *types.SubjectPostData) (subject types.Subject, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	name := strings.TrimSpace(data.Name)
+	if name == "" {
+		return subject, fmt.Errorf("%w; field Name", errDefs.ErrMissingField)
+	}
+	if data.ParentId != nil {
+		if _, err = r.FindSubject(*data.ParentId); err != nil {
+			return
+		}
+	}
+
+	var id int64
+	err = r.DB.Conn.QueryRow(
+		`INSERT INTO subject (name, parent_id) VALUES ($1, $2) RETURNING id`,
+		name, data.ParentId,
+	).Scan(&id)
+	if isUniqueViolation(err) {
+		return subject, fmt.Errorf("%w; subject %q at this level", errDefs.ErrDoesExist, name)
+	}
+	if err != nil {
+		return
+	}
+	return r.FindSubject(id)
+}
+
+func (r *repo) UpdateSubject(id int64, data *types.SubjectPatchData) (subject types.Subject, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	if data.Name == nil && data.ParentId == nil {
+		return subject, fmt.Errorf("%w: no fields to update", errDefs.ErrBadRequest)
+	}
+	if subject, err = r.FindSubject(id); err != nil {
+		return
+	}
+
+	name := subject.Name
+	if data.Name != nil {
+		if name = strings.TrimSpace(*data.Name); name == "" {
+			return subject, fmt.Errorf("%w; field Name", errDefs.ErrMissingField)
+		}
+	}
+	parentId := subject.ParentId
+	if data.ParentId != nil {
+		parentId = nil
+		if *data.ParentId != 0 {
+			parentId = data.ParentId
+			var isDescendant bool
+			err = r.DB.Conn.QueryRow(
+				subjectDescendantsQuery+`SELECT EXISTS(SELECT 1 FROM tree WHERE id = $2)`,
+				id, *parentId,
+			).Scan(&isDescendant)
+			if err != nil {
+				return
+			}
+			if isDescendant {
+				return subject, fmt.Errorf("%w: subject cannot be moved below itself", errDefs.ErrBadRequest)
+			}
+			if _, err = r.FindSubject(*parentId); err != nil {
+				return
+			}
+		}
+	}
+
+	_, err = r.DB.Conn.Exec(`UPDATE subject SET name = $1, parent_id = $2 WHERE id = $3`, name, parentId, id)
+	if isUniqueViolation(err) {
+		return subject, fmt.Errorf("%w; subject %q at this level", errDefs.ErrDoesExist, name)
+	}
+	if err != nil {
+		return
+	}
+	return r.FindSubject(id)
+}
+
+func (r *repo) RemoveSubject(id int64) (n int64, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	result, err := r.DB.Conn.Exec(`DELETE FROM subject WHERE id = $1`, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *repo) FindSubjectsForBook(code string) (subjects []types.Subject, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	bookId, err := r.findBookIdByCode(code)
+	if err != nil {
+		return
+	}
+	all, _, err := r.findAllSubjects()
+	if err != nil {
+		return
+	}
+
+	rows, err := r.DB.Conn.Query(`SELECT subject_id FROM book_subject WHERE book_id = $1`, bookId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subjects = make([]types.Subject, 0)
+	for rows.Next() {
+		var subjectId int64
+		if err := rows.Scan(&subjectId); err != nil {
+			return nil, err
+		}
+		if subject, ok := all[subjectId]; ok {
+			subjects = append(subjects, *subject)
+		}
+	}
+	return subjects, rows.Err()
+}
+
+func (r *repo) AssignSubjectToBook(code string, subjectId int64) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	bookId, err := r.findBookIdByCode(code)
+	if err != nil {
+		return
+	}
+	var exists bool
+	err = r.DB.Conn.QueryRow(`SELECT EXISTS(SELECT 1 FROM subject WHERE id = $1)`, subjectId).Scan(&exists)
+	if err != nil {
+		return
+	}
+	if !exists {
+		return fmt.Errorf("%w: subject with id %d not found", errDefs.ErrEntityNotFound, subjectId)
+	}
+	_, err = r.DB.Conn.Exec(
+		`INSERT INTO book_subject (book_id, subject_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		bookId, subjectId,
+	)
+	return
+}
+
+func (r *repo) UnassignSubjectFromBook(code string, subjectId int64) (n int64, err error) {
+	if r.DB.Conn == nil {
+		err = errDefs.ErrDatabaseOffline
+		return
+	}
+	result, err := r.DB.Conn.Exec(`
+		DELETE FROM book_subject
+		WHERE book_id = (SELECT id FROM book WHERE code = $1) AND subject_id = $2
+	`, code, subjectId)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// inSubjectTree restricts book b to the subject selected by
+// subjectDescendantsQuery or any subject below it.
+const inSubjectTree = `
+	WHERE EXISTS (
+		SELECT 1 FROM book_subject bs
+		WHERE bs.book_id = b.id AND bs.subject_id IN (SELECT id FROM tree)
+	)
+`
+
+func (r *repo) confirmSubjectExists(subjectId int64) (err error) {
+	var exists bool
+	err = r.DB.Conn.QueryRow(`SELECT EXISTS(SELECT 1 FROM subject WHERE id = $1)`, subjectId).Scan(&exists)
+	if err != nil {
+		return
+	}
+	if !exists {
+		return fmt.Errorf("%w: subject with id %d not found", errDefs.ErrEntityNotFound, subjectId)
+	}
+	return nil
+}
+
+func (r *repo) FindBooksInSubject(subjectId int64) (books []types.Book, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	if err = r.confirmSubjectExists(subjectId); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Conn.Query(subjectDescendantsQuery+`
+		SELECT code, title, author FROM book b
+		`+inSubjectTree+`
+		ORDER BY id
+	`, subjectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books = make([]types.Book, 0)
+	for rows.Next() {
+		var book types.Book
+		if err := rows.Scan(&book.Code, &book.Title, &book.Author); err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+	return books, rows.Err()
+}
+
+func (r *repo) FindBooksInSubjectByRating(subjectId int64) (books []types.RatedBook, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	if err = r.confirmSubjectExists(subjectId); err != nil {
+		return nil, err
+	}
+	rows, err := r.DB.Conn.Query(subjectDescendantsQuery+ratedBooksQuery(inSubjectTree, ""), subjectId)
+	if err != nil {
+		return nil, err
+	}
+	return scanRatedBooks(rows)
+}
+
+func (r *repo) doPostgresPreparationForSubject() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS subject (
+				id SERIAL PRIMARY KEY,
+				name varchar(100) NOT NULL,
+				parent_id INTEGER REFERENCES subject(id) ON DELETE CASCADE
+			);
+		`)
+		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`
+			CREATE UNIQUE INDEX IF NOT EXISTS subject_name_per_parent
+			ON subject (COALESCE(parent_id, 0), lower(name));
+		`)
+		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS book_subject (
+				book_id INTEGER NOT NULL REFERENCES book(id) ON DELETE CASCADE,
+				subject_id INTEGER NOT NULL REFERENCES subject(id) ON DELETE CASCADE,
+				PRIMARY KEY (book_id, subject_id)
+			);
+		`)
+		logPossibleError(err)
+	}
+}
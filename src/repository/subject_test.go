@@ -0,0 +1,96 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func subjectRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "name", "parent_id"}).
+		AddRow(1, "Computing", nil).
+		AddRow(3, "Go", 2).
+		AddRow(4, "History", nil).
+		AddRow(2, "Programming", 1)
+}
+
+func TestFindSubjectTree(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, parent_id FROM subject ORDER BY name, id`)).
+		WillReturnRows(subjectRows())
+
+	roots, err := r.FindSubjectTree()
+	require.NoError(t, err)
+	require.Len(t, roots, 2)
+	require.Equal(t, "Computing", roots[0].Name)
+	require.Equal(t, "History", roots[1].Name)
+	require.Len(t, roots[0].Children, 1)
+	require.Len(t, roots[0].Children[0].Children, 1)
+
+	goSubject := roots[0].Children[0].Children[0]
+	require.Equal(t, "Go", goSubject.Name)
+	require.Equal(t, "Computing > Programming > Go", goSubject.Path)
+}
+
+func TestUpdateSubjectRejectsCycle(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, parent_id FROM subject ORDER BY name, id`)).
+		WillReturnRows(subjectRows())
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM tree WHERE id = $2)`)).
+		WithArgs(int64(1), int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	parentId := int64(3)
+	_, err := r.UpdateSubject(1, &types.SubjectPatchData{ParentId: &parentId})
+	require.ErrorIs(t, err, errDefs.ErrBadRequest)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindBooksInSubject(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM subject WHERE id = $1)`)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(regexp.QuoteMeta(`WITH RECURSIVE tree AS`)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"code", "title", "author"}).
+			AddRow("123", "The Go Programming Language", "Alan Donovan"))
+
+	books, err := r.FindBooksInSubject(1)
+	require.NoError(t, err)
+	require.Equal(t, []types.Book{{Code: "123", Title: "The Go Programming Language", Author: "Alan Donovan"}}, books)
+}
+
+func TestFindBooksInSubjectByRating(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM subject WHERE id = $1)`)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(regexp.QuoteMeta(`WITH RECURSIVE tree AS`)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"code", "title", "author", "average", "count"}).
+			AddRow("123", "The Go Programming Language", "Alan Donovan", 4, 1))
+
+	books, err := r.FindBooksInSubjectByRating(1)
+	require.NoError(t, err)
+	require.Equal(t, []types.RatedBook{
+		{Book: types.Book{Code: "123", Title: "The Go Programming Language", Author: "Alan Donovan"}, AverageRating: 4, RatingCount: 1},
+	}, books)
+}
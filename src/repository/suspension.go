@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+type SuspensionRepository interface {
+	SuspendAccount(suspension types.Suspension) (err error)
+	ReinstateAccount(username string) (err error)
+	FindSuspensions(now time.Time) (suspensions []types.Suspension, err error)
+}
+
+// SuspendedError describes why a suspended account is refused.
+func SuspendedError(suspension types.Suspension) error {
+	if suspension.Until == nil {
+		return fmt.Errorf("%w: account is disabled: %s", errDefs.ErrForbidden, suspension.Reason)
+	}
+	return fmt.Errorf("%w: account is suspended until %s: %s", errDefs.ErrForbidden, *suspension.Until, suspension.Reason)
+}
+
+// checkSuspension returns SuspendedError while the account is suspended.
+func (r *repo) checkSuspension(username string) (err error) {
+	suspension := types.Suspension{Username: username}
+	var until sql.NullString
+	err = r.DB.Conn.QueryRow(`
+		SELECT s.reason, s.until
+		FROM account_suspension s JOIN account a ON a.id = s.account_id
+		WHERE a.username = $1 AND (s.until IS NULL OR s.until > $2)
+	`, username, time.Now().UTC().Format(time.RFC3339)).Scan(&suspension.Reason, &until)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if until.Valid {
+		suspension.Until = &until.String
+	}
+	return SuspendedError(suspension)
+}
+
+// SuspendAccount suspends the account, replacing an earlier suspension, and
+// revokes all of its tokens and sessions.
+func (r *repo) SuspendAccount(suspension types.Suspension) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO account_suspension (account_id, reason, suspended_by, suspended_at, until)
+		SELECT id, $2, $3, $4, $5 FROM account WHERE username = $1
+		ON CONFLICT (account_id) DO UPDATE SET
+			reason = EXCLUDED.reason,
+			suspended_by = EXCLUDED.suspended_by,
+			suspended_at = EXCLUDED.suspended_at,
+			until = EXCLUDED.until
+	`, suspension.Username, suspension.Reason, suspension.SuspendedBy, suspension.SuspendedAt, suspension.Until)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: no account found with the specified username", errDefs.ErrEntityNotFound)
+	}
+	cutoff, err := r.revokeAllTokens(tx, suspension.Username)
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	notifyTokensRevoked(cutoff)
+	return nil
+}
+
+func (r *repo) ReinstateAccount(username string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	result, err := r.DB.Conn.Exec(`
+		DELETE FROM account_suspension WHERE account_id = (SELECT id FROM account WHERE username = $1)
+	`, username)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: account %q is not suspended", errDefs.ErrEntityNotFound, username)
+	}
+	return nil
+}
+
+// FindSuspensions returns the suspensions in effect at now.
+func (r *repo) FindSuspensions(now time.Time) (suspensions []types.Suspension, err error) {
+	if r.DB.Conn == nil {
+		return nil, errDefs.ErrDatabaseOffline
+	}
+	rows, err := r.DB.Conn.Query(`
+		SELECT a.username, s.reason, s.suspended_by, s.suspended_at, s.until
+		FROM account_suspension s JOIN account a ON a.id = s.account_id
+		WHERE s.until IS NULL OR s.until > $1
+		ORDER BY s.suspended_at
+	`, now.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	suspensions = make([]types.Suspension, 0)
+	for rows.Next() {
+		var suspension types.Suspension
+		var until sql.NullString
+		if err = rows.Scan(&suspension.Username, &suspension.Reason, &suspension.SuspendedBy, &suspension.SuspendedAt, &until); err != nil {
+			return nil, err
+		}
+		if until.Valid {
+			suspension.Until = &until.String
+		}
+		suspensions = append(suspensions, suspension)
+	}
+	err = rows.Err()
+	return
+}
+
+func (r *repo) doPostgresPreparationForSuspension() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS account_suspension (
+				account_id INTEGER PRIMARY KEY REFERENCES account(id) ON DELETE CASCADE,
+				reason varchar(500) NOT NULL,
+				suspended_by varchar(100) NOT NULL,
+				suspended_at varchar(30) NOT NULL,
+				until varchar(30)
+			);
+		`)
+		logPossibleError(err)
+	}
+}
@@ -0,0 +1,103 @@
+package repository_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestConfirmAccountSuspended(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	selectSuspension := regexp.QuoteMeta(`SELECT s.reason, s.until FROM account_suspension s`)
+
+	tests := []struct {
+		name   string
+		until  any
+		errMsg string
+	}{
+		{name: "Suspended", until: "2025-04-01T00:00:00Z", errMsg: "forbidden: account is suspended until 2025-04-01T00:00:00Z: spam"},
+		{name: "Disabled", until: nil, errMsg: "forbidden: account is disabled: spam"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rMock, mock := setupMock(t)
+			r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+			defer r.DB.Conn.Close()
+
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT password FROM account WHERE $1 = username`)).
+				WithArgs("john").
+				WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow(string(hashedPassword)))
+			mock.ExpectQuery(selectSuspension).
+				WithArgs("john", sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"reason", "until"}).AddRow("spam", tt.until))
+
+			err := r.ConfirmAccount("john", "password123")
+			require.ErrorIs(t, err, errDefs.ErrForbidden)
+			require.EqualError(t, err, tt.errMsg)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestReinstateAccountNotSuspended(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM account_suspension`)).
+		WithArgs("john").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.ErrorIs(t, r.ReinstateAccount("john"), errDefs.ErrEntityNotFound)
+}
+
+func TestSuspendAccount(t *testing.T) {
+	tests := []struct {
+		name        string
+		revokeErr   error
+		expectError bool
+	}{
+		{name: "Success"},
+		{name: "Failing revocation keeps the account unsuspended", revokeErr: errors.New("connection reset"), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rMock, mock := setupMock(t)
+			r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+			defer r.DB.Conn.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO account_suspension`)).
+				WithArgs("john", "spam", "admin", "2025-03-07T12:00:00Z", nil).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			if tt.revokeErr != nil {
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO token_cutoff`)).WillReturnError(tt.revokeErr)
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO token_cutoff`)).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE refresh_token SET revoked_at = $2`)).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE session SET revoked_at = $2`)).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			}
+
+			err := r.SuspendAccount(types.Suspension{Username: "john", Reason: "spam", SuspendedBy: "admin", SuspendedAt: "2025-03-07T12:00:00Z"})
+
+			if tt.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+type TwoFactorRepository interface {
+	SaveTotpSecret(username string, secret string) (err error)
+	FindTotp(username string) (state types.TotpState, err error)
+	EnableTotp(username string, counter int64, recoveryCodeHashes []string) (err error)
+	UseTotpCounter(username string, counter int64) (err error)
+	UseRecoveryCode(username string, codeHash string) (err error)
+	ReplaceRecoveryCodes(username string, recoveryCodeHashes []string) (err error)
+	DisableTotp(username string) (err error)
+}
+
+// SaveTotpSecret stores a secret awaiting confirmation, replacing an earlier
+// unconfirmed one.
+func (r *repo) SaveTotpSecret(username string, secret string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	result, err := r.DB.Conn.Exec(`
+		INSERT INTO account_totp (account_id, secret, enabled, last_counter, created_at)
+		SELECT id, $2, FALSE, 0, $3 FROM account WHERE username = $1
+		ON CONFLICT (account_id) DO UPDATE
+		SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at
+		WHERE account_totp.enabled = FALSE
+	`, username, secret, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: two-factor authentication is already enabled", errDefs.ErrConflict)
+	}
+	return
+}
+
+func (r *repo) FindTotp(username string) (state types.TotpState, err error) {
+	if r.DB.Conn == nil {
+		return state, errDefs.ErrDatabaseOffline
+	}
+	err = r.DB.Conn.QueryRow(`
+		SELECT t.secret, t.enabled, t.last_counter
+		FROM account_totp t JOIN account a ON a.id = t.account_id
+		WHERE a.username = $1
+	`, username).Scan(&state.Secret, &state.Enabled, &state.LastCounter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return state, fmt.Errorf("%w: two-factor authentication is not set up", errDefs.ErrEntityNotFound)
+	}
+	return
+}
+
+func (r *repo) EnableTotp(username string, counter int64, recoveryCodeHashes []string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE account_totp t SET enabled = TRUE, last_counter = $2
+		FROM account a
+		WHERE a.id = t.account_id AND a.username = $1 AND t.enabled = FALSE
+	`, username, counter)
+	if err != nil {
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: two-factor authentication is already enabled", errDefs.ErrConflict)
+	}
+	if err = replaceRecoveryCodes(tx, username, recoveryCodeHashes); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+// UseTotpCounter marks the time step of an accepted code as used. A code of
+// the same or an earlier step is refused, so every code works only once.
+func (r *repo) UseTotpCounter(username string, counter int64) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	result, err := r.DB.Conn.Exec(`
+		UPDATE account_totp t SET last_counter = $2
+		FROM account a
+		WHERE a.id = t.account_id AND a.username = $1 AND t.enabled = TRUE AND t.last_counter < $2
+	`, username, counter)
+	if err != nil {
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: code has already been used", errDefs.ErrUnauthorized)
+	}
+	return
+}
+
+func (r *repo) UseRecoveryCode(username string, codeHash string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	result, err := r.DB.Conn.Exec(`
+		UPDATE totp_recovery_code c SET used_at = $3
+		FROM account a
+		WHERE a.id = c.account_id AND a.username = $1 AND c.code_hash = $2 AND c.used_at IS NULL
+	`, username, codeHash, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: invalid recovery code", errDefs.ErrUnauthorized)
+	}
+	return
+}
+
+func (r *repo) ReplaceRecoveryCodes(username string, recoveryCodeHashes []string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+	if err = replaceRecoveryCodes(tx, username, recoveryCodeHashes); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, username string, recoveryCodeHashes []string) (err error) {
+	_, err = tx.Exec(`
+		DELETE FROM totp_recovery_code WHERE account_id = (SELECT id FROM account WHERE username = $1)
+	`, username)
+	if err != nil {
+		return
+	}
+	for _, hash := range recoveryCodeHashes {
+		_, err = tx.Exec(`
+			INSERT INTO totp_recovery_code (account_id, code_hash)
+			VALUES ((SELECT id FROM account WHERE username = $1), $2)
+		`, username, hash)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (r *repo) DisableTotp(username string) (err error) {
+	if r.DB.Conn == nil {
+		return errDefs.ErrDatabaseOffline
+	}
+	tx, err := r.DB.Conn.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+	if err = replaceRecoveryCodes(tx, username, nil); err != nil {
+		return
+	}
+	_, err = tx.Exec(`
+		DELETE FROM account_totp WHERE account_id = (SELECT id FROM account WHERE username = $1)
+	`, username)
+	if err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+func (r *repo) doPostgresPreparationForTwoFactor() {
+	if r.DB.Conn != nil {
+		_, err := r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS account_totp (
+				account_id INTEGER PRIMARY KEY REFERENCES account(id) ON DELETE CASCADE,
+				secret varchar(64) NOT NULL,
+				enabled BOOLEAN NOT NULL DEFAULT FALSE,
+				last_counter BIGINT NOT NULL DEFAULT 0,
+				created_at varchar(30) NOT NULL
+			);
+		`)
+		logPossibleError(err)
+		_, err = r.DB.Conn.Exec(`
+			CREATE TABLE IF NOT EXISTS totp_recovery_code (
+				id SERIAL PRIMARY KEY,
+				account_id INTEGER NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+				code_hash char(64) NOT NULL,
+				used_at varchar(30),
+				UNIQUE (account_id, code_hash)
+			);
+		`)
+		logPossibleError(err)
+	}
+}
@@ -0,0 +1,81 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+
+	"tick_test/repository"
+	"tick_test/utils/errDefs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseTotpCounter(t *testing.T) {
+	update := regexp.QuoteMeta(`UPDATE account_totp t SET last_counter = $2`)
+
+	tests := []struct {
+		name        string
+		rows        int64
+		expectedErr error
+	}{
+		{name: "Success", rows: 1},
+		{name: "Code already used", rows: 0, expectedErr: errDefs.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rMock, mock := setupMock(t)
+			r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+			defer r.DB.Conn.Close()
+
+			mock.ExpectExec(update).
+				WithArgs("john", int64(56666666)).
+				WillReturnResult(sqlmock.NewResult(0, tt.rows))
+
+			err := r.UseTotpCounter("john", 56666666)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSaveTotpSecretAlreadyEnabled(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO account_totp (account_id, secret, enabled, last_counter, created_at)`)).
+		WithArgs("john", "SECRET", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := r.SaveTotpSecret("john", "SECRET")
+	require.ErrorIs(t, err, errDefs.ErrConflict)
+}
+
+func TestEnableTotp(t *testing.T) {
+	rMock, mock := setupMock(t)
+	r := repository.NewRepo(&repository.Database{Conn: rMock.DB})
+	defer r.DB.Conn.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE account_totp t SET enabled = TRUE, last_counter = $2`)).
+		WithArgs("john", int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM totp_recovery_code`)).
+		WithArgs("john").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, hash := range []string{"h1", "h2"} {
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO totp_recovery_code (account_id, code_hash)`)).
+			WithArgs("john", hash).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+
+	require.NoError(t, r.EnableTotp("john", 7, []string{"h1", "h2"}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
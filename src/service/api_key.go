@@ -0,0 +1,89 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/jwt"
+	"tick_test/utils/random"
+)
+
+const (
+	apiKeyMarker       = "tk_"
+	apiKeyPrefixLength = 8
+)
+
+type ApiKeyService struct {
+	Repo repository.ApiKeyRepository
+	Now  func() time.Time
+}
+
+func NewApiKeyService(repo repository.ApiKeyRepository) *ApiKeyService {
+	return &ApiKeyService{Repo: repo, Now: time.Now}
+}
+
+// Create issues a key of the form tk_<prefix>_<secret>. Only its hash is
+// stored, so the key is returned this one time.
+func (aks *ApiKeyService) Create(username string, data types.ApiKeyPostData) (types.ApiKeyCreated, error) {
+	for _, scope := range data.Scopes {
+		if !slices.Contains(types.ApiKeyScopes, scope) {
+			return types.ApiKeyCreated{}, fmt.Errorf("%w: unknown scope %q", errDefs.ErrBadRequest, scope)
+		}
+	}
+	now := aks.Now().UTC()
+	key := types.ApiKey{
+		Name:      data.Name,
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(data.Scopes))),
+		CreatedAt: now.Format(time.RFC3339),
+	}
+	if data.ExpiresIn != "" {
+		lifetime, err := types.ParseISO8601Duration(data.ExpiresIn, time.Minute)
+		if err != nil {
+			return types.ApiKeyCreated{}, fmt.Errorf("%w: expiresIn: %v", errDefs.ErrBadRequest, err)
+		}
+		expiresAt := now.Add(lifetime).Format(time.RFC3339)
+		key.ExpiresAt = &expiresAt
+	}
+
+	prefix, err := random.Token(6)
+	if err != nil {
+		return types.ApiKeyCreated{}, err
+	}
+	secret, err := random.Token(32)
+	if err != nil {
+		return types.ApiKeyCreated{}, err
+	}
+	key.Prefix = apiKeyMarker + prefix[:apiKeyPrefixLength]
+	plain := key.Prefix + "_" + secret
+
+	if key.Id, err = aks.Repo.CreateApiKey(username, key, HashToken(plain)); err != nil {
+		return types.ApiKeyCreated{}, err
+	}
+	return types.ApiKeyCreated{ApiKey: key, Key: plain}, nil
+}
+
+func (aks *ApiKeyService) List(username string) ([]types.ApiKey, error) {
+	return aks.Repo.FindApiKeys(username)
+}
+
+func (aks *ApiKeyService) Revoke(username string, id int64) error {
+	return aks.Repo.RevokeApiKey(username, id)
+}
+
+// Authenticate returns the claims of the key's owner, limited to the key's
+// scopes.
+func (aks *ApiKeyService) Authenticate(key string) (jwt.Claims, error) {
+	if !strings.HasPrefix(key, apiKeyMarker) {
+		return jwt.Claims{}, fmt.Errorf("%w: malformed API key", errDefs.ErrUnauthorized)
+	}
+	username, role, scopes, err := aks.Repo.AuthenticateApiKey(HashToken(key), aks.Now())
+	if err != nil {
+		return jwt.Claims{}, err
+	}
+	return jwt.Claims{Username: username, Role: role, Scopes: scopes}, nil
+}
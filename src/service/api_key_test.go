@@ -0,0 +1,68 @@
+package service_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+func TestApiKeyCreateAndAuthenticate(t *testing.T) {
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	stored := make(map[string]types.ApiKey)
+	repo := &mocks.ApiKeyRepositoryMock{
+		CreateApiKeyFn: func(username string, key types.ApiKey, keyHash string) (int64, error) {
+			assert.Equal(t, "robot", username)
+			stored[keyHash] = key
+			return 7, nil
+		},
+		AuthenticateApiKeyFn: func(keyHash string, at time.Time) (string, types.Role, []string, error) {
+			key, ok := stored[keyHash]
+			if !ok {
+				return "", "", nil, fmt.Errorf("%w: API key invalid, expired or revoked", errDefs.ErrUnauthorized)
+			}
+			return "robot", types.BookKeeperRole, key.Scopes, nil
+		},
+	}
+	aks := service.NewApiKeyService(repo)
+	aks.Now = func() time.Time { return now }
+
+	created, err := aks.Create("robot", types.ApiKeyPostData{
+		Name:      "import script",
+		Scopes:    []string{types.BooksWriteScope, types.BooksReadScope, types.BooksWriteScope},
+		ExpiresIn: "P30D",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), created.Id)
+	assert.True(t, strings.HasPrefix(created.Key, created.Prefix+"_"))
+	assert.Len(t, created.Prefix, len("tk_")+8)
+	assert.Equal(t, []string{types.BooksReadScope, types.BooksWriteScope}, created.Scopes)
+	require.NotNil(t, created.ExpiresAt)
+	assert.Equal(t, "2025-04-06T12:00:00Z", *created.ExpiresAt)
+	assert.NotContains(t, stored, created.Key, "only the hash is stored")
+
+	claims, err := aks.Authenticate(created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, "robot", claims.Username)
+	assert.Equal(t, types.BookKeeperRole, claims.Role)
+	assert.Equal(t, []string{types.BooksReadScope, types.BooksWriteScope}, claims.Scopes)
+
+	_, err = aks.Authenticate(created.Key + "x")
+	assert.ErrorIs(t, err, errDefs.ErrUnauthorized)
+	_, err = aks.Authenticate("not-a-key")
+	assert.ErrorIs(t, err, errDefs.ErrUnauthorized)
+}
+
+func TestApiKeyCreateUnknownScope(t *testing.T) {
+	aks := service.NewApiKeyService(&mocks.ApiKeyRepositoryMock{})
+	_, err := aks.Create("robot", types.ApiKeyPostData{Name: "x", Scopes: []string{"books:burn"}})
+	assert.ErrorIs(t, err, errDefs.ErrBadRequest)
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ClaimsContextKey is where Authorizer.Require stores the confirmed claims.
+const ClaimsContextKey = "claims"
+
+// Authorizer decides what a role may do from the permissions granted to it.
+// The grants are cached in memory and reloaded after every change.
+type Authorizer struct {
+	Repo repository.RoleRepository
+	// Authenticate confirms who sends the request.
+	Authenticate func(c *gin.Context) (jwt.Claims, error)
+	// RespondError answers requests that could not be authenticated or lack
+	// the permission.
+	RespondError func(c *gin.Context, err error)
+
+	mutex       sync.RWMutex
+	permissions map[types.Role][]string
+}
+
+func NewAuthorizer(repo repository.RoleRepository) *Authorizer {
+	return &Authorizer{
+		Repo: repo,
+		RespondError: func(c *gin.Context, err error) {
+			c.JSON(errDefs.DetermineStatus(err), gin.H{"Error": err.Error()})
+		},
+		permissions: make(map[types.Role][]string),
+	}
+}
+
+// Load replaces the cached grants with the ones in the database.
+func (a *Authorizer) Load() error {
+	roles, err := a.Repo.FindRoles()
+	if err != nil {
+		return err
+	}
+	permissions := make(map[types.Role][]string, len(roles))
+	for _, role := range roles {
+		permissions[role.Name] = role.Permissions
+	}
+	a.mutex.Lock()
+	a.permissions = permissions
+	a.mutex.Unlock()
+	return nil
+}
+
+func (a *Authorizer) HasPermission(role types.Role, permission string) bool {
+	if role == types.AdminRole {
+		return true
+	}
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return slices.Contains(a.permissions[role], permission)
+}
+
+// Covers tells whether role holds every permission of other, so that an
+// account with role may hand other out or act on accounts holding it.
+// Nothing but Admin covers Admin.
+func (a *Authorizer) Covers(role types.Role, other types.Role) bool {
+	if role == types.AdminRole {
+		return true
+	}
+	if other == types.AdminRole {
+		return false
+	}
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	for _, permission := range a.permissions[other] {
+		if !slices.Contains(a.permissions[role], permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// RoleExists tells whether accounts can be given role.
+func (a *Authorizer) RoleExists(role types.Role) bool {
+	if slices.Contains(types.BuiltInRoles, role) {
+		return true
+	}
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	_, ok := a.permissions[role]
+	return ok
+}
+
+// Require is a middleware admitting only requests whose role holds
+// permission. Handlers behind it find the claims under ClaimsContextKey.
+func (a *Authorizer) Require(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := a.Authenticate(c)
+		if err != nil {
+			a.RespondError(c, err)
+			c.Abort()
+			return
+		}
+		if !a.HasPermission(claims.Role, permission) {
+			a.RespondError(c, fmt.Errorf("%w: permission %s required", errDefs.ErrForbidden, permission))
+			c.Abort()
+			return
+		}
+		c.Set(ClaimsContextKey, claims)
+		c.Next()
+	}
+}
+
+// Roles lists all roles. The Admin role is shown with every permission.
+func (a *Authorizer) Roles() ([]types.RoleInfo, error) {
+	roles, err := a.Repo.FindRoles()
+	if err != nil {
+		return nil, err
+	}
+	for i := range roles {
+		roles[i].BuiltIn = slices.Contains(types.BuiltInRoles, roles[i].Name)
+		if roles[i].Name == types.AdminRole {
+			roles[i].Permissions = slices.Clone(types.Permissions)
+		}
+	}
+	return roles, nil
+}
+
+// CreateRole creates a role on behalf of an account with role by, which can
+// only hand out permissions it holds itself.
+func (a *Authorizer) CreateRole(by types.Role, data types.RolePostData) error {
+	for _, permission := range data.Permissions {
+		if err := validatePermission(permission); err != nil {
+			return err
+		}
+		if err := a.mayHandOut(by, permission); err != nil {
+			return err
+		}
+	}
+	if err := a.Repo.CreateRole(types.Role(data.Name), data.Permissions); err != nil {
+		return err
+	}
+	logrus.Info("created role ", data.Name, " with permissions ", data.Permissions)
+	return a.Load()
+}
+
+func (a *Authorizer) DeleteRole(role types.Role) error {
+	if slices.Contains(types.BuiltInRoles, role) {
+		return fmt.Errorf("%w: built-in role %s cannot be deleted", errDefs.ErrBadRequest, role)
+	}
+	if err := a.Repo.DeleteRole(role); err != nil {
+		return err
+	}
+	logrus.Info("deleted role ", role)
+	return a.Load()
+}
+
+// Grant grants permission to role on behalf of an account with role by,
+// which can only hand out permissions it holds itself and never to itself.
+func (a *Authorizer) Grant(by types.Role, role types.Role, permission string) error {
+	if err := a.changeable(role, permission); err != nil {
+		return err
+	}
+	if role == by {
+		return fmt.Errorf("%w: role %s cannot change its own permissions", errDefs.ErrForbidden, role)
+	}
+	if err := a.mayHandOut(by, permission); err != nil {
+		return err
+	}
+	if err := a.Repo.GrantPermission(role, permission); err != nil {
+		return err
+	}
+	logrus.Info("granted ", permission, " to role ", role)
+	return a.Load()
+}
+
+func (a *Authorizer) Revoke(role types.Role, permission string) error {
+	if err := a.changeable(role, permission); err != nil {
+		return err
+	}
+	if err := a.Repo.RevokePermission(role, permission); err != nil {
+		return err
+	}
+	logrus.Info("revoked ", permission, " from role ", role)
+	return a.Load()
+}
+
+func (a *Authorizer) changeable(role types.Role, permission string) error {
+	if role == types.AdminRole {
+		return fmt.Errorf("%w: role %s always holds every permission", errDefs.ErrBadRequest, role)
+	}
+	return validatePermission(permission)
+}
+
+func (a *Authorizer) mayHandOut(by types.Role, permission string) error {
+	if !a.HasPermission(by, permission) {
+		return fmt.Errorf("%w: role %s does not hold permission %s", errDefs.ErrForbidden, by, permission)
+	}
+	return nil
+}
+
+func validatePermission(permission string) error {
+	if !slices.Contains(types.Permissions, permission) {
+		return fmt.Errorf("%w: unknown permission %q", errDefs.ErrBadRequest, permission)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package service_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/jwt"
+)
+
+// newRoleRepository keeps role grants in memory.
+func newRoleRepository(grants map[types.Role][]string) *mocks.RoleRepositoryMock {
+	return &mocks.RoleRepositoryMock{
+		FindRolesFn: func() ([]types.RoleInfo, error) {
+			roles := make([]types.RoleInfo, 0)
+			for name, permissions := range grants {
+				roles = append(roles, types.RoleInfo{Name: name, Permissions: permissions})
+			}
+			return roles, nil
+		},
+		CreateRoleFn: func(name types.Role, permissions []string) error {
+			if _, ok := grants[name]; ok {
+				return errDefs.ErrDoesExist
+			}
+			grants[name] = permissions
+			return nil
+		},
+		GrantPermissionFn: func(role types.Role, permission string) error {
+			grants[role] = append(grants[role], permission)
+			return nil
+		},
+		RevokePermissionFn: func(role types.Role, permission string) error {
+			grants[role] = slices.DeleteFunc(grants[role], func(p string) bool { return p == permission })
+			return nil
+		},
+	}
+}
+
+func TestAuthorizerRoles(t *testing.T) {
+	grants := map[types.Role][]string{
+		types.UserRole:       {},
+		types.BookKeeperRole: {types.BookManagePermission},
+		types.AdminRole:      {},
+	}
+	authz := service.NewAuthorizer(newRoleRepository(grants))
+	require.NoError(t, authz.Load())
+
+	assert.True(t, authz.HasPermission(types.BookKeeperRole, types.BookManagePermission))
+	assert.False(t, authz.HasPermission(types.UserRole, types.BookManagePermission))
+	assert.True(t, authz.HasPermission(types.AdminRole, types.RoleManagePermission), "Admin holds every permission")
+
+	require.NoError(t, authz.CreateRole(types.AdminRole, types.RolePostData{Name: "Auditor", Permissions: []string{types.BookMergePermission}}))
+	assert.True(t, authz.RoleExists("Auditor"))
+	assert.True(t, authz.HasPermission("Auditor", types.BookMergePermission))
+
+	require.NoError(t, authz.Grant(types.AdminRole, "Auditor", types.ReviewModeratePermission))
+	assert.True(t, authz.HasPermission("Auditor", types.ReviewModeratePermission))
+	require.NoError(t, authz.Revoke("Auditor", types.BookMergePermission))
+	assert.False(t, authz.HasPermission("Auditor", types.BookMergePermission))
+
+	assert.ErrorIs(t, authz.Grant(types.AdminRole, "Auditor", "book.burn"), errDefs.ErrBadRequest)
+	assert.ErrorIs(t, authz.Revoke(types.AdminRole, types.RoleManagePermission), errDefs.ErrBadRequest)
+	assert.ErrorIs(t, authz.DeleteRole(types.BookKeeperRole), errDefs.ErrBadRequest)
+	assert.False(t, authz.RoleExists("Ghost"))
+}
+
+func TestAuthorizerHandOut(t *testing.T) {
+	grants := map[types.Role][]string{
+		types.UserRole:       {},
+		types.BookKeeperRole: {types.BookManagePermission},
+		"RoleManager":        {types.RoleManagePermission, types.BookManagePermission},
+	}
+	authz := service.NewAuthorizer(newRoleRepository(grants))
+	require.NoError(t, authz.Load())
+
+	require.NoError(t, authz.Grant("RoleManager", types.UserRole, types.BookManagePermission))
+	assert.ErrorIs(t, authz.Grant("RoleManager", types.UserRole, types.AccountPromotePermission), errDefs.ErrForbidden, "only held permissions are handed out")
+	assert.ErrorIs(t, authz.Grant("RoleManager", "RoleManager", types.BookManagePermission), errDefs.ErrForbidden, "no role changes its own permissions")
+	assert.False(t, authz.HasPermission(types.UserRole, types.AccountPromotePermission))
+
+	require.NoError(t, authz.CreateRole("RoleManager", types.RolePostData{Name: "Shelver", Permissions: []string{types.BookManagePermission}}))
+	assert.ErrorIs(t, authz.CreateRole("RoleManager", types.RolePostData{Name: "Boss", Permissions: []string{types.AccountPromotePermission}}), errDefs.ErrForbidden)
+	assert.False(t, authz.RoleExists("Boss"))
+}
+
+func TestAuthorizerCovers(t *testing.T) {
+	grants := map[types.Role][]string{
+		types.UserRole:       {},
+		types.BookKeeperRole: {types.BookManagePermission, types.SubjectManagePermission},
+		"Librarian":          {types.BookManagePermission, types.SubjectManagePermission, types.AccountPromotePermission},
+	}
+	authz := service.NewAuthorizer(newRoleRepository(grants))
+	require.NoError(t, authz.Load())
+
+	assert.True(t, authz.Covers("Librarian", types.BookKeeperRole))
+	assert.True(t, authz.Covers("Librarian", types.UserRole))
+	assert.False(t, authz.Covers(types.BookKeeperRole, "Librarian"))
+	assert.False(t, authz.Covers("Librarian", types.AdminRole), "nothing but Admin covers Admin")
+	assert.True(t, authz.Covers(types.AdminRole, types.AdminRole))
+}
+
+func TestAuthorizerRequire(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	grants := map[types.Role][]string{types.BookKeeperRole: {types.BookManagePermission}}
+	authz := service.NewAuthorizer(newRoleRepository(grants))
+	require.NoError(t, authz.Load())
+	authz.Authenticate = func(c *gin.Context) (jwt.Claims, error) {
+		switch c.GetHeader("User-Token") {
+		case "keeper":
+			return jwt.Claims{Username: "keeper", Role: types.BookKeeperRole}, nil
+		case "user":
+			return jwt.Claims{Username: "user", Role: types.UserRole}, nil
+		default:
+			return jwt.Claims{}, errDefs.ErrUnauthorized
+		}
+	}
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus int
+	}{
+		{name: "granted", token: "keeper", expectedStatus: http.StatusOK},
+		{name: "missing permission", token: "user", expectedStatus: http.StatusForbidden},
+		{name: "not authenticated", token: "", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.POST("/books", authz.Require(types.BookManagePermission), func(c *gin.Context) {
+				claims, _ := c.Get(service.ClaimsContextKey)
+				assert.Equal(t, "keeper", claims.(jwt.Claims).Username)
+				c.JSON(http.StatusOK, nil)
+			})
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/books", nil)
+			req.Header.Set("User-Token", tt.token)
+			engine.ServeHTTP(w, req)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusForbidden {
+				assert.JSONEq(t, `{"Error":"forbidden: permission book.manage required"}`, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/dedup"
+)
+
+// DuplicateService looks for books that were entered more than once.
+type DuplicateService struct {
+	Repo repository.BookRepository
+}
+
+func NewDuplicateService(repo repository.BookRepository) *DuplicateService {
+	return &DuplicateService{Repo: repo}
+}
+
+func dedupEntry(book types.Book) dedup.Entry {
+	return dedup.Entry{Key: book.Code, Title: book.Title, Author: book.Author, ISBN: book.Isbn}
+}
+
+// Candidates returns the existing books that book is likely a duplicate of.
+func (ds *DuplicateService) Candidates(book types.Book) ([]types.DuplicateCandidate, error) {
+	books, err := ds.Repo.FindAllBooks()
+	if err != nil {
+		return nil, err
+	}
+	return CandidatesIn(book, books), nil
+}
+
+// CandidatesIn returns the books in pool that book is likely a duplicate of.
+func CandidatesIn(book types.Book, pool []types.Book) []types.DuplicateCandidate {
+	entries := make([]dedup.Entry, 0, len(pool))
+	byCode := make(map[string]types.Book, len(pool))
+	for _, other := range pool {
+		entries = append(entries, dedupEntry(other))
+		byCode[other.Code] = other
+	}
+
+	matches := dedup.Candidates(dedupEntry(book), entries)
+	candidates := make([]types.DuplicateCandidate, 0, len(matches))
+	for _, match := range matches {
+		candidates = append(candidates, types.DuplicateCandidate{
+			Book:   byCode[match.Key],
+			Score:  match.Score,
+			Reason: match.Reason,
+		})
+	}
+	return candidates
+}
+
+func (ds *DuplicateService) Clusters() ([]types.DuplicateCluster, error) {
+	books, err := ds.Repo.FindAllBooks()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]dedup.Entry, 0, len(books))
+	byCode := make(map[string]types.Book, len(books))
+	for _, book := range books {
+		entries = append(entries, dedupEntry(book))
+		byCode[book.Code] = book
+	}
+
+	clusters := make([]types.DuplicateCluster, 0)
+	for _, codes := range dedup.Clusters(entries) {
+		cluster := types.DuplicateCluster{Books: make([]types.Book, 0, len(codes))}
+		for _, code := range codes {
+			cluster.Books = append(cluster.Books, byCode[code])
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters, nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"time"
+
+	"tick_test/repository"
+	"tick_test/types"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultLoginHistoryRetention = 90 * 24 * time.Hour
+	DefaultLoginHistoryLimit     = 50
+	MaxLoginHistoryLimit         = 500
+
+	maxUserAgentLength = 500
+	maxReasonLength    = 200
+)
+
+// LoginHistory records the login attempts on each account for Retention.
+type LoginHistory struct {
+	Repo      repository.LoginHistoryRepository
+	Retention time.Duration
+	Now       func() time.Time
+}
+
+func NewLoginHistory(repo repository.LoginHistoryRepository) *LoginHistory {
+	return &LoginHistory{
+		Repo:      repo,
+		Retention: DefaultLoginHistoryRetention,
+		Now:       time.Now,
+	}
+}
+
+// Record stores a login attempt of username from device; loginErr is nil
+// for a successful one. Failing to record is logged rather than failing the
+// login.
+func (lh *LoginHistory) Record(username string, device types.DeviceInfo, loginErr error) {
+	event := types.LoginEvent{
+		Succeeded: loginErr == nil,
+		Device:    types.DeviceInfo{UserAgent: truncate(device.UserAgent, maxUserAgentLength), IP: device.IP},
+		CreatedAt: lh.Now().UTC().Format(time.RFC3339),
+	}
+	if loginErr != nil {
+		event.Reason = truncate(loginErr.Error(), maxReasonLength)
+	}
+	if err := lh.Repo.SaveLoginEvent(username, event); err != nil {
+		logrus.Error("recording login of ", username, ": ", err)
+	}
+}
+
+// List returns the latest limit login attempts of username, newest first.
+func (lh *LoginHistory) List(username string, limit int) ([]types.LoginEvent, error) {
+	return lh.Repo.FindLoginEvents(username, limit)
+}
+
+func (lh *LoginHistory) Cleanup(now time.Time) error {
+	return lh.Repo.DeleteLoginEventsBefore(now.Add(-lh.Retention))
+}
+
+// Start deletes expired login attempts on every interval.
+func (lh *LoginHistory) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if err := lh.Cleanup(now); err != nil {
+				logrus.Error("cleaning up login history: ", err)
+			}
+		}
+	}()
+}
+
+func truncate(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+	// cut at a rune boundary
+	for maxLength > 0 && s[maxLength]&0xC0 == 0x80 {
+		maxLength--
+	}
+	return s[:maxLength]
+}
@@ -0,0 +1,52 @@
+package service_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+func TestLoginHistory(t *testing.T) {
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	var saved []types.LoginEvent
+	var deletedBefore time.Time
+	repo := &mocks.LoginHistoryRepositoryMock{
+		SaveLoginEventFn: func(username string, event types.LoginEvent) error {
+			saved = append(saved, event)
+			return nil
+		},
+		DeleteLoginEventsBeforeFn: func(before time.Time) error {
+			deletedBefore = before
+			return nil
+		},
+	}
+	lh := service.NewLoginHistory(repo)
+	lh.Now = func() time.Time { return now }
+
+	device := types.DeviceInfo{UserAgent: strings.Repeat("ü", 300), IP: "10.0.0.1"}
+	lh.Record("john", device, nil)
+	lh.Record("john", device, fmt.Errorf("%w: invalid credentials", errDefs.ErrUnauthorized))
+	require.Len(t, saved, 2)
+
+	assert.True(t, saved[0].Succeeded)
+	assert.Empty(t, saved[0].Reason)
+	assert.Equal(t, "2025-03-07T12:00:00Z", saved[0].CreatedAt)
+	assert.Equal(t, "10.0.0.1", saved[0].Device.IP)
+	assert.Len(t, saved[0].Device.UserAgent, 500, "user agents are cut to fit the column")
+	assert.Equal(t, strings.Repeat("ü", 250), saved[0].Device.UserAgent, "without splitting a character")
+
+	assert.False(t, saved[1].Succeeded)
+	assert.Equal(t, "unauthorized: invalid credentials", saved[1].Reason)
+
+	require.NoError(t, lh.Cleanup(now))
+	assert.Equal(t, now.Add(-service.DefaultLoginHistoryRetention), deletedBefore)
+}
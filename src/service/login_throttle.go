@@ -0,0 +1,161 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"tick_test/utils/errDefs"
+
+	"github.com/sirupsen/logrus"
+)
+
+type failedLogins struct {
+	Count       int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// LoginThrottle slows down password guessing. Failed logins are counted per
+// username and per client IP; every failure doubles the wait before the next
+// attempt, and reaching the limit locks the key out for LockoutDuration.
+type LoginThrottle struct {
+	MaxUserFailures int
+	MaxIPFailures   int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutDuration time.Duration
+	Now             func() time.Time
+
+	mutex    sync.Mutex
+	failures map[string]*failedLogins
+}
+
+func NewLoginThrottle() *LoginThrottle {
+	return &LoginThrottle{
+		MaxUserFailures: 5,
+		MaxIPFailures:   20,
+		BaseDelay:       time.Second,
+		MaxDelay:        5 * time.Minute,
+		LockoutDuration: 15 * time.Minute,
+		Now:             time.Now,
+		failures:        make(map[string]*failedLogins),
+	}
+}
+
+func userKey(username string) string { return "user:" + username }
+func ipKey(ip string) string         { return "ip:" + ip }
+
+// Allow returns an errDefs.RetryAfterError while username or ip has to wait.
+func (lt *LoginThrottle) Allow(username string, ip string) error {
+	lt.mutex.Lock()
+	defer lt.mutex.Unlock()
+	now := lt.Now()
+	wait := max(lt.waitFor(userKey(username), now), lt.waitFor(ipKey(ip), now))
+	if wait > 0 {
+		return &errDefs.RetryAfterError{
+			Message:    "too many failed login attempts",
+			RetryAfter: wait,
+		}
+	}
+	return nil
+}
+
+func (lt *LoginThrottle) waitFor(key string, now time.Time) time.Duration {
+	entry, ok := lt.failures[key]
+	if !ok {
+		return 0
+	}
+	if now.Before(entry.LockedUntil) {
+		return entry.LockedUntil.Sub(now)
+	}
+	if next := entry.LastFailure.Add(lt.backoff(entry.Count)); now.Before(next) {
+		return next.Sub(now)
+	}
+	return 0
+}
+
+func (lt *LoginThrottle) backoff(count int) time.Duration {
+	if count <= 0 {
+		return 0
+	}
+	delay := float64(lt.BaseDelay) * math.Pow(2, float64(count-1))
+	if delay > float64(lt.MaxDelay) {
+		return lt.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+// Fail records a failed login for username and ip.
+func (lt *LoginThrottle) Fail(username string, ip string) {
+	lt.mutex.Lock()
+	defer lt.mutex.Unlock()
+	now := lt.Now()
+	lt.fail(userKey(username), lt.MaxUserFailures, now)
+	lt.fail(ipKey(ip), lt.MaxIPFailures, now)
+}
+
+func (lt *LoginThrottle) fail(key string, limit int, now time.Time) {
+	entry, ok := lt.failures[key]
+	if !ok || (!entry.LockedUntil.IsZero() && !now.Before(entry.LockedUntil)) {
+		entry = &failedLogins{}
+		lt.failures[key] = entry
+	}
+	entry.Count++
+	entry.LastFailure = now
+	if limit > 0 && entry.Count >= limit {
+		entry.LockedUntil = now.Add(lt.LockoutDuration)
+		logrus.Warn("login locked out for ", key, " until ", entry.LockedUntil.UTC().Format(time.RFC3339))
+	}
+}
+
+// Succeed forgets the failures of username. The failures of the client IP
+// are kept, so one valid account cannot be used to keep guessing others.
+func (lt *LoginThrottle) Succeed(username string) {
+	lt.mutex.Lock()
+	delete(lt.failures, userKey(username))
+	lt.mutex.Unlock()
+}
+
+// Unlock lifts the lockout of a username, of a client IP, or of both.
+func (lt *LoginThrottle) Unlock(username string, ip string) error {
+	lt.mutex.Lock()
+	defer lt.mutex.Unlock()
+	removed := false
+	if _, ok := lt.failures[userKey(username)]; ok && username != "" {
+		delete(lt.failures, userKey(username))
+		removed = true
+	}
+	if _, ok := lt.failures[ipKey(ip)]; ok && ip != "" {
+		delete(lt.failures, ipKey(ip))
+		removed = true
+	}
+	if !removed {
+		return fmt.Errorf("%w: no failed logins recorded", errDefs.ErrEntityNotFound)
+	}
+	return nil
+}
+
+// Cleanup forgets failures that no longer delay anyone.
+func (lt *LoginThrottle) Cleanup() {
+	lt.mutex.Lock()
+	defer lt.mutex.Unlock()
+	now := lt.Now()
+	for key := range lt.failures {
+		if lt.waitFor(key, now) == 0 && now.Sub(lt.failures[key].LastFailure) > lt.LockoutDuration {
+			delete(lt.failures, key)
+		}
+	}
+}
+
+// Start runs Cleanup every interval in the background.
+func (lt *LoginThrottle) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			lt.Cleanup()
+		}
+	}()
+}
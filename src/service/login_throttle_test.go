@@ -0,0 +1,68 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tick_test/service"
+	"tick_test/utils/errDefs"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	lt := service.NewLoginThrottle()
+	lt.MaxUserFailures = 3
+	lt.MaxIPFailures = 5
+	lt.Now = func() time.Time { return now }
+
+	retryAfter := func(username, ip string) time.Duration {
+		err := lt.Allow(username, ip)
+		if err == nil {
+			return 0
+		}
+		var retryErr *errDefs.RetryAfterError
+		require.True(t, errors.As(err, &retryErr))
+		require.ErrorIs(t, err, errDefs.ErrTooManyRequests)
+		return retryErr.RetryAfter
+	}
+
+	assert.Zero(t, retryAfter("john", "10.0.0.1"))
+
+	lt.Fail("john", "10.0.0.1")
+	assert.Equal(t, time.Second, retryAfter("john", "10.0.0.1"))
+	assert.Equal(t, time.Second, retryAfter("john", "10.0.0.2"), "username is delayed from any client")
+	assert.Equal(t, time.Second, retryAfter("jane", "10.0.0.1"), "client is delayed for any username")
+
+	now = now.Add(time.Second)
+	lt.Fail("john", "10.0.0.1")
+	assert.Equal(t, 2*time.Second, retryAfter("john", "10.0.0.3"), "backoff doubles")
+
+	now = now.Add(2 * time.Second)
+	lt.Fail("john", "10.0.0.1")
+	assert.Equal(t, 15*time.Minute, retryAfter("john", "10.0.0.3"), "locked out after the limit")
+
+	require.NoError(t, lt.Unlock("john", ""))
+	assert.Zero(t, retryAfter("john", "10.0.0.3"))
+	assert.Equal(t, 4*time.Second, retryAfter("jane", "10.0.0.1"), "unlocking a username keeps the client delayed")
+
+	assert.ErrorIs(t, lt.Unlock("nobody", ""), errDefs.ErrEntityNotFound)
+}
+
+func TestLoginThrottleSucceed(t *testing.T) {
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	lt := service.NewLoginThrottle()
+	lt.Now = func() time.Time { return now }
+
+	lt.Fail("john", "10.0.0.1")
+	lt.Succeed("john")
+	assert.NoError(t, lt.Allow("john", "10.0.0.2"))
+	assert.Error(t, lt.Allow("john", "10.0.0.1"))
+
+	now = now.Add(time.Hour)
+	lt.Cleanup()
+	assert.NoError(t, lt.Allow("john", "10.0.0.1"))
+}
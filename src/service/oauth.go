@@ -0,0 +1,106 @@
+package service
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/jwt"
+	"tick_test/utils/random"
+)
+
+// OAuthService registers confidential OAuth2 clients for accounts and
+// issues their client credentials tokens.
+type OAuthService struct {
+	Repo           repository.OAuthClientRepository
+	AccessLifetime time.Duration
+	Now            func() time.Time
+}
+
+func NewOAuthService(repo repository.OAuthClientRepository) *OAuthService {
+	return &OAuthService{Repo: repo, AccessLifetime: DefaultAccessTokenLifetime, Now: time.Now}
+}
+
+// Register creates a client acting for username. Only a hash of its secret
+// is stored, so the secret is returned this one time.
+func (oas *OAuthService) Register(username string, data types.OAuthClientPostData) (types.OAuthClientCreated, error) {
+	for _, scope := range data.Scopes {
+		if !slices.Contains(types.ApiKeyScopes, scope) {
+			return types.OAuthClientCreated{}, fmt.Errorf("%w: unknown scope %q", errDefs.ErrBadRequest, scope)
+		}
+	}
+	clientId, err := random.Token(16)
+	if err != nil {
+		return types.OAuthClientCreated{}, err
+	}
+	secret, err := random.Token(32)
+	if err != nil {
+		return types.OAuthClientCreated{}, err
+	}
+	client := types.OAuthClient{
+		ClientId:  clientId,
+		Name:      data.Name,
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(data.Scopes))),
+		CreatedAt: oas.Now().UTC().Format(time.RFC3339),
+	}
+	if err := oas.Repo.CreateOAuthClient(username, client, HashToken(secret)); err != nil {
+		return types.OAuthClientCreated{}, err
+	}
+	return types.OAuthClientCreated{OAuthClient: client, ClientSecret: secret}, nil
+}
+
+func (oas *OAuthService) List(username string) ([]types.OAuthClient, error) {
+	return oas.Repo.FindOAuthClients(username)
+}
+
+func (oas *OAuthService) Delete(username string, clientId string) error {
+	return oas.Repo.DeleteOAuthClient(username, clientId)
+}
+
+// Authenticate returns the client with clientId if secret is its secret.
+func (oas *OAuthService) Authenticate(clientId string, secret string) (repository.OAuthClientCredentials, error) {
+	invalid := &types.OAuthError{Code: types.OAuthInvalidClient, Description: "client authentication failed"}
+	credentials, err := oas.Repo.FindOAuthClientCredentials(clientId)
+	if errors.Is(err, errDefs.ErrEntityNotFound) {
+		return repository.OAuthClientCredentials{}, invalid
+	}
+	if err != nil {
+		return repository.OAuthClientCredentials{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(HashToken(secret)), []byte(credentials.SecretHash)) != 1 {
+		return repository.OAuthClientCredentials{}, invalid
+	}
+	return credentials, nil
+}
+
+// ClientCredentials issues an access token for the owner of the client,
+// limited to the space separated scopes requested, or to all scopes of the
+// client if scope is empty. No refresh token is issued; the client simply
+// asks for a new token.
+func (oas *OAuthService) ClientCredentials(credentials repository.OAuthClientCredentials, scope string) (types.OAuthToken, error) {
+	scopes := credentials.Client.Scopes
+	if requested := strings.Fields(scope); len(requested) > 0 {
+		for _, s := range requested {
+			if !slices.Contains(credentials.Client.Scopes, s) {
+				return types.OAuthToken{}, &types.OAuthError{Code: types.OAuthInvalidScope, Description: fmt.Sprintf("client may not request scope %q", s)}
+			}
+		}
+		scopes = slices.Compact(slices.Sorted(slices.Values(requested)))
+	}
+	accessToken, err := jwt.GenerateScopedToken(credentials.Owner, credentials.Role, oas.AccessLifetime, scopes)
+	if err != nil {
+		return types.OAuthToken{}, err
+	}
+	return types.OAuthToken{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(oas.AccessLifetime.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
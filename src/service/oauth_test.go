@@ -0,0 +1,76 @@
+package service_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/repository"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/jwt"
+)
+
+func TestOAuthClientLifecycle(t *testing.T) {
+	jwt.SetSecretKey([]byte("oauth-test-secret"))
+	stored := make(map[string]repository.OAuthClientCredentials)
+	repo := &mocks.OAuthClientRepositoryMock{
+		CreateOAuthClientFn: func(username string, client types.OAuthClient, secretHash string) error {
+			stored[client.ClientId] = repository.OAuthClientCredentials{Client: client, Owner: username, Role: types.BookKeeperRole, SecretHash: secretHash}
+			return nil
+		},
+		FindOAuthClientCredentialsFn: func(clientId string) (repository.OAuthClientCredentials, error) {
+			credentials, ok := stored[clientId]
+			if !ok {
+				return credentials, fmt.Errorf("%w: no OAuth client %s", errDefs.ErrEntityNotFound, clientId)
+			}
+			return credentials, nil
+		},
+	}
+	oas := service.NewOAuthService(repo)
+
+	created, err := oas.Register("robot", types.OAuthClientPostData{Name: "Importer", Scopes: []string{types.BooksWriteScope, types.BooksReadScope}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{types.BooksReadScope, types.BooksWriteScope}, created.Scopes)
+	assert.NotEqual(t, created.ClientSecret, stored[created.ClientId].SecretHash, "only the hash is stored")
+
+	var oauthErr *types.OAuthError
+	_, err = oas.Authenticate(created.ClientId, "wrong")
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, types.OAuthInvalidClient, oauthErr.Code)
+	_, err = oas.Authenticate("unknown", created.ClientSecret)
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, types.OAuthInvalidClient, oauthErr.Code)
+
+	credentials, err := oas.Authenticate(created.ClientId, created.ClientSecret)
+	require.NoError(t, err)
+	assert.Equal(t, "robot", credentials.Owner)
+
+	token, err := oas.ClientCredentials(credentials, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", token.TokenType)
+	assert.Equal(t, "books:read books:write", token.Scope)
+	assert.Empty(t, token.RefreshToken)
+
+	token, err = oas.ClientCredentials(credentials, "books:read")
+	require.NoError(t, err)
+	claims, err := jwt.ValidateToken(token.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "robot", claims.Username)
+	assert.Equal(t, types.BookKeeperRole, claims.Role)
+	assert.Equal(t, []string{types.BooksReadScope}, claims.Scopes)
+
+	_, err = oas.ClientCredentials(credentials, "books:read admin")
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, types.OAuthInvalidScope, oauthErr.Code)
+}
+
+func TestOAuthRegisterUnknownScope(t *testing.T) {
+	oas := service.NewOAuthService(&mocks.OAuthClientRepositoryMock{})
+	_, err := oas.Register("robot", types.OAuthClientPostData{Name: "Importer", Scopes: []string{"everything"}})
+	assert.ErrorIs(t, err, errDefs.ErrBadRequest)
+}
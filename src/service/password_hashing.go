@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+
+	"tick_test/internal/config"
+	"tick_test/utils/passhash"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// NewPasswordHasher builds the hasher selected by the configuration and
+// checks that its parameters are sound.
+func NewPasswordHasher(cfg config.PasswordHashingConfig) (passhash.Hasher, error) {
+	switch cfg.Algorithm {
+	case "", "argon2id":
+		hasher := passhash.Argon2id{
+			Memory:      cfg.Memory,
+			Iterations:  cfg.Iterations,
+			Parallelism: cfg.Parallelism,
+			SaltLength:  cfg.SaltLength,
+			KeyLength:   cfg.KeyLength,
+		}
+		switch {
+		case hasher.Iterations < 1:
+			return nil, fmt.Errorf("passwordHashing.iterations: needs to be at least 1")
+		case hasher.Parallelism < 1:
+			return nil, fmt.Errorf("passwordHashing.parallelism: needs to be at least 1")
+		case hasher.Memory < 8*uint32(hasher.Parallelism):
+			return nil, fmt.Errorf("passwordHashing.memory: needs to be at least 8 KiB per lane")
+		case hasher.SaltLength < 8:
+			return nil, fmt.Errorf("passwordHashing.saltLength: needs to be at least 8 bytes")
+		case hasher.KeyLength < 16:
+			return nil, fmt.Errorf("passwordHashing.keyLength: needs to be at least 16 bytes")
+		}
+		return hasher, nil
+	case "bcrypt":
+		if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("passwordHashing.bcryptCost: needs to be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		return passhash.Bcrypt{Cost: cfg.BcryptCost}, nil
+	default:
+		return nil, fmt.Errorf("passwordHashing.algorithm: unknown algorithm %q", cfg.Algorithm)
+	}
+}
@@ -0,0 +1,31 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tick_test/internal/config"
+	"tick_test/service"
+	"tick_test/utils/passhash"
+)
+
+func TestNewPasswordHasher(t *testing.T) {
+	hasher, err := service.NewPasswordHasher(config.PasswordHashingConfig{
+		Algorithm: "argon2id", Memory: 19456, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, passhash.DefaultArgon2id, hasher)
+
+	hasher, err = service.NewPasswordHasher(config.PasswordHashingConfig{Algorithm: "bcrypt", BcryptCost: 12})
+	require.NoError(t, err)
+	assert.Equal(t, passhash.Bcrypt{Cost: 12}, hasher)
+
+	_, err = service.NewPasswordHasher(config.PasswordHashingConfig{Algorithm: "argon2id", Memory: 4, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	assert.ErrorContains(t, err, "passwordHashing.memory")
+	_, err = service.NewPasswordHasher(config.PasswordHashingConfig{Algorithm: "bcrypt", BcryptCost: 40})
+	assert.ErrorContains(t, err, "passwordHashing.bcryptCost")
+	_, err = service.NewPasswordHasher(config.PasswordHashingConfig{Algorithm: "md5"})
+	assert.ErrorContains(t, err, "passwordHashing.algorithm")
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"tick_test/internal/config"
+	"tick_test/repository"
+)
+
+type runeChecker struct {
+	Score   int
+	Checker func(rune) bool
+}
+
+var runeCheckers = []runeChecker{
+	{Score: 3, Checker: func(glyph rune) bool { return glyph > unicode.MaxASCII }},
+	{Score: 1, Checker: unicode.IsUpper},
+	{Score: 1, Checker: unicode.IsLower},
+	{Score: 1, Checker: unicode.IsSymbol},
+}
+
+// RatePassword scores a password by its length and the kinds of characters
+// it uses.
+func RatePassword(password string) int {
+	score := 0
+	passwordLen := len(password)
+	for _, pLen := range []int{5, 8, 14, 20} {
+		if passwordLen > pLen {
+			score += 1
+		} else {
+			break
+		}
+	}
+	for _, glyphChecker := range runeCheckers {
+		if strings.IndexFunc(password, glyphChecker.Checker) != -1 {
+			score += glyphChecker.Score
+		}
+	}
+	return score
+}
+
+var characterClassNames = map[string]string{
+	"lower":  "lowercase letter",
+	"upper":  "uppercase letter",
+	"digit":  "digit",
+	"symbol": "symbol",
+}
+
+var characterClasses = map[string]func(rune) bool{
+	"lower":  unicode.IsLower,
+	"upper":  unicode.IsUpper,
+	"digit":  unicode.IsDigit,
+	"symbol": func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) },
+}
+
+// PasswordPolicy implements repository.PasswordPolicy.
+type PasswordPolicy struct {
+	MinLength        int
+	RequiredClasses  []string
+	MinScore         int
+	Blacklist        map[string]bool
+	DisallowUsername bool
+	History          int
+}
+
+var _ repository.PasswordPolicy = (*PasswordPolicy)(nil)
+
+func NewPasswordPolicy(cfg config.PasswordPolicyConfig) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{
+		MinLength:        cfg.MinLength,
+		RequiredClasses:  cfg.RequiredClasses,
+		MinScore:         cfg.MinScore,
+		Blacklist:        make(map[string]bool),
+		DisallowUsername: cfg.DisallowUsername,
+		History:          cfg.History,
+	}
+	for _, class := range cfg.RequiredClasses {
+		if _, ok := characterClasses[class]; !ok {
+			return nil, fmt.Errorf("passwordPolicy.requiredClasses: unknown class %q", class)
+		}
+	}
+	for _, password := range cfg.Blacklist {
+		policy.Blacklist[strings.ToLower(password)] = true
+	}
+	if cfg.BlacklistFile != "" {
+		file, err := os.Open(cfg.BlacklistFile)
+		if err != nil {
+			return nil, fmt.Errorf("passwordPolicy.blacklistFile: %w", err)
+		}
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if password := strings.TrimSpace(scanner.Text()); password != "" {
+				policy.Blacklist[strings.ToLower(password)] = true
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("passwordPolicy.blacklistFile: %w", err)
+		}
+	}
+	return policy, nil
+}
+
+func (pp *PasswordPolicy) HistorySize() int {
+	return pp.History
+}
+
+func (pp *PasswordPolicy) Violations(username string, password string, previousHashes []string) []string {
+	violations := make([]string, 0)
+	if length := utf8.RuneCountInString(password); length < pp.MinLength {
+		violations = append(violations, fmt.Sprintf("password must be at least %d characters long", pp.MinLength))
+	}
+	for _, class := range pp.RequiredClasses {
+		if strings.IndexFunc(password, characterClasses[class]) == -1 {
+			violations = append(violations, fmt.Sprintf("password must contain at least one %s", characterClassNames[class]))
+		}
+	}
+	if score := RatePassword(password); score < pp.MinScore {
+		violations = append(violations, fmt.Sprintf("password scores %d but at least %d is required", score, pp.MinScore))
+	}
+	if pp.Blacklist[strings.ToLower(password)] {
+		violations = append(violations, "password is too common")
+	}
+	if pp.DisallowUsername && username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		violations = append(violations, "password must not contain the username")
+	}
+	for _, hash := range previousHashes {
+		if repository.PasswordMatches(password, hash) {
+			violations = append(violations, fmt.Sprintf("password must differ from the last %d passwords", pp.History))
+			break
+		}
+	}
+	return violations
+}
@@ -0,0 +1,87 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"tick_test/internal/config"
+	"tick_test/service"
+)
+
+func TestRatePassword(t *testing.T) {
+	assert.Equal(t, 1, service.RatePassword("abc"))
+	assert.Equal(t, 2, service.RatePassword("abcdef"))
+	assert.Equal(t, 4, service.RatePassword("Abcdefghi"))
+	assert.Equal(t, 8, service.RatePassword("Abcdefghijklmnoż"))
+}
+
+func TestPasswordPolicyViolations(t *testing.T) {
+	policy, err := service.NewPasswordPolicy(config.PasswordPolicyConfig{
+		MinLength:        10,
+		RequiredClasses:  []string{"upper", "digit"},
+		MinScore:         3,
+		Blacklist:        []string{"Password123!"},
+		DisallowUsername: true,
+		History:          3,
+	})
+	require.NoError(t, err)
+	oldHash, _ := bcrypt.GenerateFromPassword([]byte("Correct9Horse"), bcrypt.MinCost)
+
+	tests := []struct {
+		name       string
+		username   string
+		password   string
+		violations []string
+	}{
+		{
+			name:       "valid password",
+			username:   "john",
+			password:   "Battery9Staple",
+			violations: []string{},
+		},
+		{
+			name:     "too short and missing classes",
+			username: "john",
+			password: "abc",
+			violations: []string{
+				"password must be at least 10 characters long",
+				"password must contain at least one uppercase letter",
+				"password must contain at least one digit",
+				"password scores 1 but at least 3 is required",
+			},
+		},
+		{
+			name:       "blacklisted regardless of case",
+			username:   "john",
+			password:   "PASSWORD123!",
+			violations: []string{"password is too common"},
+		},
+		{
+			name:       "contains username",
+			username:   "john",
+			password:   "Johnny12345",
+			violations: []string{"password must not contain the username"},
+		},
+		{
+			name:       "reused password",
+			username:   "john",
+			password:   "Correct9Horse",
+			violations: []string{"password must differ from the last 3 passwords"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := policy.Violations(tt.username, tt.password, []string{string(oldHash)})
+			assert.Equal(t, tt.violations, violations)
+		})
+	}
+}
+
+func TestNewPasswordPolicyUnknownClass(t *testing.T) {
+	_, err := service.NewPasswordPolicy(config.PasswordPolicyConfig{RequiredClasses: []string{"emoji"}})
+	assert.Error(t, err)
+}
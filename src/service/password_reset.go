@@ -0,0 +1,144 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/random"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ResetNotifier delivers a password reset token to the account holder.
+type ResetNotifier interface {
+	NotifyPasswordReset(reset types.PasswordReset) error
+}
+
+// InboxNotifier delivers reset tokens as internal messages. They are sent by
+// the account to itself, so that no one else, not even the Admin who issued
+// the reset, can read the token among their sent messages.
+type InboxNotifier struct {
+	Repo repository.MessageRepository
+}
+
+func (in *InboxNotifier) NotifyPasswordReset(reset types.PasswordReset) error {
+	requestedBy := "A password reset was requested for your account."
+	if reset.IssuedBy != "" {
+		requestedBy = fmt.Sprintf("%s issued a password reset for your account.", reset.IssuedBy)
+	}
+	return in.Repo.SaveMessage(&types.Message{
+		From: reset.Username,
+		To:   reset.Username,
+		When: types.ISO8601Date(time.Now().UTC().Format(time.RFC3339)),
+		Content: fmt.Sprintf(
+			"%s Use the token %s to choose a new password before %s. If you did not ask for it, you can ignore this message.",
+			requestedBy, reset.Token, reset.ExpiresAt.UTC().Format(time.RFC3339),
+		),
+	})
+}
+
+// PasswordResetService issues single-use, time-limited reset tokens. Only
+// their hash is stored; the plain token goes to the Notifier.
+type PasswordResetService struct {
+	Repo     repository.PasswordResetRepository
+	Notifier ResetNotifier
+	Lifetime time.Duration
+	// RequestCooldown is how long a user has to wait before asking for
+	// another token, so that nobody can flood an account with them.
+	RequestCooldown time.Duration
+	Now             func() time.Time
+
+	mutex       sync.Mutex
+	lastRequest map[string]time.Time
+}
+
+func NewPasswordResetService(repo repository.PasswordResetRepository, notifier ResetNotifier) *PasswordResetService {
+	return &PasswordResetService{
+		Repo:            repo,
+		Notifier:        notifier,
+		Lifetime:        time.Hour,
+		RequestCooldown: time.Minute,
+		Now:             time.Now,
+		lastRequest:     make(map[string]time.Time),
+	}
+}
+
+// Request sends a reset token on behalf of the account holder. Unknown
+// usernames and requests during the cooldown are ignored without an error,
+// so the answer does not tell which accounts exist.
+func (prs *PasswordResetService) Request(username string) error {
+	if !prs.claimRequest(username) {
+		return nil
+	}
+	err := prs.issue(username, "")
+	if errors.Is(err, errDefs.ErrEntityNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Issue sends a reset token for username on behalf of an Admin.
+func (prs *PasswordResetService) Issue(username string, issuedBy string) error {
+	return prs.issue(username, issuedBy)
+}
+
+// Force stops the current password of username from working, ends all of
+// its sessions and sends a reset token on behalf of an Admin.
+func (prs *PasswordResetService) Force(username string, issuedBy string) error {
+	if err := prs.Repo.ClearPassword(username); err != nil {
+		return err
+	}
+	return prs.issue(username, issuedBy)
+}
+
+func (prs *PasswordResetService) issue(username string, issuedBy string) error {
+	token, err := random.Token(32)
+	if err != nil {
+		return err
+	}
+	expiresAt := prs.Now().Add(prs.Lifetime)
+	if err := prs.Repo.SavePasswordReset(username, HashToken(token), expiresAt); err != nil {
+		return err
+	}
+	return prs.Notifier.NotifyPasswordReset(types.PasswordReset{
+		Username:  username,
+		Token:     token,
+		ExpiresAt: expiresAt,
+		IssuedBy:  issuedBy,
+	})
+}
+
+// Confirm sets a new password with a reset token and returns the account it
+// belonged to.
+func (prs *PasswordResetService) Confirm(data types.PasswordResetConfirmData) (string, error) {
+	if data.Password != data.SamePassword {
+		return "", fmt.Errorf("%w: field `Password` differs from field `SamePassword`", errDefs.ErrBadRequest)
+	}
+	username, err := prs.Repo.ResetPassword(HashToken(data.Token), data.Password, prs.Now())
+	if err != nil {
+		return "", err
+	}
+	logrus.Info("password reset for ", username)
+	return username, nil
+}
+
+func (prs *PasswordResetService) claimRequest(username string) bool {
+	prs.mutex.Lock()
+	defer prs.mutex.Unlock()
+	now := prs.Now()
+	for key, at := range prs.lastRequest {
+		if now.Sub(at) >= prs.RequestCooldown {
+			delete(prs.lastRequest, key)
+		}
+	}
+	if _, waiting := prs.lastRequest[username]; waiting {
+		return false
+	}
+	prs.lastRequest[username] = now
+	return true
+}
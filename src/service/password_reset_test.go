@@ -0,0 +1,128 @@
+package service_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+type recordingNotifier struct {
+	resets []types.PasswordReset
+}
+
+func (rn *recordingNotifier) NotifyPasswordReset(reset types.PasswordReset) error {
+	rn.resets = append(rn.resets, reset)
+	return nil
+}
+
+type inbox struct {
+	messages []types.Message
+}
+
+func (i *inbox) SaveMessage(msg *types.Message) error {
+	i.messages = append(i.messages, *msg)
+	return nil
+}
+
+func (i *inbox) FindMessages(username string, sent bool, recv bool) ([]types.Message, error) {
+	msgs := make([]types.Message, 0)
+	for _, msg := range i.messages {
+		if (sent && msg.From == username) || (recv && msg.To == username) {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
+
+func TestInboxNotifierHidesTokenFromIssuer(t *testing.T) {
+	messages := &inbox{}
+	notifier := &service.InboxNotifier{Repo: messages}
+	require.NoError(t, notifier.NotifyPasswordReset(types.PasswordReset{Username: "john", Token: "secret-token", ExpiresAt: time.Now(), IssuedBy: "admin"}))
+
+	received, _ := messages.FindMessages("john", false, true)
+	require.Len(t, received, 1)
+	assert.Contains(t, received[0].Content, "secret-token")
+	assert.Contains(t, received[0].Content, "admin issued")
+
+	sent, _ := messages.FindMessages("admin", true, true)
+	assert.Empty(t, sent, "the issuer cannot read the token")
+}
+
+func TestPasswordResetIssueAndConfirm(t *testing.T) {
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	stored := make(map[string]string)
+	repo := &mocks.PasswordResetRepositoryMock{
+		SavePasswordResetFn: func(username string, tokenHash string, expiresAt time.Time) error {
+			assert.Equal(t, now.Add(time.Hour), expiresAt)
+			stored[tokenHash] = username
+			return nil
+		},
+		ResetPasswordFn: func(tokenHash string, password string, at time.Time) (string, error) {
+			username, ok := stored[tokenHash]
+			if !ok {
+				return "", fmt.Errorf("%w: reset token invalid, expired or already used", errDefs.ErrUnauthorized)
+			}
+			delete(stored, tokenHash)
+			return username, nil
+		},
+	}
+	notifier := &recordingNotifier{}
+	prs := service.NewPasswordResetService(repo, notifier)
+	prs.Now = func() time.Time { return now }
+
+	require.NoError(t, prs.Issue("john", "admin"))
+	require.Len(t, notifier.resets, 1)
+	reset := notifier.resets[0]
+	assert.Equal(t, "john", reset.Username)
+	assert.Equal(t, "admin", reset.IssuedBy)
+	assert.NotContains(t, stored, reset.Token, "only the hash is stored")
+
+	_, err := prs.Confirm(types.PasswordResetConfirmData{Token: reset.Token, Password: "new-password", SamePassword: "other"})
+	assert.ErrorIs(t, err, errDefs.ErrBadRequest)
+
+	username, err := prs.Confirm(types.PasswordResetConfirmData{Token: reset.Token, Password: "new-password", SamePassword: "new-password"})
+	require.NoError(t, err)
+	assert.Equal(t, "john", username)
+
+	_, err = prs.Confirm(types.PasswordResetConfirmData{Token: reset.Token, Password: "new-password", SamePassword: "new-password"})
+	assert.ErrorIs(t, err, errDefs.ErrUnauthorized, "tokens are single-use")
+}
+
+func TestPasswordResetRequest(t *testing.T) {
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	saved := 0
+	repo := &mocks.PasswordResetRepositoryMock{
+		SavePasswordResetFn: func(username string, tokenHash string, expiresAt time.Time) error {
+			if username != "john" {
+				return fmt.Errorf("%w: no account found with the specified username", errDefs.ErrEntityNotFound)
+			}
+			saved++
+			return nil
+		},
+	}
+	notifier := &recordingNotifier{}
+	prs := service.NewPasswordResetService(repo, notifier)
+	prs.Now = func() time.Time { return now }
+
+	assert.NoError(t, prs.Request("nobody"), "unknown accounts are not revealed")
+	assert.Empty(t, notifier.resets)
+
+	require.NoError(t, prs.Request("john"))
+	require.Len(t, notifier.resets, 1)
+	assert.Empty(t, notifier.resets[0].IssuedBy)
+
+	require.NoError(t, prs.Request("john"))
+	assert.Equal(t, 1, saved, "requests during the cooldown are ignored")
+
+	now = now.Add(prs.RequestCooldown)
+	require.NoError(t, prs.Request("john"))
+	assert.Equal(t, 2, saved)
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tick_test/internal/config"
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/blobstore"
+)
+
+// AvatarKey names the avatar of an account after its id, which unlike the
+// username never changes.
+func AvatarKey(accountId int64) string {
+	return "avatars/" + strconv.FormatInt(accountId, 10)
+}
+
+// NewErasurePolicy checks the erasure rules of the configuration. Messages
+// and reviews can be deleted or anonymized; revisions can be anonymized or
+// kept under the editor's name. Empty rules keep their default.
+func NewErasurePolicy(cfg config.ErasureConfig) (types.ErasurePolicy, error) {
+	policy := types.DefaultErasurePolicy
+	rules := []struct {
+		name    string
+		value   string
+		action  *types.ErasureAction
+		allowed []types.ErasureAction
+	}{
+		{"erasure.sentMessages", cfg.SentMessages, &policy.SentMessages, []types.ErasureAction{types.EraseDelete, types.EraseAnonymize}},
+		{"erasure.receivedMessages", cfg.ReceivedMessages, &policy.ReceivedMessages, []types.ErasureAction{types.EraseDelete, types.EraseAnonymize}},
+		{"erasure.reviews", cfg.Reviews, &policy.Reviews, []types.ErasureAction{types.EraseDelete, types.EraseAnonymize}},
+		{"erasure.revisions", cfg.Revisions, &policy.Revisions, []types.ErasureAction{types.EraseAnonymize, types.EraseKeep}},
+	}
+	for _, rule := range rules {
+		if rule.value == "" {
+			continue
+		}
+		valid := false
+		for _, action := range rule.allowed {
+			if types.ErasureAction(rule.value) == action {
+				*rule.action, valid = action, true
+			}
+		}
+		if !valid {
+			return types.DefaultErasurePolicy, fmt.Errorf("%s: unknown action %q", rule.name, rule.value)
+		}
+	}
+	return policy, nil
+}
+
+// PersonalDataService exports the data of an account and erases accounts
+// together with their avatar.
+type PersonalDataService struct {
+	Repo     repository.PersonalDataRepository
+	Accounts repository.AccountRepository
+	Avatars  blobstore.Store
+	Now      func() time.Time
+}
+
+func NewPersonalDataService(repo repository.PersonalDataRepository, accounts repository.AccountRepository, avatars blobstore.Store) *PersonalDataService {
+	return &PersonalDataService{Repo: repo, Accounts: accounts, Avatars: avatars, Now: time.Now}
+}
+
+// Export writes the data of the account to w as a zip archive with one JSON
+// file per kind of data, plus the avatar if there is one.
+func (pds *PersonalDataService) Export(username string, w io.Writer) error {
+	data, err := pds.Repo.FindPersonalData(username)
+	if err != nil {
+		return err
+	}
+	// every list is exported as [], never as null
+	if data.MessagesSent == nil {
+		data.MessagesSent = make([]types.Message, 0)
+	}
+	if data.MessagesReceived == nil {
+		data.MessagesReceived = make([]types.Message, 0)
+	}
+	if data.Reviews == nil {
+		data.Reviews = make([]types.Review, 0)
+	}
+	if data.Shelves == nil {
+		data.Shelves = make([]types.Shelf, 0)
+	}
+	if data.Revisions == nil {
+		data.Revisions = make([]types.EditedRevision, 0)
+	}
+	var avatar []byte
+	if data.Profile.HasAvatar {
+		id, err := pds.Accounts.FindAccountIdByUsername(username)
+		if err != nil {
+			return err
+		}
+		avatar, _, err = pds.Avatars.Get(AvatarKey(id))
+		if err != nil && !errors.Is(err, blobstore.ErrNotFound) {
+			return err
+		}
+	}
+
+	now := pds.Now()
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name    string
+		content any
+	}{
+		{"profile.json", data.Profile},
+		{"messages-sent.json", data.MessagesSent},
+		{"messages-received.json", data.MessagesReceived},
+		{"reviews.json", data.Reviews},
+		{"shelves.json", data.Shelves},
+		{"revisions.json", data.Revisions},
+	}
+	for _, file := range files {
+		content, err := json.MarshalIndent(file.content, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeArchiveFile(archive, file.name, content, now); err != nil {
+			return err
+		}
+	}
+	if len(avatar) > 0 {
+		if err := writeArchiveFile(archive, "avatar"+imageExtension(avatar), avatar, now); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func writeArchiveFile(archive *zip.Writer, name string, content []byte, modified time.Time) error {
+	file, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(content)
+	return err
+}
+
+func imageExtension(data []byte) string {
+	switch http.DetectContentType(data) {
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpg"
+	default:
+		return ""
+	}
+}
+
+// Erase deletes the account according to the erasure policy of the
+// repository and removes its avatar.
+func (pds *PersonalDataService) Erase(username string) error {
+	id, err := pds.Accounts.FindAccountIdByUsername(username)
+	if err != nil {
+		return err
+	}
+	if err := pds.Accounts.DeleteAccount(username); err != nil {
+		return err
+	}
+	return pds.Avatars.Delete(AvatarKey(id))
+}
@@ -0,0 +1,92 @@
+package service_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/internal/config"
+	"tick_test/service"
+	"tick_test/types"
+)
+
+func TestNewErasurePolicy(t *testing.T) {
+	policy, err := service.NewErasurePolicy(config.ErasureConfig{Reviews: "delete", Revisions: "keep"})
+	require.NoError(t, err)
+	assert.Equal(t, types.ErasurePolicy{
+		SentMessages:     types.EraseAnonymize,
+		ReceivedMessages: types.EraseDelete,
+		Reviews:          types.EraseDelete,
+		Revisions:        types.EraseKeep,
+	}, policy)
+
+	_, err = service.NewErasurePolicy(config.ErasureConfig{SentMessages: "keep"})
+	assert.ErrorContains(t, err, "erasure.sentMessages", "messages cannot be kept without their account")
+	_, err = service.NewErasurePolicy(config.ErasureConfig{Revisions: "delete"})
+	assert.ErrorContains(t, err, "erasure.revisions", "the edit history is never deleted")
+}
+
+func TestPersonalDataExport(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	repo := &mocks.PersonalDataRepositoryMock{
+		FindPersonalDataFn: func(username string) (types.PersonalData, error) {
+			return types.PersonalData{
+				Profile:      types.Profile{Username: username, HasAvatar: true},
+				MessagesSent: []types.Message{{From: username, To: "jane", Content: "hi"}},
+				Reviews:      []types.Review{{Book: "B1", Username: username, Stars: 4}},
+			}, nil
+		},
+	}
+	accounts := &mocks.AccountRepositoryMock{
+		FindAccountIdByUsernameFn: func(string) (int64, error) { return 3, nil },
+	}
+	store := &mocks.BlobStoreMock{Blobs: map[string][]byte{"avatars/3": png}}
+	pds := service.NewPersonalDataService(repo, accounts, store)
+
+	var archive bytes.Buffer
+	require.NoError(t, pds.Export("john", &archive))
+
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		require.NoError(t, err)
+		files[file.Name], err = io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+	}
+	assert.Len(t, files, 7)
+	assert.Equal(t, png, files["avatar.png"])
+
+	var sent []types.Message
+	require.NoError(t, json.Unmarshal(files["messages-sent.json"], &sent))
+	assert.Equal(t, "jane", sent[0].To)
+	assert.JSONEq(t, "[]", string(files["shelves.json"]))
+	assert.JSONEq(t, "[]", string(files["messages-received.json"]))
+	assert.JSONEq(t, "[]", string(files["revisions.json"]))
+}
+
+func TestPersonalDataErase(t *testing.T) {
+	var deleted string
+	accounts := &mocks.AccountRepositoryMock{
+		FindAccountIdByUsernameFn: func(string) (int64, error) { return 3, nil },
+		DeleteAccountFn: func(username string) error {
+			deleted = username
+			return nil
+		},
+	}
+	store := &mocks.BlobStoreMock{Blobs: map[string][]byte{"avatars/3": []byte("avatar"), "avatars/4": []byte("other")}}
+	pds := service.NewPersonalDataService(&mocks.PersonalDataRepositoryMock{}, accounts, store)
+
+	require.NoError(t, pds.Erase("john"))
+	assert.Equal(t, "john", deleted)
+	assert.NotContains(t, store.Blobs, "avatars/3")
+	assert.Contains(t, store.Blobs, "avatars/4")
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	// time zone names have to resolve on hosts without a zoneinfo database
+	_ "time/tzdata"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"golang.org/x/text/language"
+)
+
+type ProfileService struct {
+	Repo repository.ProfileRepository
+}
+
+func NewProfileService(repo repository.ProfileRepository) *ProfileService {
+	return &ProfileService{Repo: repo}
+}
+
+func (ps *ProfileService) Get(username string) (types.Profile, error) {
+	return ps.Repo.FindProfile(username)
+}
+
+// Update applies the fields present in data. Email addresses, IANA time
+// zone names and BCP 47 locales are checked and normalized.
+func (ps *ProfileService) Update(username string, data types.ProfilePatchData) (types.Profile, error) {
+	profile, err := ps.Repo.FindProfile(username)
+	if err != nil {
+		return types.Profile{}, err
+	}
+	if data.DisplayName != nil {
+		profile.DisplayName = strings.TrimSpace(*data.DisplayName)
+	}
+	if data.Bio != nil {
+		profile.Bio = strings.TrimSpace(*data.Bio)
+	}
+	if data.Email != nil {
+		if profile.Email, err = normalizeEmail(*data.Email); err != nil {
+			return types.Profile{}, err
+		}
+	}
+	if data.Timezone != nil {
+		profile.Timezone = strings.TrimSpace(*data.Timezone)
+		if _, err := time.LoadLocation(profile.Timezone); err != nil || profile.Timezone == "Local" {
+			return types.Profile{}, fmt.Errorf("%w: unknown time zone %q", errDefs.ErrBadRequest, profile.Timezone)
+		}
+	}
+	if data.Locale != nil {
+		if profile.Locale, err = normalizeLocale(*data.Locale); err != nil {
+			return types.Profile{}, err
+		}
+	}
+	if err := ps.Repo.SaveProfile(profile); err != nil {
+		return types.Profile{}, err
+	}
+	return ps.Repo.FindProfile(username)
+}
+
+func (ps *ProfileService) SetAvatar(username string, hasAvatar bool) error {
+	return ps.Repo.SetAvatar(username, hasAvatar)
+}
+
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return "", nil
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", fmt.Errorf("%w: invalid email address %q", errDefs.ErrBadRequest, email)
+	}
+	return address.Address, nil
+}
+
+func normalizeLocale(locale string) (string, error) {
+	locale = strings.TrimSpace(locale)
+	if locale == "" {
+		return "", nil
+	}
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid locale %q", errDefs.ErrBadRequest, locale)
+	}
+	return tag.String(), nil
+}
@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+func ptr(s string) *string { return &s }
+
+func TestProfileUpdate(t *testing.T) {
+	stored := types.Profile{Username: "john", Role: types.UserRole, DisplayName: "John", Email: "john@example.com"}
+	repo := &mocks.ProfileRepositoryMock{
+		FindProfileFn: func(username string) (types.Profile, error) { return stored, nil },
+		SaveProfileFn: func(profile types.Profile) error {
+			stored = profile
+			return nil
+		},
+	}
+	ps := service.NewProfileService(repo)
+
+	profile, err := ps.Update("john", types.ProfilePatchData{
+		Bio:      ptr("  Reads a lot. "),
+		Timezone: ptr("Europe/Vienna"),
+		Locale:   ptr("de-at"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "John", profile.DisplayName, "absent fields are kept")
+	assert.Equal(t, "john@example.com", profile.Email)
+	assert.Equal(t, "Reads a lot.", profile.Bio)
+	assert.Equal(t, "Europe/Vienna", profile.Timezone)
+	assert.Equal(t, "de-AT", profile.Locale)
+
+	profile, err = ps.Update("john", types.ProfilePatchData{Email: ptr("")})
+	require.NoError(t, err)
+	assert.Empty(t, profile.Email, "an empty string clears a field")
+
+	tests := []struct {
+		name string
+		data types.ProfilePatchData
+	}{
+		{name: "email", data: types.ProfilePatchData{Email: ptr("John <john@example.com>")}},
+		{name: "time zone", data: types.ProfilePatchData{Timezone: ptr("Mars/Olympus")}},
+		{name: "local time zone", data: types.ProfilePatchData{Timezone: ptr("Local")}},
+		{name: "locale", data: types.ProfilePatchData{Locale: ptr("not a locale")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ps.Update("john", tt.data)
+			assert.ErrorIs(t, err, errDefs.ErrBadRequest)
+		})
+	}
+}
+
+func TestProfilePublic(t *testing.T) {
+	profile := types.Profile{
+		Username:    "john",
+		Role:        types.AdminRole,
+		DisplayName: "John",
+		Email:       "john@example.com",
+		Bio:         "bio",
+		HasAvatar:   true,
+		Timezone:    "UTC",
+		Locale:      "en",
+	}
+	assert.Equal(t, types.PublicProfile{Username: "john", DisplayName: "John", Bio: "bio", HasAvatar: true}, profile.Public())
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"tick_test/repository"
+	"tick_test/types"
+
+	"github.com/sirupsen/logrus"
+)
+
+// similarNeighbours is how many similar books are kept per book after each refresh.
+const similarNeighbours = 20
+
+type scoredBook struct {
+	Code  string
+	Score float64
+}
+
+// RecommendationService precomputes item-to-item cosine similarity between
+// books from how users shelve and rate them.
+type RecommendationService struct {
+	Repo repository.RecommendationRepository
+
+	mutex     sync.RWMutex
+	similar   map[string][]scoredBook
+	userBooks map[string]map[string]float64
+}
+
+func NewRecommendationService(repo repository.RecommendationRepository) *RecommendationService {
+	return &RecommendationService{
+		Repo:      repo,
+		similar:   make(map[string][]scoredBook),
+		userBooks: make(map[string]map[string]float64),
+	}
+}
+
+func (rs *RecommendationService) Refresh() error {
+	interactions, err := rs.Repo.FindInteractions()
+	if err != nil {
+		return err
+	}
+
+	userBooks := make(map[string]map[string]float64)
+	norms := make(map[string]float64)
+	for _, interaction := range interactions {
+		if userBooks[interaction.Username] == nil {
+			userBooks[interaction.Username] = make(map[string]float64)
+		}
+		userBooks[interaction.Username][interaction.Book] += interaction.Weight
+	}
+	for _, books := range userBooks {
+		for code, weight := range books {
+			norms[code] += weight * weight
+		}
+	}
+
+	dots := make(map[string]map[string]float64)
+	for _, books := range userBooks {
+		for code, weight := range books {
+			for other, otherWeight := range books {
+				if code == other {
+					continue
+				}
+				if dots[code] == nil {
+					dots[code] = make(map[string]float64)
+				}
+				dots[code][other] += weight * otherWeight
+			}
+		}
+	}
+
+	similar := make(map[string][]scoredBook, len(dots))
+	for code, others := range dots {
+		neighbours := make([]scoredBook, 0, len(others))
+		for other, dot := range others {
+			denominator := math.Sqrt(norms[code] * norms[other])
+			if denominator == 0 || dot <= 0 {
+				continue
+			}
+			neighbours = append(neighbours, scoredBook{Code: other, Score: dot / denominator})
+		}
+		sortScoredBooks(neighbours)
+		if len(neighbours) > similarNeighbours {
+			neighbours = neighbours[:similarNeighbours]
+		}
+		similar[code] = neighbours
+	}
+
+	rs.mutex.Lock()
+	rs.similar = similar
+	rs.userBooks = userBooks
+	rs.mutex.Unlock()
+	return nil
+}
+
+// Start refreshes the similarity data immediately and then on every interval.
+func (rs *RecommendationService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := rs.Refresh(); err != nil {
+				logrus.Error("refreshing recommendations: ", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func sortScoredBooks(books []scoredBook) {
+	sort.Slice(books, func(i, j int) bool {
+		if books[i].Score != books[j].Score {
+			return books[i].Score > books[j].Score
+		}
+		return books[i].Code < books[j].Code
+	})
+}
+
+// resolve turns scored codes into recommendations, dropping codes of books
+// that were deleted since the last refresh.
+func (rs *RecommendationService) resolve(scored []scoredBook, reason string) ([]types.Recommendation, error) {
+	codes := make([]string, 0, len(scored))
+	for _, item := range scored {
+		codes = append(codes, item.Code)
+	}
+	books, err := rs.Repo.FindBooksByCodes(codes)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]types.Book, len(books))
+	for _, book := range books {
+		byCode[book.Code] = book
+	}
+
+	recommendations := make([]types.Recommendation, 0, len(scored))
+	for _, item := range scored {
+		if book, ok := byCode[item.Code]; ok {
+			recommendations = append(recommendations, types.Recommendation{Book: book, Score: item.Score, Reason: reason})
+		}
+	}
+	return recommendations, nil
+}
+
+// fillWithRelated tops recommendations up to limit with books by the same
+// author or in the same subject as code.
+func (rs *RecommendationService) fillWithRelated(recommendations []types.Recommendation, code string, limit int, exclude map[string]bool) ([]types.Recommendation, error) {
+	if len(recommendations) >= limit {
+		return recommendations[:limit], nil
+	}
+	related, err := rs.Repo.FindRelatedBooks(code, limit+len(exclude))
+	if err != nil {
+		return nil, err
+	}
+	for _, book := range related {
+		if len(recommendations) >= limit {
+			break
+		}
+		if exclude[book.Code] {
+			continue
+		}
+		exclude[book.Code] = true
+		recommendations = append(recommendations, types.Recommendation{Book: book, Reason: "same author or subject"})
+	}
+	return recommendations, nil
+}
+
+func (rs *RecommendationService) Similar(code string, limit int) ([]types.Recommendation, error) {
+	rs.mutex.RLock()
+	neighbours := rs.similar[code]
+	rs.mutex.RUnlock()
+
+	if len(neighbours) > limit {
+		neighbours = neighbours[:limit]
+	}
+	recommendations, err := rs.resolve(neighbours, "readers of this book also liked")
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := map[string]bool{code: true}
+	for _, recommendation := range recommendations {
+		exclude[recommendation.Code] = true
+	}
+	return rs.fillWithRelated(recommendations, code, limit, exclude)
+}
+
+func (rs *RecommendationService) Recommended(username string, limit int) ([]types.Recommendation, error) {
+	rs.mutex.RLock()
+	own := rs.userBooks[username]
+	scores := make(map[string]float64)
+	for code, weight := range own {
+		if weight <= 0 {
+			continue
+		}
+		for _, neighbour := range rs.similar[code] {
+			if _, seen := own[neighbour.Code]; !seen {
+				scores[neighbour.Code] += weight * neighbour.Score
+			}
+		}
+	}
+	rs.mutex.RUnlock()
+
+	scored := make([]scoredBook, 0, len(scores))
+	for code, score := range scores {
+		scored = append(scored, scoredBook{Code: code, Score: score})
+	}
+	sortScoredBooks(scored)
+	if len(scored) > limit {
+		scored = scored[:limit]
+	}
+	recommendations, err := rs.resolve(scored, "based on your shelves and ratings")
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := make(map[string]bool)
+	favourites := make([]scoredBook, 0, len(own))
+	for code, weight := range own {
+		exclude[code] = true
+		if weight > 0 {
+			favourites = append(favourites, scoredBook{Code: code, Score: weight})
+		}
+	}
+	for _, recommendation := range recommendations {
+		exclude[recommendation.Code] = true
+	}
+	sortScoredBooks(favourites)
+	for _, favourite := range favourites {
+		if len(recommendations) >= limit {
+			break
+		}
+		if recommendations, err = rs.fillWithRelated(recommendations, favourite.Code, limit, exclude); err != nil {
+			return nil, err
+		}
+	}
+	return recommendations, nil
+}
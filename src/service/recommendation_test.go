@@ -0,0 +1,76 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+	"tick_test/types"
+)
+
+func recommendationRepoMock(interactions []types.Interaction, related []types.Book) *mocks.RecommendationRepositoryMock {
+	return &mocks.RecommendationRepositoryMock{
+		FindInteractionsFn: func() ([]types.Interaction, error) {
+			return interactions, nil
+		},
+		FindRelatedBooksFn: func(code string, limit int) ([]types.Book, error) {
+			return related, nil
+		},
+		FindBooksByCodesFn: func(codes []string) ([]types.Book, error) {
+			books := make([]types.Book, 0, len(codes))
+			for _, code := range codes {
+				books = append(books, types.Book{Code: code})
+			}
+			return books, nil
+		},
+	}
+}
+
+func codesOf(recommendations []types.Recommendation) []string {
+	codes := make([]string, 0, len(recommendations))
+	for _, recommendation := range recommendations {
+		codes = append(codes, recommendation.Code)
+	}
+	return codes
+}
+
+func TestRecommendationSimilar(t *testing.T) {
+	rs := service.NewRecommendationService(recommendationRepoMock([]types.Interaction{
+		{Username: "anna", Book: "A", Weight: 1},
+		{Username: "anna", Book: "B", Weight: 1},
+		{Username: "bert", Book: "A", Weight: 1},
+		{Username: "bert", Book: "B", Weight: 1},
+		{Username: "bert", Book: "C", Weight: 1},
+		{Username: "carl", Book: "C", Weight: 1},
+		{Username: "carl", Book: "D", Weight: -1},
+	}, []types.Book{{Code: "A"}, {Code: "E"}}))
+	assert.NoError(t, rs.Refresh())
+
+	similar, err := rs.Similar("A", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"B", "C", "E"}, codesOf(similar))
+	assert.Greater(t, similar[0].Score, similar[1].Score)
+	assert.Equal(t, "same author or subject", similar[2].Reason)
+}
+
+func TestRecommendationRecommended(t *testing.T) {
+	rs := service.NewRecommendationService(recommendationRepoMock([]types.Interaction{
+		{Username: "anna", Book: "A", Weight: 1},
+		{Username: "bert", Book: "A", Weight: 1},
+		{Username: "bert", Book: "B", Weight: 1},
+		{Username: "carl", Book: "A", Weight: 1},
+		{Username: "carl", Book: "B", Weight: 1},
+		{Username: "carl", Book: "C", Weight: 1},
+	}, nil))
+	assert.NoError(t, rs.Refresh())
+
+	recommended, err := rs.Recommended("anna", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"B", "C"}, codesOf(recommended))
+
+	unknown, err := rs.Recommended("nobody", 5)
+	assert.NoError(t, err)
+	assert.Empty(t, unknown)
+}
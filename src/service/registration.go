@@ -0,0 +1,116 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/random"
+)
+
+const DefaultInviteLifetime = 7 * 24 * time.Hour
+
+// RegistrationService creates the accounts people register themselves
+// according to Mode, and manages invite codes and the registrations
+// awaiting approval.
+type RegistrationService struct {
+	Repo           repository.RegistrationRepository
+	Accounts       repository.AccountRepository
+	Mode           types.RegistrationMode
+	InviteLifetime time.Duration
+	Now            func() time.Time
+}
+
+func NewRegistrationService(repo repository.RegistrationRepository, accounts repository.AccountRepository) *RegistrationService {
+	return &RegistrationService{
+		Repo:           repo,
+		Accounts:       accounts,
+		Mode:           types.OpenRegistration,
+		InviteLifetime: DefaultInviteLifetime,
+		Now:            time.Now,
+	}
+}
+
+// Register creates the account and reports whether it awaits approval.
+// Without an invite code the account always gets the User role; an invite
+// code sets the role of the invite and is accepted in every mode. Nobody
+// registers as Admin; the first admin is created with the -create-admin
+// flag.
+func (rs *RegistrationService) Register(data *types.AccountPostData) (pending bool, err error) {
+	switch {
+	case data.InviteCode != "":
+		if data.Role != "" {
+			return false, fmt.Errorf("%w: the role of an invited account is set by its invite", errDefs.ErrBadRequest)
+		}
+		_, err = rs.Repo.SaveInvitedAccount(data, HashToken(data.InviteCode), rs.Now())
+		return false, err
+	case data.Role != "" && types.Role(data.Role) != types.UserRole:
+		return false, fmt.Errorf("%w: role %s can only be granted by an invite or an admin", errDefs.ErrForbidden, data.Role)
+	}
+
+	data.Role = string(types.UserRole)
+	switch rs.Mode {
+	case types.InviteRegistration:
+		return false, fmt.Errorf("%w: registration requires an invite code", errDefs.ErrForbidden)
+	case types.ApprovalRegistration:
+		return true, rs.Repo.SavePendingAccount(data, rs.Now())
+	default:
+		return false, rs.Accounts.SaveAccount(data)
+	}
+}
+
+// Invite issues a single-use code registering an account of the invite's
+// role, User if none is given. Only a hash of the code is stored, so it is
+// returned this one time.
+func (rs *RegistrationService) Invite(createdBy string, data types.InvitePostData) (types.InviteCreated, error) {
+	role := types.Role(data.Role)
+	if role == "" {
+		role = types.UserRole
+	}
+	lifetime := rs.InviteLifetime
+	if data.ExpiresIn != "" {
+		var err error
+		if lifetime, err = types.ParseISO8601Duration(data.ExpiresIn, time.Minute); err != nil {
+			return types.InviteCreated{}, fmt.Errorf("%w: expiresIn: %v", errDefs.ErrBadRequest, err)
+		}
+	}
+	code, err := random.Token(16)
+	if err != nil {
+		return types.InviteCreated{}, err
+	}
+	now := rs.Now().UTC()
+	invite := types.Invite{
+		Role:      role,
+		CreatedBy: createdBy,
+		CreatedAt: now.Format(time.RFC3339),
+		ExpiresAt: now.Add(lifetime).Format(time.RFC3339),
+	}
+	if invite.Id, err = rs.Repo.CreateInvite(invite, HashToken(code)); err != nil {
+		return types.InviteCreated{}, err
+	}
+	return types.InviteCreated{Invite: invite, Code: code}, nil
+}
+
+func (rs *RegistrationService) Invites() ([]types.Invite, error) {
+	return rs.Repo.FindInvites()
+}
+
+func (rs *RegistrationService) RevokeInvite(id int64) error {
+	return rs.Repo.DeleteInvite(id)
+}
+
+func (rs *RegistrationService) Pending() ([]types.PendingAccount, error) {
+	return rs.Repo.FindPendingAccounts()
+}
+
+// PendingAccount returns ErrEntityNotFound unless the registration of
+// username awaits approval.
+func (rs *RegistrationService) PendingAccount(username string) (types.PendingAccount, error) {
+	return rs.Repo.FindPendingAccount(username)
+}
+
+func (rs *RegistrationService) Approve(username string) error {
+	return rs.Repo.ApprovePendingAccount(username)
+}
@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+func TestRegister(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mode          types.RegistrationMode
+		data          types.AccountPostData
+		expectedSaved string
+		expectPending bool
+		expectedErr   error
+	}{
+		{name: "Open", mode: types.OpenRegistration, data: types.AccountPostData{Username: "john"}, expectedSaved: "account"},
+		{name: "Open - role User", mode: types.OpenRegistration, data: types.AccountPostData{Username: "john", Role: "User"}, expectedSaved: "account"},
+		{name: "Open - self-assigned role", mode: types.OpenRegistration, data: types.AccountPostData{Username: "john", Role: "BookKeeper"}, expectedErr: errDefs.ErrForbidden},
+		{name: "Open - self-assigned Admin", mode: types.OpenRegistration, data: types.AccountPostData{Username: "john", Role: "Admin"}, expectedErr: errDefs.ErrForbidden},
+		{name: "Invite only - no code", mode: types.InviteRegistration, data: types.AccountPostData{Username: "john"}, expectedErr: errDefs.ErrForbidden},
+		{name: "Invite only - code", mode: types.InviteRegistration, data: types.AccountPostData{Username: "john", InviteCode: "code"}, expectedSaved: "invited"},
+		{name: "Invite code with role", mode: types.OpenRegistration, data: types.AccountPostData{Username: "john", Role: "User", InviteCode: "code"}, expectedErr: errDefs.ErrBadRequest},
+		{name: "Approval", mode: types.ApprovalRegistration, data: types.AccountPostData{Username: "john"}, expectedSaved: "pending", expectPending: true},
+		{name: "Approval - code", mode: types.ApprovalRegistration, data: types.AccountPostData{Username: "john", InviteCode: "code"}, expectedSaved: "invited"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var saved string
+			accounts := &mocks.AccountRepositoryMock{
+				SaveAccountFn: func(data *types.AccountPostData) error {
+					saved = "account"
+					return nil
+				},
+			}
+			repo := &mocks.RegistrationRepositoryMock{
+				SaveInvitedAccountFn: func(data *types.AccountPostData, codeHash string, now time.Time) (types.Role, error) {
+					assert.Equal(t, service.HashToken("code"), codeHash)
+					saved = "invited"
+					return types.BookKeeperRole, nil
+				},
+				SavePendingAccountFn: func(data *types.AccountPostData, now time.Time) error {
+					saved = "pending"
+					return nil
+				},
+			}
+			rs := service.NewRegistrationService(repo, accounts)
+			rs.Mode = tc.mode
+
+			data := tc.data
+			pending, err := rs.Register(&data)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+				assert.Empty(t, saved)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectPending, pending)
+			assert.Equal(t, tc.expectedSaved, saved)
+			if saved != "invited" && tc.data.Role == "" {
+				assert.Equal(t, "User", data.Role)
+			}
+		})
+	}
+}
+
+func TestInvite(t *testing.T) {
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	var stored types.Invite
+	var storedHash string
+	repo := &mocks.RegistrationRepositoryMock{
+		CreateInviteFn: func(invite types.Invite, codeHash string) (int64, error) {
+			stored, storedHash = invite, codeHash
+			return 3, nil
+		},
+	}
+	rs := service.NewRegistrationService(repo, nil)
+	rs.Now = func() time.Time { return now }
+
+	created, err := rs.Invite("admin", types.InvitePostData{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), created.Id)
+	assert.Equal(t, types.UserRole, created.Role)
+	assert.Equal(t, "admin", stored.CreatedBy)
+	assert.Equal(t, "2025-03-14T12:00:00Z", created.ExpiresAt)
+	assert.NotEmpty(t, created.Code)
+	assert.Equal(t, service.HashToken(created.Code), storedHash, "only the hash is stored")
+
+	created, err = rs.Invite("admin", types.InvitePostData{Role: "BookKeeper", ExpiresIn: "PT2H"})
+	require.NoError(t, err)
+	assert.Equal(t, types.BookKeeperRole, created.Role)
+	assert.Equal(t, "2025-03-07T14:00:00Z", created.ExpiresAt)
+
+	_, err = rs.Invite("admin", types.InvitePostData{ExpiresIn: "PT10S"})
+	require.ErrorIs(t, err, errDefs.ErrBadRequest)
+}
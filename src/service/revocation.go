@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/jwt"
+
+	"github.com/sirupsen/logrus"
+)
+
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// RevocationStore is the token denylist. Lookups are served from memory; the
+// database keeps revocations across restarts. Single tokens are denylisted by
+// their jti, single JWT logins by their session id.
+type RevocationStore struct {
+	Repo repository.RevocationRepository
+	// MaxTokenLifetime bounds how long a per-account cutoff has to be kept.
+	MaxTokenLifetime time.Duration
+
+	mutex   sync.RWMutex
+	tokens  map[string]time.Time
+	cutoffs map[string]time.Time
+}
+
+func NewRevocationStore(repo repository.RevocationRepository, maxTokenLifetime time.Duration) *RevocationStore {
+	rs := &RevocationStore{
+		Repo:             repo,
+		MaxTokenLifetime: maxTokenLifetime,
+		tokens:           make(map[string]time.Time),
+		cutoffs:          make(map[string]time.Time),
+	}
+	repository.SetTokensRevokedHook(rs.rememberCutoff)
+	return rs
+}
+
+// Load replaces the in-memory denylist with the one in the database.
+func (rs *RevocationStore) Load() error {
+	tokens, cutoffs, err := rs.Repo.FindRevocations()
+	if err != nil {
+		return err
+	}
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.tokens = make(map[string]time.Time, len(tokens))
+	for _, token := range tokens {
+		rs.tokens[token.Jti] = token.ExpiresAt
+	}
+	rs.cutoffs = make(map[string]time.Time, len(cutoffs))
+	for _, cutoff := range cutoffs {
+		rs.cutoffs[cutoff.Username] = cutoff.RevokedBefore
+	}
+	return nil
+}
+
+func (rs *RevocationStore) rememberCutoff(cutoff types.TokenCutoff) {
+	rs.mutex.Lock()
+	rs.cutoffs[cutoff.Username] = cutoff.RevokedBefore
+	rs.mutex.Unlock()
+}
+
+// Check is a jwt.RevocationChecker.
+func (rs *RevocationStore) Check(claims jwt.Claims) error {
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+	if _, revoked := rs.tokens[claims.Jti]; revoked {
+		return ErrTokenRevoked
+	}
+	if _, revoked := rs.tokens[claims.SessionId]; revoked && claims.SessionId != "" {
+		return ErrTokenRevoked
+	}
+	if cutoff, ok := rs.cutoffs[claims.Username]; ok && claims.IssuedAt.Before(cutoff) {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// Revoke denylists a single token until it expires.
+func (rs *RevocationStore) Revoke(claims jwt.Claims) error {
+	if err := rs.Repo.RevokeToken(claims.Jti, claims.Username, claims.ExpiresAt); err != nil {
+		return err
+	}
+	rs.mutex.Lock()
+	rs.tokens[claims.Jti] = claims.ExpiresAt
+	rs.mutex.Unlock()
+	return nil
+}
+
+// RevokeRefreshToken revokes refreshToken and every token rotated from the
+// same login, provided it belongs to username.
+func (rs *RevocationStore) RevokeRefreshToken(username string, refreshToken string) error {
+	return rs.Repo.RevokeRefreshTokenFamily(username, HashToken(refreshToken))
+}
+
+// RevokeSession ends the login of username with the public session id. The
+// access tokens of a JWT login carry the id and are denylisted by it until
+// the last of them expires.
+func (rs *RevocationStore) RevokeSession(username string, sessionId string) error {
+	kind, key, ok := types.ParseSessionId(sessionId)
+	if !ok {
+		return fmt.Errorf("%w: session not found", errDefs.ErrEntityNotFound)
+	}
+	if err := rs.Repo.RevokeAccountSession(username, kind, key); err != nil {
+		return err
+	}
+	if kind != types.TokenSession {
+		return nil
+	}
+	expiresAt := time.Now().Add(rs.MaxTokenLifetime)
+	if err := rs.Repo.RevokeToken(sessionId, username, expiresAt); err != nil {
+		return err
+	}
+	rs.mutex.Lock()
+	rs.tokens[sessionId] = expiresAt
+	rs.mutex.Unlock()
+	return nil
+}
+
+// RevokeAll invalidates every token issued to username so far.
+func (rs *RevocationStore) RevokeAll(username string) error {
+	return rs.Repo.RevokeAllTokens(username)
+}
+
+// Cleanup forgets revocations that no longer matter because the tokens they
+// cover have expired.
+func (rs *RevocationStore) Cleanup(now time.Time) error {
+	cutoffsBefore := now.Add(-rs.MaxTokenLifetime)
+	rs.mutex.Lock()
+	for jti, expiresAt := range rs.tokens {
+		if expiresAt.Before(now) {
+			delete(rs.tokens, jti)
+		}
+	}
+	for username, cutoff := range rs.cutoffs {
+		if cutoff.Before(cutoffsBefore) {
+			delete(rs.cutoffs, username)
+		}
+	}
+	rs.mutex.Unlock()
+	return rs.Repo.DeleteExpiredRevocations(now, cutoffsBefore)
+}
+
+// Start loads the denylist and then cleans it up on every interval.
+func (rs *RevocationStore) Start(interval time.Duration) {
+	if err := rs.Load(); err != nil {
+		logrus.Error("loading revoked tokens: ", err)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if err := rs.Cleanup(now); err != nil {
+				logrus.Error("cleaning up revoked tokens: ", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,91 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/jwt"
+)
+
+func TestRevocationStore(t *testing.T) {
+	jwt.SetSecretKey([]byte("RU5DT0RFRF9TRUNSRVRfVEVYVA=="))
+	now := time.Now()
+	repo := &mocks.RevocationRepositoryMock{
+		FindRevocationsFn: func() ([]types.RevokedToken, []types.TokenCutoff, error) {
+			return []types.RevokedToken{{Jti: "stolen", ExpiresAt: now.Add(time.Minute)}},
+				[]types.TokenCutoff{{Username: "anna", RevokedBefore: now}}, nil
+		},
+		RevokeTokenFn: func(jti string, username string, expiresAt time.Time) error {
+			return nil
+		},
+		DeleteExpiredRevocationsFn: func(now time.Time, cutoffsBefore time.Time) error {
+			return nil
+		},
+	}
+	store := service.NewRevocationStore(repo, time.Hour)
+	assert.NoError(t, store.Load())
+
+	assert.ErrorIs(t, store.Check(jwt.Claims{Username: "bert", Jti: "stolen"}), service.ErrTokenRevoked)
+	assert.ErrorIs(t, store.Check(jwt.Claims{Username: "anna", Jti: "a", IssuedAt: now.Add(-time.Second)}), service.ErrTokenRevoked)
+	assert.NoError(t, store.Check(jwt.Claims{Username: "anna", Jti: "b", IssuedAt: now.Add(time.Millisecond)}))
+
+	token, err := jwt.GenerateToken("bert", types.UserRole, time.Minute)
+	assert.NoError(t, err)
+	claims, err := jwt.ValidateToken(token)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, claims.Jti)
+	assert.NoError(t, store.Revoke(claims))
+
+	jwt.SetRevocationChecker(store.Check)
+	defer jwt.SetRevocationChecker(nil)
+	_, err = jwt.ValidateToken(token)
+	assert.ErrorIs(t, err, service.ErrTokenRevoked)
+
+	assert.NoError(t, store.Cleanup(now.Add(2*time.Hour)))
+	assert.NoError(t, store.Check(jwt.Claims{Username: "anna", Jti: "stolen", IssuedAt: now.Add(-time.Second)}))
+}
+
+func TestRevocationStoreRevokeSession(t *testing.T) {
+	jwt.SetSecretKey([]byte("RU5DT0RFRF9TRUNSRVRfVEVYVA=="))
+	var revokedKind types.SessionKind
+	var revokedKey string
+	denylisted := make([]string, 0)
+	repo := &mocks.RevocationRepositoryMock{
+		RevokeAccountSessionFn: func(username string, kind types.SessionKind, key string) error {
+			revokedKind, revokedKey = kind, key
+			return nil
+		},
+		RevokeTokenFn: func(jti string, username string, expiresAt time.Time) error {
+			denylisted = append(denylisted, jti)
+			return nil
+		},
+	}
+	store := service.NewRevocationStore(repo, time.Hour)
+
+	assert.ErrorIs(t, store.RevokeSession("anna", "laptop"), errDefs.ErrEntityNotFound)
+
+	assert.NoError(t, store.RevokeSession("anna", "session-12"))
+	assert.Equal(t, types.ServerSession, revokedKind)
+	assert.Equal(t, "12", revokedKey)
+	assert.Empty(t, denylisted, "server-side sessions are checked in the database")
+
+	assert.NoError(t, store.RevokeSession("anna", "token-a-b_c"))
+	assert.Equal(t, types.TokenSession, revokedKind)
+	assert.Equal(t, "a-b_c", revokedKey)
+	assert.Equal(t, []string{"token-a-b_c"}, denylisted)
+
+	token, err := jwt.GenerateSessionToken("anna", types.UserRole, time.Minute, "token-a-b_c")
+	assert.NoError(t, err)
+	claims, err := jwt.ValidateToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "token-a-b_c", claims.SessionId)
+	assert.ErrorIs(t, store.Check(claims), service.ErrTokenRevoked)
+	assert.NoError(t, store.Check(jwt.Claims{Username: "anna", Jti: "other", SessionId: "token-other"}))
+	assert.NoError(t, store.Check(jwt.Claims{Username: "anna", Jti: "apikey"}))
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"fmt"
+	"strings"
 	"tick_test/repository"
 	"tick_test/types"
 	"tick_test/utils/errDefs"
@@ -16,9 +17,22 @@ type SecurityService struct {
 	AccRepo repository.AccountRepository
 }
 
+// AccessToken returns the token of the User-Token header or, as OAuth2
+// clients send it, of an "Authorization: Bearer <token>" header.
+func AccessToken(c *gin.Context) string {
+	if token := c.GetHeader("User-Token"); token != "" {
+		return token
+	}
+	scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
 func (ss *SecurityService) CheckRole(c *gin.Context, role types.Role) (types.Account, bool, error) {
 	acc := types.Account{}
-	claims, err := jwt.ValidateToken(c.Request.Header.Get("User-Token"))
+	claims, err := jwt.ValidateToken(AccessToken(c))
 	if err != nil {
 		return acc, false, fmt.Errorf("%w: %v", errDefs.ErrUnauthorized, err)
 	}
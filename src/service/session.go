@@ -0,0 +1,91 @@
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/jwt"
+	"tick_test/utils/random"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultSessionIdleTimeout = 30 * time.Minute
+	DefaultSessionMaxLifetime = 30 * 24 * time.Hour
+)
+
+// SessionService manages opaque, server-side session tokens. A session stays
+// valid while it is used at least every IdleTimeout, but never longer than
+// MaxLifetime, and can be revoked at any time.
+type SessionService struct {
+	Repo        repository.SessionRepository
+	IdleTimeout time.Duration
+	MaxLifetime time.Duration
+}
+
+func NewSessionService(repo repository.SessionRepository) *SessionService {
+	return &SessionService{
+		Repo:        repo,
+		IdleTimeout: DefaultSessionIdleTimeout,
+		MaxLifetime: DefaultSessionMaxLifetime,
+	}
+}
+
+func (ss *SessionService) Open(username string, device types.DeviceInfo) (string, error) {
+	token, err := random.Token(32)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	if err := ss.Repo.CreateSession(username, HashToken(token), device, now.Add(ss.IdleTimeout), now.Add(ss.MaxLifetime)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (ss *SessionService) Validate(token string) (jwt.Claims, error) {
+	now := time.Now()
+	id, username, role, err := ss.Repo.TouchSession(HashToken(token), now, now.Add(ss.IdleTimeout))
+	if err != nil {
+		return jwt.Claims{}, err
+	}
+	sessionId := types.SessionId(types.ServerSession, strconv.FormatInt(id, 10))
+	return jwt.Claims{Username: username, Role: role, SessionId: sessionId}, nil
+}
+
+func (ss *SessionService) Revoke(token string) error {
+	return ss.Repo.RevokeSession(HashToken(token))
+}
+
+// List returns the active logins of the account, server-side sessions and
+// JWT logins alike. The one named by currentId is marked as current.
+func (ss *SessionService) List(username string, currentId string) ([]types.Session, error) {
+	sessions, err := ss.Repo.FindActiveSessions(username, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].Id == currentId
+	}
+	return sessions, nil
+}
+
+func (ss *SessionService) Cleanup(now time.Time) error {
+	return ss.Repo.DeleteExpiredSessions(now)
+}
+
+// Start deletes expired and revoked sessions on every interval.
+func (ss *SessionService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if err := ss.Cleanup(now); err != nil {
+				logrus.Error("cleaning up sessions: ", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,26 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+)
+
+func TestSessionCleanup(t *testing.T) {
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	var deletedBefore time.Time
+	ss := service.NewSessionService(&mocks.SessionRepositoryMock{
+		DeleteExpiredSessionsFn: func(now time.Time) error {
+			deletedBefore = now
+			return nil
+		},
+	})
+
+	require.NoError(t, ss.Cleanup(now))
+	assert.Equal(t, now, deletedBefore)
+}
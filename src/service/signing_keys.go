@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"tick_test/utils/jwt"
+	"tick_test/utils/random"
+
+	"github.com/sirupsen/logrus"
+)
+
+const signingKeyExtension = ".pem"
+
+type datedSigningKey struct {
+	jwt.SigningKey
+	path      string
+	createdAt time.Time
+}
+
+// SigningKeyRotator keeps the JWT keyring in a directory of PEM files, one
+// private key per file named <kid>.pem. The newest file holds the active
+// key; older ones stay as retired keys for RetiredKeyLifetime after they
+// were superseded, so that the tokens they signed can still be verified.
+// Instances sharing the directory pick up each other's keys on Refresh.
+type SigningKeyRotator struct {
+	Dir       string
+	Algorithm string
+	// RotationInterval is how long a key stays active; 0 disables rotation.
+	RotationInterval   time.Duration
+	RetiredKeyLifetime time.Duration
+	Now                func() time.Time
+}
+
+func NewSigningKeyRotator(dir string, algorithm string) *SigningKeyRotator {
+	return &SigningKeyRotator{
+		Dir:                dir,
+		Algorithm:          algorithm,
+		RotationInterval:   30 * 24 * time.Hour,
+		RetiredKeyLifetime: 24 * time.Hour,
+		Now:                time.Now,
+	}
+}
+
+// Refresh loads the keys from the directory into the jwt keyring, creating
+// the first key or a new active one when rotation is due, and deletes keys
+// that have been retired for longer than RetiredKeyLifetime.
+func (skr *SigningKeyRotator) Refresh() error {
+	keys, err := skr.loadKeys()
+	if err != nil {
+		return err
+	}
+	now := skr.Now()
+	if len(keys) == 0 || skr.RotationInterval > 0 && !now.Before(keys[len(keys)-1].createdAt.Add(skr.RotationInterval)) {
+		key, err := skr.createKey(now)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		logrus.Info("created JWT signing key ", key.Id)
+	}
+
+	signingKeys := make([]jwt.SigningKey, 0, len(keys))
+	for i, key := range keys {
+		if i < len(keys)-1 && keys[i+1].createdAt.Add(skr.RetiredKeyLifetime).Before(now) {
+			if err := os.Remove(key.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			logrus.Info("removed retired JWT signing key ", key.Id)
+			continue
+		}
+		signingKeys = append(signingKeys, key.SigningKey)
+	}
+	jwt.SetKeyring(jwt.NewKeyring(signingKeys...))
+	return nil
+}
+
+// loadKeys returns the keys in the directory, oldest first.
+func (skr *SigningKeyRotator) loadKeys() ([]datedSigningKey, error) {
+	if err := os.MkdirAll(skr.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(skr.Dir)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]datedSigningKey, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), signingKeyExtension) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(skr.Dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := jwt.ParseSigningKeyPEM(strings.TrimSuffix(entry.Name(), signingKeyExtension), data)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s: %w", path, err)
+		}
+		keys = append(keys, datedSigningKey{SigningKey: key, path: path, createdAt: info.ModTime()})
+	}
+	slices.SortFunc(keys, func(a, b datedSigningKey) int {
+		if c := a.createdAt.Compare(b.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Id, b.Id)
+	})
+	return keys, nil
+}
+
+// createKey writes a new key to the directory. It is written under a
+// temporary name first so that other instances never read half of it.
+func (skr *SigningKeyRotator) createKey(now time.Time) (datedSigningKey, error) {
+	suffix, err := random.Token(6)
+	if err != nil {
+		return datedSigningKey{}, err
+	}
+	id := now.UTC().Format("20060102T150405Z") + "-" + suffix
+	key, err := jwt.GenerateSigningKey(id, skr.Algorithm)
+	if err != nil {
+		return datedSigningKey{}, err
+	}
+	data, err := key.EncodePEM()
+	if err != nil {
+		return datedSigningKey{}, err
+	}
+	path := filepath.Join(skr.Dir, id+signingKeyExtension)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return datedSigningKey{}, err
+	}
+	if err := os.Chtimes(tmp, now, now); err != nil {
+		return datedSigningKey{}, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return datedSigningKey{}, err
+	}
+	return datedSigningKey{SigningKey: key, path: path, createdAt: now}, nil
+}
+
+// Start refreshes the keyring on every interval.
+func (skr *SigningKeyRotator) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := skr.Refresh(); err != nil {
+				logrus.Error("refreshing JWT signing keys: ", err)
+			}
+		}
+	}()
+}
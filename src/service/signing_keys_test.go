@@ -0,0 +1,48 @@
+package service_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tick_test/service"
+	"tick_test/utils/jwt"
+)
+
+func TestSigningKeyRotator(t *testing.T) {
+	original := jwt.GetKeyring()
+	defer jwt.SetKeyring(original)
+
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+	skr := service.NewSigningKeyRotator(dir, jwt.EdDSAAlgorithm)
+	skr.RotationInterval = 30 * 24 * time.Hour
+	skr.RetiredKeyLifetime = time.Hour
+	skr.Now = func() time.Time { return now }
+
+	require.NoError(t, skr.Refresh())
+	first, ok := jwt.GetKeyring().Active()
+	require.True(t, ok, "the first key is created")
+	assert.Equal(t, jwt.EdDSAAlgorithm, first.Algorithm)
+
+	now = now.Add(24 * time.Hour)
+	require.NoError(t, skr.Refresh())
+	active, _ := jwt.GetKeyring().Active()
+	assert.Equal(t, first.Id, active.Id, "rotation is not due yet")
+
+	now = now.Add(30 * 24 * time.Hour)
+	require.NoError(t, skr.Refresh())
+	active, _ = jwt.GetKeyring().Active()
+	assert.NotEqual(t, first.Id, active.Id)
+	assert.Len(t, jwt.GetKeyring().JWKS().Keys, 2, "the retired key is still published")
+
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, skr.Refresh())
+	assert.Len(t, jwt.GetKeyring().JWKS().Keys, 1)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "the retired key file is removed")
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SuspensionStore refuses the tokens of suspended accounts. Lookups are
+// served from memory; the database is the source of truth and is reloaded
+// on every interval so that suspensions made elsewhere take effect.
+type SuspensionStore struct {
+	Repo repository.SuspensionRepository
+	Now  func() time.Time
+
+	mutex     sync.RWMutex
+	suspended map[string]types.Suspension
+}
+
+func NewSuspensionStore(repo repository.SuspensionRepository) *SuspensionStore {
+	return &SuspensionStore{
+		Repo:      repo,
+		Now:       time.Now,
+		suspended: make(map[string]types.Suspension),
+	}
+}
+
+// Load replaces the in-memory suspensions with the ones in the database.
+func (ss *SuspensionStore) Load() error {
+	suspensions, err := ss.Repo.FindSuspensions(ss.Now())
+	if err != nil {
+		return err
+	}
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	ss.suspended = make(map[string]types.Suspension, len(suspensions))
+	for _, suspension := range suspensions {
+		ss.suspended[suspension.Username] = suspension
+	}
+	return nil
+}
+
+// Check returns repository.SuspendedError while username is suspended.
+func (ss *SuspensionStore) Check(username string) error {
+	ss.mutex.RLock()
+	suspension, ok := ss.suspended[username]
+	ss.mutex.RUnlock()
+	if !ok || !ss.active(suspension) {
+		return nil
+	}
+	return repository.SuspendedError(suspension)
+}
+
+func (ss *SuspensionStore) active(suspension types.Suspension) bool {
+	if suspension.Until == nil {
+		return true
+	}
+	until, err := time.Parse(time.RFC3339, *suspension.Until)
+	return err != nil || ss.Now().Before(until)
+}
+
+// Suspend suspends data.Username on behalf of suspendedBy until data.Until,
+// or indefinitely without it.
+func (ss *SuspensionStore) Suspend(data types.SuspensionPostData, suspendedBy string) (types.Suspension, error) {
+	now := ss.Now().UTC()
+	suspension := types.Suspension{
+		Username:    data.Username,
+		Reason:      data.Reason,
+		SuspendedBy: suspendedBy,
+		SuspendedAt: now.Format(time.RFC3339),
+	}
+	if data.Until != "" {
+		until, err := time.Parse(time.RFC3339, data.Until)
+		if err != nil {
+			return types.Suspension{}, fmt.Errorf("%w: until: %v", errDefs.ErrBadRequest, err)
+		}
+		if !until.After(now) {
+			return types.Suspension{}, fmt.Errorf("%w: until needs to be in the future", errDefs.ErrBadRequest)
+		}
+		formatted := until.UTC().Format(time.RFC3339)
+		suspension.Until = &formatted
+	}
+	if err := ss.Repo.SuspendAccount(suspension); err != nil {
+		return types.Suspension{}, err
+	}
+	ss.mutex.Lock()
+	ss.suspended[suspension.Username] = suspension
+	ss.mutex.Unlock()
+	return suspension, nil
+}
+
+func (ss *SuspensionStore) Reinstate(username string) error {
+	if err := ss.Repo.ReinstateAccount(username); err != nil {
+		return err
+	}
+	ss.mutex.Lock()
+	delete(ss.suspended, username)
+	ss.mutex.Unlock()
+	return nil
+}
+
+// List returns the suspensions in effect.
+func (ss *SuspensionStore) List() ([]types.Suspension, error) {
+	return ss.Repo.FindSuspensions(ss.Now())
+}
+
+// Start loads the suspensions and then reloads them on every interval.
+func (ss *SuspensionStore) Start(interval time.Duration) {
+	if err := ss.Load(); err != nil {
+		logrus.Error("loading account suspensions: ", err)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := ss.Load(); err != nil {
+				logrus.Error("reloading account suspensions: ", err)
+			}
+		}
+	}()
+}
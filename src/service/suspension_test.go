@@ -0,0 +1,53 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+)
+
+func TestSuspensionStore(t *testing.T) {
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	var saved []types.Suspension
+	repo := &mocks.SuspensionRepositoryMock{
+		SuspendAccountFn: func(suspension types.Suspension) error {
+			saved = append(saved, suspension)
+			return nil
+		},
+		ReinstateAccountFn: func(username string) error { return nil },
+	}
+	ss := service.NewSuspensionStore(repo)
+	ss.Now = func() time.Time { return now }
+
+	_, err := ss.Suspend(types.SuspensionPostData{Username: "john", Reason: "spam", Until: "2025-03-01T00:00:00Z"}, "admin")
+	assert.ErrorIs(t, err, errDefs.ErrBadRequest, "until in the past")
+	_, err = ss.Suspend(types.SuspensionPostData{Username: "john", Reason: "spam", Until: "tomorrow"}, "admin")
+	assert.ErrorIs(t, err, errDefs.ErrBadRequest)
+	assert.Empty(t, saved)
+
+	suspension, err := ss.Suspend(types.SuspensionPostData{Username: "john", Reason: "spam", Until: "2025-03-08T14:00:00+02:00"}, "admin")
+	require.NoError(t, err)
+	require.NotNil(t, suspension.Until)
+	assert.Equal(t, "2025-03-08T12:00:00Z", *suspension.Until)
+	assert.Equal(t, "admin", suspension.SuspendedBy)
+	assert.ErrorIs(t, ss.Check("john"), errDefs.ErrForbidden)
+	assert.NoError(t, ss.Check("jane"))
+
+	now = now.Add(24 * time.Hour)
+	assert.NoError(t, ss.Check("john"), "suspension has ended")
+
+	_, err = ss.Suspend(types.SuspensionPostData{Username: "john", Reason: "abuse"}, "admin")
+	require.NoError(t, err)
+	now = now.Add(365 * 24 * time.Hour)
+	assert.ErrorIs(t, ss.Check("john"), errDefs.ErrForbidden, "disabled until reinstated")
+
+	require.NoError(t, ss.Reinstate("john"))
+	assert.NoError(t, ss.Check("john"))
+}
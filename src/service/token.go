@@ -0,0 +1,85 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/jwt"
+	"tick_test/utils/random"
+)
+
+const (
+	DefaultAccessTokenLifetime  = 30 * time.Minute
+	DefaultRefreshTokenLifetime = 30 * 24 * time.Hour
+)
+
+// TokenService issues short-lived access tokens together with long-lived
+// refresh tokens. Only a hash of each refresh token is stored.
+type TokenService struct {
+	Repo            repository.AccountRepository
+	AccessLifetime  time.Duration
+	RefreshLifetime time.Duration
+}
+
+func NewTokenService(repo repository.AccountRepository) *TokenService {
+	return &TokenService{
+		Repo:            repo,
+		AccessLifetime:  DefaultAccessTokenLifetime,
+		RefreshLifetime: DefaultRefreshTokenLifetime,
+	}
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// pair issues an access token for the login whose refresh tokens share
+// family, which becomes its session id.
+func (ts *TokenService) pair(username string, role types.Role, refreshToken string, family string) (types.TokenPair, error) {
+	accessToken, err := jwt.GenerateSessionToken(username, role, ts.AccessLifetime, types.SessionId(types.TokenSession, family))
+	if err != nil {
+		return types.TokenPair{}, err
+	}
+	return types.TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresIn:        int64(ts.AccessLifetime.Seconds()),
+		RefreshExpiresIn: int64(ts.RefreshLifetime.Seconds()),
+	}, nil
+}
+
+// Issue starts a new token family for a fresh login from device. clientId
+// is the OAuth2 client logging in, or empty for the account endpoints; only
+// that client can refresh the tokens.
+func (ts *TokenService) Issue(username string, role types.Role, device types.DeviceInfo, clientId string) (types.TokenPair, error) {
+	refreshToken, err := random.Token(32)
+	if err != nil {
+		return types.TokenPair{}, err
+	}
+	family, err := random.Token(16)
+	if err != nil {
+		return types.TokenPair{}, err
+	}
+	if err := ts.Repo.SaveRefreshToken(username, family, clientId, HashToken(refreshToken), device, time.Now().Add(ts.RefreshLifetime)); err != nil {
+		return types.TokenPair{}, err
+	}
+	return ts.pair(username, role, refreshToken, family)
+}
+
+// Refresh exchanges a refresh token issued to clientId for a new access and
+// refresh token.
+func (ts *TokenService) Refresh(refreshToken string, clientId string) (types.TokenPair, error) {
+	next, err := random.Token(32)
+	if err != nil {
+		return types.TokenPair{}, err
+	}
+	rotated, err := ts.Repo.RotateRefreshToken(HashToken(refreshToken), HashToken(next), clientId, time.Now().Add(ts.RefreshLifetime))
+	if err != nil {
+		return types.TokenPair{}, err
+	}
+	return ts.pair(rotated.Username, rotated.Role, next, rotated.Family)
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"tick_test/repository"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/random"
+	"tick_test/utils/totp"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	recoveryCodeCount = 10
+	// totpSkew is how many 30 second steps a code may be off, for clock drift.
+	totpSkew = 1
+)
+
+type twoFactorChallenge struct {
+	Username  string
+	Role      types.Role
+	ExpiresAt time.Time
+	Attempts  int
+}
+
+// TwoFactorService manages TOTP enrollment, verification and recovery codes,
+// and the short-lived challenges between the password and the code step of
+// a login.
+type TwoFactorService struct {
+	Repo                 repository.TwoFactorRepository
+	Issuer               string
+	EnforcedRoles        []types.Role
+	ChallengeLifetime    time.Duration
+	MaxChallengeAttempts int
+	Now                  func() time.Time
+
+	mutex      sync.Mutex
+	challenges map[string]*twoFactorChallenge
+}
+
+func NewTwoFactorService(repo repository.TwoFactorRepository) *TwoFactorService {
+	return &TwoFactorService{
+		Repo:                 repo,
+		Issuer:               "tick_test",
+		ChallengeLifetime:    5 * time.Minute,
+		MaxChallengeAttempts: 5,
+		Now:                  time.Now,
+		challenges:           make(map[string]*twoFactorChallenge),
+	}
+}
+
+// Enroll creates a new secret for username. It is not used until Confirm.
+func (tfs *TwoFactorService) Enroll(username string) (types.TotpEnrollment, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return types.TotpEnrollment{}, err
+	}
+	if err := tfs.Repo.SaveTotpSecret(username, secret); err != nil {
+		return types.TotpEnrollment{}, err
+	}
+	return types.TotpEnrollment{Secret: secret, URI: totp.URI(tfs.Issuer, username, secret)}, nil
+}
+
+// Confirm enables two-factor authentication once the user proves the
+// authenticator app produces valid codes, and returns the recovery codes.
+func (tfs *TwoFactorService) Confirm(username string, code string) ([]string, error) {
+	state, err := tfs.Repo.FindTotp(username)
+	if err != nil {
+		return nil, err
+	}
+	if state.Enabled {
+		return nil, fmt.Errorf("%w: two-factor authentication is already enabled", errDefs.ErrConflict)
+	}
+	counter, ok, err := totp.Verify(state.Secret, code, tfs.Now(), totpSkew)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid code", errDefs.ErrUnauthorized)
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := tfs.Repo.EnableTotp(username, counter, hashes); err != nil {
+		return nil, err
+	}
+	logrus.Info("two-factor authentication enabled for ", username)
+	return codes, nil
+}
+
+func (tfs *TwoFactorService) Enabled(username string) (bool, error) {
+	state, err := tfs.Repo.FindTotp(username)
+	if errors.Is(err, errDefs.ErrEntityNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return state.Enabled, nil
+}
+
+// Required tells whether accounts of role must use two-factor authentication.
+func (tfs *TwoFactorService) Required(role types.Role) bool {
+	for _, enforced := range tfs.EnforcedRoles {
+		if enforced == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Verify accepts a current code from the authenticator app or an unused
+// recovery code. Either works only once.
+func (tfs *TwoFactorService) Verify(username string, code string) error {
+	state, err := tfs.Repo.FindTotp(username)
+	if errors.Is(err, errDefs.ErrEntityNotFound) || (err == nil && !state.Enabled) {
+		return fmt.Errorf("%w: two-factor authentication is not enabled", errDefs.ErrBadRequest)
+	}
+	if err != nil {
+		return err
+	}
+	if !isTotpCode(code) {
+		return tfs.Repo.UseRecoveryCode(username, HashToken(normalizeRecoveryCode(code)))
+	}
+	counter, ok, err := totp.Verify(state.Secret, code, tfs.Now(), totpSkew)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: invalid code", errDefs.ErrUnauthorized)
+	}
+	return tfs.Repo.UseTotpCounter(username, counter)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of username. Callers
+// verify a code of the account first.
+func (tfs *TwoFactorService) RegenerateRecoveryCodes(username string) ([]string, error) {
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := tfs.Repo.ReplaceRecoveryCodes(username, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off for username. Callers verify
+// a code of the account first.
+func (tfs *TwoFactorService) Disable(username string) error {
+	if err := tfs.Repo.DisableTotp(username); err != nil {
+		return err
+	}
+	logrus.Info("two-factor authentication disabled for ", username)
+	return nil
+}
+
+// Challenge starts the second login step for a user whose password has
+// been confirmed.
+func (tfs *TwoFactorService) Challenge(username string, role types.Role) (types.TwoFactorChallenge, error) {
+	token, err := random.Token(32)
+	if err != nil {
+		return types.TwoFactorChallenge{}, err
+	}
+	tfs.mutex.Lock()
+	tfs.challenges[HashToken(token)] = &twoFactorChallenge{
+		Username:  username,
+		Role:      role,
+		ExpiresAt: tfs.Now().Add(tfs.ChallengeLifetime),
+	}
+	tfs.mutex.Unlock()
+	return types.TwoFactorChallenge{
+		MfaRequired: true,
+		MfaToken:    token,
+		ExpiresIn:   int64(tfs.ChallengeLifetime.Seconds()),
+	}, nil
+}
+
+// Pending returns the user a challenge was issued to.
+func (tfs *TwoFactorService) Pending(token string) (string, error) {
+	tfs.mutex.Lock()
+	defer tfs.mutex.Unlock()
+	challenge, err := tfs.findChallenge(HashToken(token))
+	if err != nil {
+		return "", err
+	}
+	return challenge.Username, nil
+}
+
+// CompleteChallenge verifies the code for a challenge. A challenge is
+// dropped once it succeeds, expires or has seen MaxChallengeAttempts wrong
+// codes.
+func (tfs *TwoFactorService) CompleteChallenge(token string, code string) (string, types.Role, error) {
+	key := HashToken(token)
+	tfs.mutex.Lock()
+	challenge, err := tfs.findChallenge(key)
+	tfs.mutex.Unlock()
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := tfs.Verify(challenge.Username, code); err != nil {
+		tfs.mutex.Lock()
+		challenge.Attempts++
+		if challenge.Attempts >= tfs.MaxChallengeAttempts {
+			delete(tfs.challenges, key)
+		}
+		tfs.mutex.Unlock()
+		return "", "", err
+	}
+	tfs.mutex.Lock()
+	delete(tfs.challenges, key)
+	tfs.mutex.Unlock()
+	return challenge.Username, challenge.Role, nil
+}
+
+func (tfs *TwoFactorService) findChallenge(key string) (*twoFactorChallenge, error) {
+	challenge, ok := tfs.challenges[key]
+	if !ok || !tfs.Now().Before(challenge.ExpiresAt) {
+		delete(tfs.challenges, key)
+		return nil, fmt.Errorf("%w: login challenge expired or unknown", errDefs.ErrUnauthorized)
+	}
+	return challenge, nil
+}
+
+// Cleanup drops expired challenges.
+func (tfs *TwoFactorService) Cleanup() {
+	tfs.mutex.Lock()
+	defer tfs.mutex.Unlock()
+	now := tfs.Now()
+	for key, challenge := range tfs.challenges {
+		if !now.Before(challenge.ExpiresAt) {
+			delete(tfs.challenges, key)
+		}
+	}
+}
+
+// Start runs Cleanup every interval in the background.
+func (tfs *TwoFactorService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			tfs.Cleanup()
+		}
+	}()
+}
+
+func isTotpCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, digit := range code {
+		if digit < '0' || digit > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes returns codes formatted like "abcde-fghij" and the hashes
+// to store for them.
+func newRecoveryCodes() (codes []string, hashes []string, err error) {
+	for range recoveryCodeCount {
+		b := make([]byte, 7)
+		if _, err = rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, HashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,138 @@
+package service_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tick_test/go_gin_pages/mocks"
+	"tick_test/service"
+	"tick_test/types"
+	"tick_test/utils/errDefs"
+	"tick_test/utils/totp"
+)
+
+const testTotpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// newTwoFactorRepository keeps the state of a single account in memory.
+func newTwoFactorRepository(state *types.TotpState, recoveryHashes map[string]bool) *mocks.TwoFactorRepositoryMock {
+	return &mocks.TwoFactorRepositoryMock{
+		SaveTotpSecretFn: func(username, secret string) error {
+			if state.Enabled {
+				return errDefs.ErrConflict
+			}
+			state.Secret = secret
+			return nil
+		},
+		FindTotpFn: func(username string) (types.TotpState, error) {
+			if state.Secret == "" {
+				return types.TotpState{}, errDefs.ErrEntityNotFound
+			}
+			return *state, nil
+		},
+		EnableTotpFn: func(username string, counter int64, hashes []string) error {
+			state.Enabled, state.LastCounter = true, counter
+			for _, hash := range hashes {
+				recoveryHashes[hash] = true
+			}
+			return nil
+		},
+		UseTotpCounterFn: func(username string, counter int64) error {
+			if counter <= state.LastCounter {
+				return fmt.Errorf("%w: code has already been used", errDefs.ErrUnauthorized)
+			}
+			state.LastCounter = counter
+			return nil
+		},
+		UseRecoveryCodeFn: func(username, hash string) error {
+			if !recoveryHashes[hash] {
+				return fmt.Errorf("%w: invalid recovery code", errDefs.ErrUnauthorized)
+			}
+			delete(recoveryHashes, hash)
+			return nil
+		},
+		DisableTotpFn: func(username string) error {
+			*state = types.TotpState{}
+			return nil
+		},
+	}
+}
+
+func TestTwoFactorEnrollAndVerify(t *testing.T) {
+	state := &types.TotpState{}
+	recoveryHashes := make(map[string]bool)
+	now := time.Unix(1700000000, 0)
+	tfs := service.NewTwoFactorService(newTwoFactorRepository(state, recoveryHashes))
+	tfs.Now = func() time.Time { return now }
+
+	enrollment, err := tfs.Enroll("john")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/tick_test:john?"))
+	enabled, err := tfs.Enabled("john")
+	require.NoError(t, err)
+	assert.False(t, enabled, "enrollment needs confirming")
+
+	_, err = tfs.Confirm("john", "000000")
+	assert.ErrorIs(t, err, errDefs.ErrUnauthorized)
+
+	code, _ := totp.Code(enrollment.Secret, totp.Counter(now))
+	codes, err := tfs.Confirm("john", code)
+	require.NoError(t, err)
+	assert.Len(t, codes, 10)
+	assert.Len(t, recoveryHashes, 10)
+	enabled, _ = tfs.Enabled("john")
+	assert.True(t, enabled)
+
+	assert.ErrorIs(t, tfs.Verify("john", code), errDefs.ErrUnauthorized, "a code is accepted once")
+	now = now.Add(30 * time.Second)
+	code, _ = totp.Code(enrollment.Secret, totp.Counter(now))
+	assert.NoError(t, tfs.Verify("john", code))
+
+	assert.NoError(t, tfs.Verify("john", strings.ToUpper(codes[0])), "recovery codes ignore case")
+	assert.ErrorIs(t, tfs.Verify("john", codes[0]), errDefs.ErrUnauthorized, "recovery codes are single-use")
+
+	require.NoError(t, tfs.Verify("john", codes[1]))
+	require.NoError(t, tfs.Disable("john"))
+	enabled, _ = tfs.Enabled("john")
+	assert.False(t, enabled)
+}
+
+func TestTwoFactorChallenge(t *testing.T) {
+	state := &types.TotpState{Secret: testTotpSecret, Enabled: true}
+	now := time.Unix(1700000000, 0)
+	tfs := service.NewTwoFactorService(newTwoFactorRepository(state, map[string]bool{}))
+	tfs.Now = func() time.Time { return now }
+	tfs.MaxChallengeAttempts = 2
+
+	challenge, err := tfs.Challenge("john", types.AdminRole)
+	require.NoError(t, err)
+	assert.True(t, challenge.MfaRequired)
+	username, err := tfs.Pending(challenge.MfaToken)
+	require.NoError(t, err)
+	assert.Equal(t, "john", username)
+
+	code, _ := totp.Code(testTotpSecret, totp.Counter(now))
+	username, role, err := tfs.CompleteChallenge(challenge.MfaToken, code)
+	require.NoError(t, err)
+	assert.Equal(t, "john", username)
+	assert.Equal(t, types.AdminRole, role)
+	_, _, err = tfs.CompleteChallenge(challenge.MfaToken, code)
+	assert.ErrorIs(t, err, errDefs.ErrUnauthorized, "a challenge is completed once")
+
+	challenge, _ = tfs.Challenge("john", types.AdminRole)
+	_, _, err = tfs.CompleteChallenge(challenge.MfaToken, "000000")
+	assert.ErrorIs(t, err, errDefs.ErrUnauthorized)
+	_, _, err = tfs.CompleteChallenge(challenge.MfaToken, "000000")
+	assert.ErrorIs(t, err, errDefs.ErrUnauthorized)
+	_, err = tfs.Pending(challenge.MfaToken)
+	assert.ErrorIs(t, err, errDefs.ErrUnauthorized, "dropped after too many attempts")
+
+	challenge, _ = tfs.Challenge("john", types.AdminRole)
+	now = now.Add(tfs.ChallengeLifetime)
+	_, err = tfs.Pending(challenge.MfaToken)
+	assert.ErrorIs(t, err, errDefs.ErrUnauthorized, "expired")
+}
@@ -16,9 +16,10 @@ type Account struct {
 
 type AccountPostData struct {
 	Username     string `json:"username" binding:"gt=4"`
-	Password     string `json:"password" binding:"gt=8"`
+	Password     string `json:"password" binding:"required"`
 	SamePassword string `json:"samePassword"`
 	Role         string `json:"role"`
+	InviteCode   string `json:"inviteCode,omitempty"`
 }
 
 type AccountPatchData struct {
@@ -36,3 +37,8 @@ type AccountGetData struct {
 	Username string `json:"username" binding:"gt=4"`
 	Role     string `json:"role"`
 }
+
+type AccountUnlockPostData struct {
+	Username string `json:"username"`
+	IP       string `json:"ip"`
+}
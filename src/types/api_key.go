@@ -0,0 +1,50 @@
+package types
+
+// Scopes an API key can be granted. Every endpoint that accepts API keys
+// requires one of them; the others refuse API keys.
+const (
+	BooksReadScope    = "books:read"
+	BooksWriteScope   = "books:write"
+	MessagesReadScope = "messages:read"
+	MessagesSendScope = "messages:send"
+	ReviewsWriteScope = "reviews:write"
+	ShelvesReadScope  = "shelves:read"
+	ShelvesWriteScope = "shelves:write"
+	AdminScope        = "admin"
+)
+
+var ApiKeyScopes = []string{
+	BooksReadScope,
+	BooksWriteScope,
+	MessagesReadScope,
+	MessagesSendScope,
+	ReviewsWriteScope,
+	ShelvesReadScope,
+	ShelvesWriteScope,
+	AdminScope,
+}
+
+// ApiKey describes a key without its secret. Prefix is the beginning of the
+// key, shown so that keys can be told apart.
+type ApiKey struct {
+	Id         int64        `json:"id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	Scopes     []string     `json:"scopes"`
+	CreatedAt  ISO8601Date  `json:"createdAt"`
+	ExpiresAt  *ISO8601Date `json:"expiresAt"`
+	LastUsedAt *ISO8601Date `json:"lastUsedAt"`
+}
+
+type ApiKeyPostData struct {
+	Name      string          `json:"name" binding:"required,max=100"`
+	Scopes    []string        `json:"scopes" binding:"required,min=1,dive,required"`
+	ExpiresIn ISO8601Duration `json:"expiresIn"`
+}
+
+// ApiKeyCreated is returned once, when the key is created; the key itself
+// cannot be retrieved later.
+type ApiKeyCreated struct {
+	ApiKey
+	Key string `json:"key"`
+}
@@ -4,4 +4,5 @@ type Book struct {
 	Code   string `json:"code"`
 	Title  string `json:"title"`
 	Author string `json:"author"`
+	Isbn   string `json:"isbn,omitempty"`
 }
@@ -0,0 +1,32 @@
+package types
+
+type DuplicateCandidate struct {
+	Book
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+type DuplicateCluster struct {
+	Books []Book `json:"books"`
+}
+
+// BookMergePostData folds the Sources into Target; reviews, shelf entries and
+// subjects are moved over and the source books are removed.
+type BookMergePostData struct {
+	Target  string   `json:"target" binding:"required"`
+	Sources []string `json:"sources" binding:"required,min=1"`
+}
+
+const (
+	ImportCreated   = "created"
+	ImportDuplicate = "duplicate"
+	ImportInvalid   = "invalid"
+	ImportFailed    = "failed"
+)
+
+type BookImportResult struct {
+	Status     string               `json:"status"`
+	Book       Book                 `json:"book"`
+	Candidates []DuplicateCandidate `json:"candidates,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
@@ -0,0 +1,72 @@
+package types
+
+// OAuth2 grant types accepted by /oauth/token.
+const (
+	PasswordGrant          = "password"
+	ClientCredentialsGrant = "client_credentials"
+	RefreshTokenGrant      = "refresh_token"
+)
+
+// OAuth2 error codes of RFC 6749, section 5.2, plus server_error for
+// failures on our side.
+const (
+	OAuthInvalidRequest       = "invalid_request"
+	OAuthInvalidClient        = "invalid_client"
+	OAuthInvalidGrant         = "invalid_grant"
+	OAuthInvalidScope         = "invalid_scope"
+	OAuthUnsupportedGrantType = "unsupported_grant_type"
+	OAuthServerError          = "server_error"
+)
+
+// OAuthClient describes a confidential client without its secret. Scopes
+// are those of ApiKeyScopes the client may request with the client
+// credentials grant.
+type OAuthClient struct {
+	ClientId  string      `json:"clientId"`
+	Name      string      `json:"name"`
+	Scopes    []string    `json:"scopes"`
+	CreatedAt ISO8601Date `json:"createdAt"`
+}
+
+type OAuthClientPostData struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,required"`
+}
+
+// OAuthClientCreated is returned once, when the client is registered; the
+// secret cannot be retrieved later.
+type OAuthClientCreated struct {
+	OAuthClient
+	ClientSecret string `json:"clientSecret"`
+}
+
+// OAuthToken is the token response of RFC 6749, section 5.1. Lifetimes are
+// in seconds.
+type OAuthToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// OAuthError is a failed token request, answered as the error response of
+// RFC 6749, section 5.2.
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// OAuth returns the pair as an OAuth2 token response.
+func (tp TokenPair) OAuth() OAuthToken {
+	return OAuthToken{
+		AccessToken:  tp.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    tp.ExpiresIn,
+		RefreshToken: tp.RefreshToken,
+	}
+}
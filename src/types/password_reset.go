@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+// PasswordReset is handed to a reset notifier for delivery. Token is the only
+// copy of the plain reset token; IssuedBy is empty when the account holder
+// asked for the reset.
+type PasswordReset struct {
+	Username  string
+	Token     string
+	ExpiresAt time.Time
+	IssuedBy  string
+}
+
+type PasswordResetRequestData struct {
+	Username string `json:"username" binding:"required"`
+}
+
+type PasswordResetConfirmData struct {
+	Token        string `json:"token" binding:"required"`
+	Password     string `json:"password" binding:"required"`
+	SamePassword string `json:"samePassword"`
+}
@@ -0,0 +1,55 @@
+package types
+
+// Permissions that can be granted to roles. The Admin role holds all of them.
+const (
+	BookManagePermission      = "book.manage"
+	SubjectManagePermission   = "subject.manage"
+	BookMergePermission       = "book.merge"
+	ReviewModeratePermission  = "review.moderate"
+	AccountPromotePermission  = "account.promote"
+	AccountUnlockPermission   = "account.unlock"
+	AccountResetPermission    = "account.reset"
+	AccountSuspendPermission  = "account.suspend"
+	AccountDeletePermission   = "account.delete"
+	AccountViewPermission     = "account.view"
+	AccountSessionsPermission = "account.sessions"
+	AccountInvitePermission   = "account.invite"
+	AccountApprovePermission  = "account.approve"
+	RoleManagePermission      = "role.manage"
+)
+
+var Permissions = []string{
+	BookManagePermission,
+	SubjectManagePermission,
+	BookMergePermission,
+	ReviewModeratePermission,
+	AccountPromotePermission,
+	AccountUnlockPermission,
+	AccountResetPermission,
+	AccountSuspendPermission,
+	AccountDeletePermission,
+	AccountViewPermission,
+	AccountSessionsPermission,
+	AccountInvitePermission,
+	AccountApprovePermission,
+	RoleManagePermission,
+}
+
+// BuiltInRoles cannot be deleted; the permissions of AdminRole cannot be
+// changed.
+var BuiltInRoles = []Role{UserRole, BookKeeperRole, AdminRole}
+
+type RoleInfo struct {
+	Name        Role     `json:"name"`
+	Permissions []string `json:"permissions"`
+	BuiltIn     bool     `json:"builtIn"`
+}
+
+type RolePostData struct {
+	Name        string   `json:"name" binding:"required,max=50,alphanum"`
+	Permissions []string `json:"permissions" binding:"dive,required"`
+}
+
+type PermissionPostData struct {
+	Permission string `json:"permission" binding:"required"`
+}
@@ -0,0 +1,52 @@
+package types
+
+// ErasedUsername stands in for the author of messages, reviews and
+// revisions whose account was deleted. It cannot be registered.
+const ErasedUsername = "[deleted]"
+
+// ErasureAction says what happens to one kind of data when the account it
+// belongs to is deleted.
+type ErasureAction string
+
+const (
+	EraseDelete    ErasureAction = "delete"
+	EraseAnonymize ErasureAction = "anonymize"
+	EraseKeep      ErasureAction = "keep"
+)
+
+// ErasurePolicy holds the erasure action for each kind of data other
+// accounts can still see once the account is gone. Everything else is
+// deleted along with the account.
+type ErasurePolicy struct {
+	SentMessages     ErasureAction
+	ReceivedMessages ErasureAction
+	Reviews          ErasureAction
+	Revisions        ErasureAction
+}
+
+// DefaultErasurePolicy keeps conversations, reviews and the edit history
+// readable for everybody else while removing the deleted account's name.
+var DefaultErasurePolicy = ErasurePolicy{
+	SentMessages:     EraseAnonymize,
+	ReceivedMessages: EraseDelete,
+	Reviews:          EraseAnonymize,
+	Revisions:        EraseAnonymize,
+}
+
+// EditedRevision is a book revision as listed in the data export of its
+// editor.
+type EditedRevision struct {
+	Book      string      `json:"book"`
+	Number    int         `json:"number"`
+	CreatedAt ISO8601Date `json:"createdAt"`
+}
+
+// PersonalData is what an account gets back when it exports its data.
+type PersonalData struct {
+	Profile          Profile          `json:"profile"`
+	MessagesSent     []Message        `json:"messagesSent"`
+	MessagesReceived []Message        `json:"messagesReceived"`
+	Reviews          []Review         `json:"reviews"`
+	Shelves          []Shelf          `json:"shelves"`
+	Revisions        []EditedRevision `json:"revisions"`
+}
@@ -0,0 +1,42 @@
+package types
+
+// Profile is what an account tells about itself. Email, Timezone and Locale
+// are private: only the account itself and Admins see them.
+type Profile struct {
+	Username    string       `json:"username"`
+	Role        Role         `json:"role"`
+	DisplayName string       `json:"displayName"`
+	Email       string       `json:"email"`
+	Bio         string       `json:"bio"`
+	HasAvatar   bool         `json:"hasAvatar"`
+	Timezone    string       `json:"timezone"`
+	Locale      string       `json:"locale"`
+	UpdatedAt   *ISO8601Date `json:"updatedAt"`
+}
+
+// PublicProfile is the part of a profile everyone may see.
+type PublicProfile struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"displayName"`
+	Bio         string `json:"bio"`
+	HasAvatar   bool   `json:"hasAvatar"`
+}
+
+func (p Profile) Public() PublicProfile {
+	return PublicProfile{
+		Username:    p.Username,
+		DisplayName: p.DisplayName,
+		Bio:         p.Bio,
+		HasAvatar:   p.HasAvatar,
+	}
+}
+
+// ProfilePatchData changes the fields that are present; an empty string
+// clears a field.
+type ProfilePatchData struct {
+	DisplayName *string `json:"displayName" binding:"omitempty,max=100"`
+	Email       *string `json:"email" binding:"omitempty,max=254"`
+	Bio         *string `json:"bio" binding:"omitempty,max=1000"`
+	Timezone    *string `json:"timezone" binding:"omitempty,max=64"`
+	Locale      *string `json:"locale" binding:"omitempty,max=35"`
+}
@@ -0,0 +1,15 @@
+package types
+
+// Interaction is how strongly a user is tied to a book, derived from the
+// user's shelves and reviews.
+type Interaction struct {
+	Username string
+	Book     string
+	Weight   float64
+}
+
+type Recommendation struct {
+	Book
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
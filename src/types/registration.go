@@ -0,0 +1,41 @@
+package types
+
+// RegistrationMode decides who may register an account: anyone, only
+// holders of an invite code, or anyone but subject to an admin's approval.
+type RegistrationMode string
+
+const (
+	OpenRegistration     RegistrationMode = "open"
+	InviteRegistration   RegistrationMode = "invite-code"
+	ApprovalRegistration RegistrationMode = "approval-required"
+)
+
+// Invite describes an invite code without the code itself. UsedBy and
+// CreatedBy are ErasedUsername once their account is deleted.
+type Invite struct {
+	Id        int64        `json:"id"`
+	Role      Role         `json:"role"`
+	CreatedBy string       `json:"createdBy"`
+	CreatedAt ISO8601Date  `json:"createdAt"`
+	ExpiresAt ISO8601Date  `json:"expiresAt"`
+	UsedBy    *string      `json:"usedBy"`
+	UsedAt    *ISO8601Date `json:"usedAt"`
+}
+
+type InvitePostData struct {
+	Role      string          `json:"role"`
+	ExpiresIn ISO8601Duration `json:"expiresIn"`
+}
+
+// InviteCreated is returned once, when the invite is issued; only a hash of
+// the code is stored.
+type InviteCreated struct {
+	Invite
+	Code string `json:"code"`
+}
+
+// PendingAccount is a registration waiting for an admin's approval.
+type PendingAccount struct {
+	Username    string      `json:"username"`
+	RequestedAt ISO8601Date `json:"requestedAt"`
+}
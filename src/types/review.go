@@ -0,0 +1,32 @@
+package types
+
+type Review struct {
+	Book      string      `json:"book"`
+	Username  string      `json:"username"`
+	Stars     int         `json:"stars"`
+	Content   string      `json:"content"`
+	CreatedAt ISO8601Date `json:"createdAt"`
+	UpdatedAt ISO8601Date `json:"updatedAt"`
+}
+
+type ReviewPostData struct {
+	Stars   int    `json:"stars" binding:"required,min=1,max=5"`
+	Content string `json:"content"`
+}
+
+type ReviewPatchData struct {
+	Stars   *int    `json:"stars" binding:"omitempty,min=1,max=5"`
+	Content *string `json:"content"`
+}
+
+type BookRating struct {
+	Code          string  `json:"code"`
+	AverageRating float64 `json:"averageRating"`
+	RatingCount   int     `json:"ratingCount"`
+}
+
+type RatedBook struct {
+	Book
+	AverageRating float64 `json:"averageRating"`
+	RatingCount   int     `json:"ratingCount"`
+}
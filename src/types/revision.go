@@ -0,0 +1,23 @@
+package types
+
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// BookRevision is one recorded change to a book. Reverts are revisions too
+// and name the revision they restored in RevertedTo.
+type BookRevision struct {
+	Number     int           `json:"number"`
+	Editor     string        `json:"editor"`
+	CreatedAt  ISO8601Date   `json:"createdAt"`
+	RevertedTo *int          `json:"revertedTo,omitempty"`
+	Changes    []FieldChange `json:"changes"`
+}
+
+// BookRevertPostData restores a book to how it was right after Revision.
+// Revision 0 is the book as it was before its first recorded change.
+type BookRevertPostData struct {
+	Revision *int `json:"revision" binding:"required,min=0"`
+}
@@ -0,0 +1,18 @@
+package types
+
+import "time"
+
+type RevokedToken struct {
+	Jti       string
+	ExpiresAt time.Time
+}
+
+// TokenCutoff revokes every token of Username issued before RevokedBefore.
+type TokenCutoff struct {
+	Username      string
+	RevokedBefore time.Time
+}
+
+type LogoutPostData struct {
+	RefreshToken string `json:"refreshToken"`
+}
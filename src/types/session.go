@@ -0,0 +1,62 @@
+package types
+
+import "strings"
+
+type AuthMode string
+
+const (
+	JwtAuthMode     AuthMode = "jwt"
+	SessionAuthMode AuthMode = "session"
+)
+
+// DeviceInfo describes the client a session was opened from.
+type DeviceInfo struct {
+	UserAgent string `json:"userAgent"`
+	IP        string `json:"ip"`
+}
+
+// SessionKind tells server-side sessions from the refresh token families of
+// JWT logins, which are listed and revoked the same way.
+type SessionKind string
+
+const (
+	ServerSession SessionKind = "session"
+	TokenSession  SessionKind = "token"
+)
+
+// SessionId builds the public id of a session from its kind and the key it
+// is stored under: the row id of a server-side session or the family of a
+// refresh token.
+func SessionId(kind SessionKind, key string) string {
+	return string(kind) + "-" + key
+}
+
+func ParseSessionId(id string) (kind SessionKind, key string, ok bool) {
+	prefix, key, ok := strings.Cut(id, "-")
+	kind = SessionKind(prefix)
+	if !ok || key == "" || kind != ServerSession && kind != TokenSession {
+		return "", "", false
+	}
+	return kind, key, true
+}
+
+// Session is an active login of an account. Current marks the one the
+// request listing it was made with.
+type Session struct {
+	Id         string      `json:"id"`
+	Kind       SessionKind `json:"kind"`
+	Device     DeviceInfo  `json:"device"`
+	CreatedAt  ISO8601Date `json:"createdAt"`
+	LastUsedAt ISO8601Date `json:"lastUsedAt"`
+	ExpiresAt  ISO8601Date `json:"expiresAt"`
+	Current    bool        `json:"current"`
+}
+
+// LoginEvent is one attempt to log in, successful or not. Reason says why
+// a failed attempt was refused.
+type LoginEvent struct {
+	Succeeded bool        `json:"succeeded"`
+	Reason    string      `json:"reason,omitempty"`
+	Device    DeviceInfo  `json:"device"`
+	CreatedAt ISO8601Date `json:"createdAt"`
+}
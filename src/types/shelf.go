@@ -0,0 +1,72 @@
+package types
+
+type ShelfVisibility string
+
+const (
+	PrivateShelf ShelfVisibility = "private"
+	SharedShelf  ShelfVisibility = "shared"
+	PublicShelf  ShelfVisibility = "public"
+)
+
+type ShelfKind string
+
+const (
+	ToReadShelf   ShelfKind = "to-read"
+	ReadingShelf  ShelfKind = "reading"
+	FinishedShelf ShelfKind = "finished"
+	CustomShelf   ShelfKind = "custom"
+)
+
+// DefaultShelves are created for every account the first time its shelves are listed.
+var DefaultShelves = map[ShelfKind]string{
+	ToReadShelf:   "To read",
+	ReadingShelf:  "Reading",
+	FinishedShelf: "Finished",
+}
+
+type Shelf struct {
+	Id         int64           `json:"id"`
+	Owner      string          `json:"owner"`
+	Name       string          `json:"name"`
+	Kind       ShelfKind       `json:"kind"`
+	Visibility ShelfVisibility `json:"visibility"`
+	SharedWith []string        `json:"sharedWith"`
+	CreatedAt  ISO8601Date     `json:"createdAt"`
+	Items      []ShelfItem     `json:"items"`
+}
+
+type ShelfItem struct {
+	Book     string      `json:"book"`
+	Title    string      `json:"title"`
+	Author   string      `json:"author"`
+	Position int         `json:"position"`
+	Note     string      `json:"note"`
+	AddedAt  ISO8601Date `json:"addedAt"`
+}
+
+type ShelfPostData struct {
+	Name       string          `json:"name" binding:"required"`
+	Visibility ShelfVisibility `json:"visibility"`
+	SharedWith []string        `json:"sharedWith"`
+}
+
+type ShelfPatchData struct {
+	Name       *string          `json:"name"`
+	Visibility *ShelfVisibility `json:"visibility"`
+	SharedWith *[]string        `json:"sharedWith"`
+}
+
+type ShelfItemPostData struct {
+	Book     string `json:"book" binding:"required"`
+	Note     string `json:"note"`
+	Position *int   `json:"position"`
+}
+
+type ShelfItemPatchData struct {
+	Note     *string `json:"note"`
+	Position *int    `json:"position"`
+}
+
+type ShelfOrderData struct {
+	Books []string `json:"books" binding:"required"`
+}
@@ -0,0 +1,25 @@
+package types
+
+type Subject struct {
+	Id       int64      `json:"id"`
+	Name     string     `json:"name"`
+	ParentId *int64     `json:"parentId"`
+	Path     string     `json:"path"`
+	Children []*Subject `json:"children,omitempty"`
+}
+
+type SubjectPostData struct {
+	Name     string `json:"name" binding:"required"`
+	ParentId *int64 `json:"parentId"`
+}
+
+// SubjectPatchData renames and/or moves a subject. A ParentId of 0 moves the
+// subject to the top level.
+type SubjectPatchData struct {
+	Name     *string `json:"name"`
+	ParentId *int64  `json:"parentId"`
+}
+
+type BookSubjectPostData struct {
+	SubjectId int64 `json:"subjectId" binding:"required"`
+}
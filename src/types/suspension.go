@@ -0,0 +1,23 @@
+package types
+
+// Suspension keeps an account from logging in or using its tokens. Until is
+// nil for accounts that stay disabled until an Admin re-enables them.
+type Suspension struct {
+	Username    string       `json:"username"`
+	Reason      string       `json:"reason"`
+	SuspendedBy string       `json:"suspendedBy"`
+	SuspendedAt ISO8601Date  `json:"suspendedAt"`
+	Until       *ISO8601Date `json:"until"`
+}
+
+// SuspensionPostData suspends an account; Until is an RFC3339 date time and
+// may be left out to disable the account indefinitely.
+type SuspensionPostData struct {
+	Username string `json:"username" binding:"required"`
+	Reason   string `json:"reason" binding:"required,max=500"`
+	Until    string `json:"until"`
+}
+
+type AccountTargetPostData struct {
+	Username string `json:"username" binding:"required"`
+}
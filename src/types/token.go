@@ -0,0 +1,14 @@
+package types
+
+// TokenPair is returned on login and refresh. Lifetimes are in seconds. In
+// session mode only the access token, an opaque session token, is set.
+type TokenPair struct {
+	AccessToken      string `json:"accessToken"`
+	RefreshToken     string `json:"refreshToken,omitempty"`
+	ExpiresIn        int64  `json:"expiresIn"`
+	RefreshExpiresIn int64  `json:"refreshExpiresIn,omitempty"`
+}
+
+type RefreshTokenPostData struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
@@ -0,0 +1,39 @@
+package types
+
+// TotpEnrollment is returned when two-factor authentication is set up. The
+// URI is meant to be shown as a QR code to an authenticator app.
+type TotpEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TotpState is the two-factor setup of an account. LastCounter is the time
+// step of the last accepted code, which may not be used again.
+type TotpState struct {
+	Secret      string
+	Enabled     bool
+	LastCounter int64
+}
+
+// TotpCodePostData carries either a code from the authenticator app or one of
+// the recovery codes.
+type TotpCodePostData struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// TwoFactorChallenge is returned by the first login step of accounts with
+// two-factor authentication; ExpiresIn is in seconds.
+type TwoFactorChallenge struct {
+	MfaRequired bool   `json:"mfaRequired"`
+	MfaToken    string `json:"mfaToken"`
+	ExpiresIn   int64  `json:"expiresIn"`
+}
+
+type TwoFactorLoginPostData struct {
+	MfaToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
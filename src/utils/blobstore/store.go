@@ -0,0 +1,92 @@
+package blobstore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("blob not found")
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Store keeps binary objects addressed by slash separated keys.
+type Store interface {
+	Put(key string, data []byte) error
+	Get(key string) (data []byte, modTime time.Time, err error)
+	Delete(key string) error
+}
+
+type LocalStore struct {
+	Dir string
+}
+
+func NewLocalStore(dir string) *LocalStore {
+	return &LocalStore{Dir: dir}
+}
+
+func (ls *LocalStore) path(key string) (string, error) {
+	if key == "" || strings.Contains(key, "\\") || strings.Contains(key, "\x00") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return filepath.Join(ls.Dir, filepath.FromSlash(key)), nil
+}
+
+func (ls *LocalStore) Put(key string, data []byte) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so readers never see partial content
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (ls *LocalStore) Get(key string) (data []byte, modTime time.Time, err error) {
+	path, err := ls.path(key)
+	if err != nil {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = fmt.Errorf("%w: %q", ErrNotFound, key)
+		}
+		return
+	}
+	data, err = os.ReadFile(path)
+	return data, info.ModTime(), err
+}
+
+func (ls *LocalStore) Delete(key string) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
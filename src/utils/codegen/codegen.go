@@ -0,0 +1,196 @@
+package codegen
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Kind string
+
+const (
+	BookCodes        Kind = "book"
+	ManipulatorCodes Kind = "manipulator"
+	SortCodes        Kind = "sort"
+)
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+const crockfordCheckSymbols = crockfordAlphabet + "*~$=U"
+
+var ErrUnknownStrategy = errors.New("unknown code strategy")
+var ErrInvalidCode = errors.New("invalid code")
+
+type Generator interface {
+	Generate() (string, error)
+}
+
+// Crockford produces Length random Crockford Base32 symbols, optionally
+// followed by a mod 37 check symbol.
+type Crockford struct {
+	Length     int
+	CheckDigit bool
+}
+
+func (cg *Crockford) Generate() (string, error) {
+	buf := make([]byte, cg.Length)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	var checksum int
+	for _, b := range buf {
+		digit := int(b & 31)
+		checksum = (checksum*32 + digit) % 37
+		sb.WriteByte(crockfordAlphabet[digit])
+	}
+	if cg.CheckDigit {
+		sb.WriteByte(crockfordCheckSymbols[checksum])
+	}
+	return sb.String(), nil
+}
+
+// NormalizeCrockford maps a hand typed code to its canonical form: upper case,
+// without hyphens, with the commonly confused letters I, L and O replaced.
+func NormalizeCrockford(code string) string {
+	code = strings.ToUpper(strings.ReplaceAll(code, "-", ""))
+	return strings.NewReplacer("I", "1", "L", "1", "O", "0").Replace(code)
+}
+
+// ValidateCrockford verifies the trailing check symbol of a code produced
+// by a Crockford generator with CheckDigit enabled.
+func ValidateCrockford(code string) error {
+	code = NormalizeCrockford(code)
+	if len(code) < 2 {
+		return fmt.Errorf("%w: %q is too short", ErrInvalidCode, code)
+	}
+	var checksum int
+	for _, r := range code[:len(code)-1] {
+		digit := strings.IndexRune(crockfordAlphabet, r)
+		if digit < 0 {
+			return fmt.Errorf("%w: %q contains invalid symbol %q", ErrInvalidCode, code, r)
+		}
+		checksum = (checksum*32 + digit) % 37
+	}
+	if crockfordCheckSymbols[checksum] != code[len(code)-1] {
+		return fmt.Errorf("%w: check symbol of %q does not match", ErrInvalidCode, code)
+	}
+	return nil
+}
+
+// ULID produces 26 symbol lexicographically sortable identifiers made of a
+// 48 bit millisecond timestamp and 80 random bits.
+type ULID struct {
+	Now func() time.Time
+}
+
+func (ug *ULID) Generate() (string, error) {
+	now := time.Now
+	if ug.Now != nil {
+		now = ug.Now
+	}
+
+	var id [16]byte
+	ms := uint64(now().UnixMilli())
+	for i := range 6 {
+		id[i] = byte(ms >> (40 - 8*i))
+	}
+	if _, err := rand.Read(id[6:]); err != nil {
+		return "", err
+	}
+
+	value := new(big.Int).SetBytes(id[:])
+	out := make([]byte, 26)
+	mask := big.NewInt(31)
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockfordAlphabet[new(big.Int).And(value, mask).Int64()]
+		value.Rsh(value, 5)
+	}
+	return string(out), nil
+}
+
+type SequenceSource func() (uint64, error)
+
+// Sequential produces Prefix followed by a zero padded counter. Without an
+// attached Source the counter lives in memory and restarts with the process.
+type Sequential struct {
+	Prefix string
+	Width  int
+	Source SequenceSource
+	next   atomic.Uint64
+}
+
+func (sg *Sequential) Generate() (string, error) {
+	var n uint64
+	if sg.Source != nil {
+		var err error
+		if n, err = sg.Source(); err != nil {
+			return "", err
+		}
+	} else {
+		n = sg.next.Add(1)
+	}
+	return fmt.Sprintf("%s%0*d", sg.Prefix, sg.Width, n), nil
+}
+
+func New(strategy string, length int, prefix string) (Generator, error) {
+	switch strategy {
+	case "crockford", "":
+		if length == 0 {
+			length = 10
+		}
+		if length < 4 || length > 64 {
+			return nil, fmt.Errorf("crockford code length needs to be between 4 and 64 but it is %v", length)
+		}
+		return &Crockford{Length: length, CheckDigit: true}, nil
+	case "ulid":
+		return &ULID{}, nil
+	case "sequential":
+		if length < 0 || length > 20 {
+			return nil, fmt.Errorf("sequential code length needs to be between 0 and 20 but it is %v", length)
+		}
+		return &Sequential{Prefix: prefix, Width: length}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStrategy, strategy)
+	}
+}
+
+var (
+	generators = map[Kind]Generator{
+		BookCodes:        &Crockford{Length: 10, CheckDigit: true},
+		ManipulatorCodes: &Crockford{Length: 10, CheckDigit: true},
+		SortCodes:        &ULID{},
+	}
+	generatorsMutex sync.RWMutex
+)
+
+func Set(kind Kind, generator Generator) {
+	generatorsMutex.Lock()
+	defer generatorsMutex.Unlock()
+	generators[kind] = generator
+}
+
+// UseSequence attaches a persistent counter to kind if it is configured
+// with the sequential strategy.
+func UseSequence(kind Kind, source SequenceSource) {
+	generatorsMutex.RLock()
+	defer generatorsMutex.RUnlock()
+	if sequential, ok := generators[kind].(*Sequential); ok {
+		sequential.Source = source
+	}
+}
+
+func Generate(kind Kind) (string, error) {
+	generatorsMutex.RLock()
+	generator, ok := generators[kind]
+	generatorsMutex.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("no code generator registered for %q", kind)
+	}
+	return generator.Generate()
+}
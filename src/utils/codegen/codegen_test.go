@@ -0,0 +1,66 @@
+package codegen_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tick_test/utils/codegen"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrockfordCheckDigit(t *testing.T) {
+	gen := &codegen.Crockford{Length: 12, CheckDigit: true}
+	for range 100 {
+		code, err := gen.Generate()
+		require.NoError(t, err)
+		require.Len(t, code, 13)
+		require.NoError(t, codegen.ValidateCrockford(code))
+		require.NoError(t, codegen.ValidateCrockford(strings.ToLower(code[:4])+"-"+code[4:]), "hyphenated lower case %q", code)
+	}
+}
+
+func TestCrockfordDetectsTypo(t *testing.T) {
+	code := "0123456789ABC"
+	code += string("0123456789ABCDEFGHJKMNPQRSTVWXYZ*~$=U"[checksum(code)])
+	require.NoError(t, codegen.ValidateCrockford(code))
+	typo := "0123456789ABD" + code[len(code)-1:]
+	assert.Error(t, codegen.ValidateCrockford(typo))
+}
+
+func checksum(code string) int {
+	var sum int
+	for _, r := range code {
+		sum = (sum*32 + strings.IndexRune("0123456789ABCDEFGHJKMNPQRSTVWXYZ", r)) % 37
+	}
+	return sum
+}
+
+func TestULIDIsSortable(t *testing.T) {
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	gen := &codegen.ULID{Now: func() time.Time { return now }}
+	first, err := gen.Generate()
+	require.NoError(t, err)
+	now = now.Add(time.Millisecond)
+	second, _ := gen.Generate()
+
+	require.Len(t, first, 26)
+	require.Len(t, second, 26)
+	assert.Less(t, first[:10], second[:10], "timestamp part sorts first")
+}
+
+func TestSequential(t *testing.T) {
+	gen, err := codegen.New("sequential", 4, "BK-")
+	require.NoError(t, err)
+	first, _ := gen.Generate()
+	second, _ := gen.Generate()
+	assert.Equal(t, "BK-0001", first)
+	assert.Equal(t, "BK-0002", second)
+}
+
+func TestNewUnknownStrategy(t *testing.T) {
+	_, err := codegen.New("uuid", 0, "")
+	assert.Error(t, err)
+}
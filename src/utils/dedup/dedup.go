@@ -0,0 +1,226 @@
+// Package dedup finds likely duplicate books by fuzzy comparison of their
+// normalized title and author and by exact ISBN.
+package dedup
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+var ErrInvalidISBN = errors.New("invalid ISBN")
+
+// Threshold is the minimum similarity of both title and author for two
+// entries to count as duplicates.
+const Threshold = 0.85
+
+type Entry struct {
+	Key    string
+	Title  string
+	Author string
+	ISBN   string
+}
+
+type Match struct {
+	Key    string
+	Score  float64
+	Reason string
+}
+
+// Normalize folds case and diacritics, drops punctuation and collapses
+// whitespace so that "Les Misérables" and "les miserables." compare equal.
+func Normalize(s string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), s)
+	if err != nil {
+		folded = s
+	}
+	fields := strings.FieldsFunc(strings.ToLower(folded), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// Distance is the Levenshtein edit distance between a and b in runes.
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// Similarity maps the edit distance of two normalized strings onto 0..1.
+func Similarity(a, b string) float64 {
+	longest := max(len([]rune(a)), len([]rune(b)))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(Distance(a, b))/float64(longest)
+}
+
+// NormalizeISBN strips separators, validates the check digit and returns the
+// ISBN-13 form. An empty input yields an empty result.
+func NormalizeISBN(isbn string) (string, error) {
+	digits := strings.ToUpper(strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, isbn))
+
+	switch len(digits) {
+	case 0:
+		return "", nil
+	case 10:
+		sum := 0
+		for i, r := range digits {
+			value := int(r - '0')
+			if r == 'X' && i == 9 {
+				value = 10
+			} else if r < '0' || r > '9' {
+				return "", ErrInvalidISBN
+			}
+			sum += (10 - i) * value
+		}
+		if sum%11 != 0 {
+			return "", ErrInvalidISBN
+		}
+		body := "978" + digits[:9]
+		return body + string(rune('0'+isbn13Check(body))), nil
+	case 13:
+		for _, r := range digits {
+			if r < '0' || r > '9' {
+				return "", ErrInvalidISBN
+			}
+		}
+		if isbn13Check(digits[:12]) != int(digits[12]-'0') {
+			return "", ErrInvalidISBN
+		}
+		return digits, nil
+	default:
+		return "", ErrInvalidISBN
+	}
+}
+
+func isbn13Check(body string) int {
+	sum := 0
+	for i, r := range body {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(r-'0')
+	}
+	return (10 - sum%10) % 10
+}
+
+// Compare reports whether a and b look like the same book. Entries are
+// expected to carry normalized titles, authors and ISBNs.
+func Compare(a, b Entry) (match Match, ok bool) {
+	if a.ISBN != "" && a.ISBN == b.ISBN {
+		return Match{Key: b.Key, Score: 1, Reason: "same ISBN"}, true
+	}
+	if a.ISBN != "" && b.ISBN != "" {
+		return Match{}, false
+	}
+	title := Similarity(a.Title, b.Title)
+	author := Similarity(a.Author, b.Author)
+	if title < Threshold || author < Threshold {
+		return Match{}, false
+	}
+	return Match{Key: b.Key, Score: (title + author) / 2, Reason: "similar title and author"}, true
+}
+
+// Prepare normalizes the fields of an entry for Compare.
+func Prepare(entry Entry) Entry {
+	entry.Title = Normalize(entry.Title)
+	entry.Author = Normalize(entry.Author)
+	entry.ISBN, _ = NormalizeISBN(entry.ISBN)
+	return entry
+}
+
+// Candidates returns the entries in pool that match entry, best first.
+func Candidates(entry Entry, pool []Entry) []Match {
+	entry = Prepare(entry)
+	matches := make([]Match, 0)
+	for _, other := range pool {
+		if other.Key == entry.Key {
+			continue
+		}
+		if match, ok := Compare(entry, Prepare(other)); ok {
+			matches = append(matches, match)
+		}
+	}
+	sortMatches(matches)
+	return matches
+}
+
+// Clusters groups the keys of all entries that are transitively duplicates
+// of each other. Entries without duplicates are left out.
+func Clusters(entries []Entry) [][]string {
+	prepared := make([]Entry, len(entries))
+	for i, entry := range entries {
+		prepared[i] = Prepare(entry)
+	}
+
+	parent := make([]int, len(prepared))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range prepared {
+		for j := i + 1; j < len(prepared); j++ {
+			if _, ok := Compare(prepared[i], prepared[j]); ok {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := make(map[int][]string)
+	order := make([]int, 0)
+	for i, entry := range prepared {
+		root := find(i)
+		if _, seen := groups[root]; !seen {
+			order = append(order, root)
+		}
+		groups[root] = append(groups[root], entry.Key)
+	}
+	clusters := make([][]string, 0)
+	for _, root := range order {
+		if len(groups[root]) > 1 {
+			clusters = append(clusters, groups[root])
+		}
+	}
+	return clusters
+}
+
+func sortMatches(matches []Match) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+}
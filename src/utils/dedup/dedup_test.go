@@ -0,0 +1,65 @@
+package dedup_test
+
+import (
+	"testing"
+
+	"tick_test/utils/dedup"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "les miserables tome i", dedup.Normalize("  Les Misérables:  TOME I. "))
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 3, dedup.Distance("kitten", "sitting"))
+	assert.Equal(t, 3, dedup.Distance("", "abc"))
+}
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := map[string]string{
+		"0-306-40615-2":     "9780306406157",
+		"978-0-306-40615-7": "9780306406157",
+		"0 8044 2957 x":     "9780804429573",
+		"":                  "",
+	}
+	for input, want := range tests {
+		got, err := dedup.NormalizeISBN(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+	for _, input := range []string{"0-306-40615-3", "978-0-306-40615-8", "12345"} {
+		_, err := dedup.NormalizeISBN(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	pool := []dedup.Entry{
+		{Key: "A", Title: "The Hobbit", Author: "J. R. R. Tolkien"},
+		{Key: "B", Title: "The Silmarillion", Author: "J. R. R. Tolkien"},
+		{Key: "C", Title: "Something else", Author: "Anyone", ISBN: "9780306406157"},
+	}
+
+	matches := dedup.Candidates(dedup.Entry{Title: "the hobit", Author: "J.R.R. Tolkien"}, pool)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "A", matches[0].Key)
+
+	matches = dedup.Candidates(dedup.Entry{Title: "Other title", Author: "Other author", ISBN: "0-306-40615-2"}, pool)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "C", matches[0].Key)
+	assert.Equal(t, "same ISBN", matches[0].Reason)
+}
+
+func TestClusters(t *testing.T) {
+	clusters := dedup.Clusters([]dedup.Entry{
+		{Key: "A", Title: "Dune", Author: "Frank Herbert"},
+		{Key: "B", Title: "Emma", Author: "Jane Austen"},
+		{Key: "C", Title: "DUNE", Author: "Frank  Herbert"},
+		{Key: "D", Title: "Dune.", Author: "Herbert, Frank", ISBN: "9780441013593"},
+		{Key: "E", Title: "Dune Messiah", Author: "Frank Herbert", ISBN: "9780441013593"},
+	})
+	assert.Equal(t, [][]string{{"A", "C"}, {"D", "E"}}, clusters)
+}
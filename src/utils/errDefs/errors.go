@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 var ErrInternalServerError = errors.New("internal server error")
@@ -14,6 +15,9 @@ var ErrBadRequest = errors.New("bad request")
 var ErrEntityNotFound = errors.New("entity not found")
 var ErrMissingField = fmt.Errorf("%w: field missing", ErrBadRequest)
 var ErrUnauthorized = errors.New("unauthorized")
+var ErrForbidden = errors.New("forbidden")
+var ErrPayloadTooLarge = errors.New("payload too large")
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
 
 func DetermineStatus(err error) (status int) {
 	switch {
@@ -25,7 +29,47 @@ func DetermineStatus(err error) (status int) {
 		return http.StatusNotFound
 	case errors.Is(err, ErrUnauthorized):
 		return http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrPayloadTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrTooManyRequests):
+		return http.StatusTooManyRequests
 	default:
 		return http.StatusInternalServerError
 	}
 }
+
+// ViolationsError is a bad request that lists every rule the input broke,
+// so the client can fix them all at once.
+type ViolationsError struct {
+	Message    string
+	Violations []string
+}
+
+func (e *ViolationsError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrBadRequest, e.Message)
+}
+
+func (e *ViolationsError) Unwrap() error {
+	return ErrBadRequest
+}
+
+var ErrTooManyRequests = errors.New("too many requests")
+
+// RetryAfterError is a too many requests error that tells the client when
+// the request may be repeated.
+type RetryAfterError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrTooManyRequests, e.Message)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return ErrTooManyRequests
+}
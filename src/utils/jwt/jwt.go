@@ -1,7 +1,10 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -10,11 +13,47 @@ import (
 )
 
 type Claims struct {
-	Username string
-	Role     types.Role
+	Username  string
+	Role      types.Role
+	Jti       string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	// Scopes limit what the request may do when it is authenticated with an
+	// API key or a client credentials token; they are empty for logins,
+	// which may do everything.
+	Scopes []string
+	// SessionId names the login the token belongs to, so that it can be
+	// revoked together with the other tokens of that login.
+	SessionId string
 }
 
+// RevocationChecker returns an error if the token described by claims was revoked.
+type RevocationChecker func(claims Claims) error
+
 var secretKey []byte
+var revocationChecker RevocationChecker
+
+func SetRevocationChecker(checker RevocationChecker) {
+	revocationChecker = checker
+}
+
+func newJti() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// numericDate converts a NumericDate claim, which may carry fractions of a
+// second, to a time.
+func numericDate(value interface{}) time.Time {
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(seconds * 1000))
+}
 
 func SetSecretKey(key []byte) {
 	secretKey = key
@@ -25,6 +64,29 @@ func GetSecretKey() []byte {
 }
 
 func GenerateToken(username string, role types.Role, duration time.Duration) (string, error) {
+	return GenerateSessionToken(username, role, duration, "")
+}
+
+// GenerateSessionToken is GenerateToken for a token that carries the id of
+// the login it was issued for in its sid claim.
+func GenerateSessionToken(username string, role types.Role, duration time.Duration, sessionId string) (string, error) {
+	extra := jwt.MapClaims{}
+	if sessionId != "" {
+		extra["sid"] = sessionId
+	}
+	return generateToken(username, role, duration, extra)
+}
+
+// GenerateScopedToken is GenerateToken for a token limited to scopes, which
+// it carries space separated in its scope claim as in RFC 6749.
+func GenerateScopedToken(username string, role types.Role, duration time.Duration, scopes []string) (string, error) {
+	if len(scopes) == 0 {
+		return "", errors.New("scopes cannot be empty")
+	}
+	return generateToken(username, role, duration, jwt.MapClaims{"scope": strings.Join(scopes, " ")})
+}
+
+func generateToken(username string, role types.Role, duration time.Duration, extra jwt.MapClaims) (string, error) {
 	if username == "" {
 		return "", errors.New("username cannot be empty")
 	}
@@ -33,19 +95,40 @@ func GenerateToken(username string, role types.Role, duration time.Duration) (st
 		return "", errors.New("role cannot be empty")
 	}
 
-	if secretKey == nil {
+	signingKey, asymmetric := SigningKey{}, false
+	if kr := GetKeyring(); kr != nil {
+		signingKey, asymmetric = kr.Active()
+	}
+	if !asymmetric && secretKey == nil {
 		return "", errors.New("secret key is not set")
 	}
 
+	jti, err := newJti()
+	if err != nil {
+		return "", err
+	}
+
+	// iat keeps milliseconds so that tokens issued right after a
+	// "revoke everything before now" are not caught by it
 	now := time.Now()
 	claims := jwt.MapClaims{
 		"username": username,
 		"role":     role,
 		"exp":      now.Add(duration).Unix(),
-		"iat":      now.Unix(),
+		"iat":      float64(now.UnixMilli()) / 1000,
+		"jti":      jti,
 		"issuer":   "tick_test",
 		"subject":  username,
 	}
+	for name, value := range extra {
+		claims[name] = value
+	}
+
+	if asymmetric {
+		token := jwt.NewWithClaims(signingKey.method(), claims)
+		token.Header["kid"] = signingKey.Id
+		return token.SignedString(signingKey.Private)
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
@@ -57,16 +140,35 @@ func GenerateToken(username string, role types.Role, duration time.Duration) (st
 	return signedToken, nil
 }
 
-func ValidateToken(tokenString string) (Claims, error) {
-	var claims Claims
-
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Validate the algorithm is what we expect
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+// keyFunc picks the key a token is verified with: the keyring key named by
+// its kid header for RS256 and EdDSA, or the shared secret for HS256.
+func keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(secretKey) == 0 {
 			return nil, errors.New("invalid token signing method")
 		}
 		return secretKey, nil
-	})
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodEd25519:
+		kid, _ := token.Header["kid"].(string)
+		kr := GetKeyring()
+		if kr == nil || kid == "" {
+			return nil, errors.New("invalid token signing key")
+		}
+		key, ok := kr.find(kid)
+		if !ok || key.method().Alg() != token.Method.Alg() {
+			return nil, errors.New("invalid token signing key")
+		}
+		return key.Private.Public(), nil
+	default:
+		return nil, errors.New("invalid token signing method")
+	}
+}
+
+func ValidateToken(tokenString string) (Claims, error) {
+	var claims Claims
+
+	token, err := jwt.Parse(tokenString, keyFunc)
 
 	if err != nil {
 		return claims, err
@@ -93,17 +195,25 @@ func ValidateToken(tokenString string) (Claims, error) {
 
 	claims.Username = username
 	claims.Role = types.Role(role)
+	claims.Jti, _ = mapClaims["jti"].(string)
+	claims.IssuedAt = numericDate(mapClaims["iat"])
+	claims.ExpiresAt = numericDate(mapClaims["exp"])
+	claims.SessionId, _ = mapClaims["sid"].(string)
+	if scope, _ := mapClaims["scope"].(string); scope != "" {
+		claims.Scopes = strings.Fields(scope)
+	}
+
+	if revocationChecker != nil {
+		if err := revocationChecker(claims); err != nil {
+			return Claims{}, err
+		}
+	}
 
 	return claims, nil
 }
 
 func GetUserFromToken(tokenString string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid token signing method")
-		}
-		return secretKey, nil
-	})
+	token, err := jwt.Parse(tokenString, keyFunc)
 
 	if err != nil {
 		return "", err
@@ -127,12 +237,7 @@ func GetUserFromToken(tokenString string) (string, error) {
 }
 
 func GetRoleFromToken(tokenString string) (types.Role, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid token signing method")
-		}
-		return secretKey, nil
-	})
+	token, err := jwt.Parse(tokenString, keyFunc)
 
 	if err != nil {
 		return "", err
@@ -219,6 +219,29 @@ func TestValidateToken_Success(t *testing.T) {
 	}
 }
 
+func TestGenerateScopedToken(t *testing.T) {
+	originalKey := jwtpkg.GetSecretKey()
+	defer jwtpkg.SetSecretKey(originalKey)
+
+	jwtpkg.SetSecretKey([]byte(testSecret))
+
+	tokenString, err := jwtpkg.GenerateScopedToken(testUsername, testRole, time.Hour, []string{"books:read", "shelves:read"})
+	if err != nil {
+		t.Fatalf("GenerateScopedToken() error = %v, expected no error", err)
+	}
+	claims, err := jwtpkg.ValidateToken(tokenString)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v, expected no error", err)
+	}
+	if strings.Join(claims.Scopes, " ") != "books:read shelves:read" {
+		t.Errorf("Scopes = %v, want [books:read shelves:read]", claims.Scopes)
+	}
+
+	if _, err := jwtpkg.GenerateScopedToken(testUsername, testRole, time.Hour, nil); err == nil {
+		t.Errorf("Expected error for a token without scopes, got no error")
+	}
+}
+
 func TestValidateToken_Failures(t *testing.T) {
 	originalKey := jwtpkg.GetSecretKey()
 	defer jwtpkg.SetSecretKey(originalKey)
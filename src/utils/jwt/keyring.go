@@ -0,0 +1,180 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	RS256Algorithm = "RS256"
+	EdDSAAlgorithm = "EdDSA"
+	// HS256Algorithm signs with the shared secret of SetSecretKey.
+	HS256Algorithm = "HS256"
+
+	rsaKeyBits = 2048
+)
+
+// SigningKey is an asymmetric key pair tokens are signed and verified with.
+// Id is sent as the kid header of the tokens it signs.
+type SigningKey struct {
+	Id        string
+	Algorithm string
+	Private   crypto.Signer
+}
+
+func (sk SigningKey) method() jwt.SigningMethod {
+	if sk.Algorithm == EdDSAAlgorithm {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// JWK is a public key as published in a JWKS document (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (sk SigningKey) jwk() JWK {
+	jwk := JWK{Kid: sk.Id, Use: "sig", Alg: sk.Algorithm}
+	switch public := sk.Private.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+	return jwk
+}
+
+// GenerateSigningKey creates a new RS256 or EdDSA key.
+func GenerateSigningKey(id string, algorithm string) (SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case RS256Algorithm:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case EdDSAAlgorithm:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+	return SigningKey{Id: id, Algorithm: algorithm, Private: private}, nil
+}
+
+// ParseSigningKeyPEM reads an RSA or Ed25519 private key in PKCS #8, or an
+// RSA key in PKCS #1, form. The algorithm follows from the key type.
+func ParseSigningKeyPEM(id string, data []byte) (SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, errors.New("no PEM block found")
+	}
+	var parsed any
+	var err error
+	if block.Type == "RSA PRIVATE KEY" {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		return SigningKey{Id: id, Algorithm: RS256Algorithm, Private: private}, nil
+	case ed25519.PrivateKey:
+		return SigningKey{Id: id, Algorithm: EdDSAAlgorithm, Private: private}, nil
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+}
+
+// EncodePEM returns the private key in PKCS #8 form.
+func (sk SigningKey) EncodePEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(sk.Private)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// Keyring holds the active key, which signs new tokens, and retired keys,
+// which only verify the tokens they signed until those expire. It does not
+// change once built; SetKeyring replaces it as a whole.
+type Keyring struct {
+	active string
+	keys   map[string]SigningKey
+}
+
+// NewKeyring builds a keyring from keys, the last of which becomes active.
+func NewKeyring(keys ...SigningKey) *Keyring {
+	kr := &Keyring{keys: make(map[string]SigningKey, len(keys))}
+	for _, key := range keys {
+		kr.keys[key.Id] = key
+		kr.active = key.Id
+	}
+	return kr
+}
+
+// Active returns the key new tokens are signed with.
+func (kr *Keyring) Active() (SigningKey, bool) {
+	key, ok := kr.keys[kr.active]
+	return key, ok
+}
+
+func (kr *Keyring) find(id string) (SigningKey, bool) {
+	key, ok := kr.keys[id]
+	return key, ok
+}
+
+// JWKS returns the public parts of all keys, active and retired.
+func (kr *Keyring) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(kr.keys))}
+	for _, key := range kr.keys {
+		set.Keys = append(set.Keys, key.jwk())
+	}
+	slices.SortFunc(set.Keys, func(a, b JWK) int { return strings.Compare(a.Kid, b.Kid) })
+	return set
+}
+
+var keyring atomic.Pointer[Keyring]
+
+// SetKeyring makes GenerateToken sign with the active key of kr instead of
+// the shared secret. Tokens signed with the secret are still accepted while
+// one is set, so that logins survive the switch.
+func SetKeyring(kr *Keyring) {
+	keyring.Store(kr)
+}
+
+func GetKeyring() *Keyring {
+	return keyring.Load()
+}
@@ -0,0 +1,112 @@
+package jwt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	jwtpkg "tick_test/utils/jwt"
+)
+
+func useKeyring(t *testing.T, kr *jwtpkg.Keyring) {
+	t.Helper()
+	original := jwtpkg.GetKeyring()
+	jwtpkg.SetKeyring(kr)
+	t.Cleanup(func() { jwtpkg.SetKeyring(original) })
+}
+
+func TestKeyring_SignAndVerify(t *testing.T) {
+	for _, algorithm := range []string{jwtpkg.EdDSAAlgorithm, jwtpkg.RS256Algorithm} {
+		t.Run(algorithm, func(t *testing.T) {
+			key, err := jwtpkg.GenerateSigningKey("key-1", algorithm)
+			if err != nil {
+				t.Fatalf("GenerateSigningKey() error = %v", err)
+			}
+			useKeyring(t, jwtpkg.NewKeyring(key))
+
+			tokenString, err := jwtpkg.GenerateToken(testUsername, testRole, time.Hour)
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+			parsed, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+			if err != nil {
+				t.Fatalf("ParseUnverified() error = %v", err)
+			}
+			if parsed.Header["kid"] != "key-1" || parsed.Header["alg"] != algorithm {
+				t.Errorf("header = %v, want kid key-1 and alg %s", parsed.Header, algorithm)
+			}
+
+			claims, err := jwtpkg.ValidateToken(tokenString)
+			if err != nil {
+				t.Fatalf("ValidateToken() error = %v", err)
+			}
+			if claims.Username != testUsername {
+				t.Errorf("Username = %v, want %v", claims.Username, testUsername)
+			}
+		})
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	originalKey := jwtpkg.GetSecretKey()
+	defer jwtpkg.SetSecretKey(originalKey)
+	jwtpkg.SetSecretKey([]byte(testSecret))
+
+	old, _ := jwtpkg.GenerateSigningKey("old", jwtpkg.EdDSAAlgorithm)
+	next, _ := jwtpkg.GenerateSigningKey("next", jwtpkg.RS256Algorithm)
+	legacyToken := createTestToken(t, testUsername, testRole, time.Hour)
+
+	useKeyring(t, jwtpkg.NewKeyring(old))
+	oldToken, err := jwtpkg.GenerateToken(testUsername, testRole, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	jwtpkg.SetKeyring(jwtpkg.NewKeyring(old, next))
+	if active, _ := jwtpkg.GetKeyring().Active(); active.Id != "next" {
+		t.Errorf("active key = %v, want next", active.Id)
+	}
+	if _, err := jwtpkg.ValidateToken(oldToken); err != nil {
+		t.Errorf("token of a retired key should verify, got %v", err)
+	}
+	if _, err := jwtpkg.ValidateToken(legacyToken); err != nil {
+		t.Errorf("HS256 token should verify while the secret is set, got %v", err)
+	}
+
+	jwtpkg.SetKeyring(jwtpkg.NewKeyring(next))
+	if _, err := jwtpkg.ValidateToken(oldToken); err == nil {
+		t.Errorf("token of a removed key should not verify")
+	}
+}
+
+func TestKeyring_JWKSAndPEM(t *testing.T) {
+	ed, _ := jwtpkg.GenerateSigningKey("b-ed", jwtpkg.EdDSAAlgorithm)
+	rsa, _ := jwtpkg.GenerateSigningKey("a-rsa", jwtpkg.RS256Algorithm)
+
+	set := jwtpkg.NewKeyring(ed, rsa).JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("len(Keys) = %d, want 2", len(set.Keys))
+	}
+	if k := set.Keys[0]; k.Kid != "a-rsa" || k.Kty != "RSA" || k.Alg != "RS256" || k.N == "" || k.E != "AQAB" {
+		t.Errorf("RSA key = %+v", k)
+	}
+	if k := set.Keys[1]; k.Kid != "b-ed" || k.Kty != "OKP" || k.Crv != "Ed25519" || k.X == "" {
+		t.Errorf("Ed25519 key = %+v", k)
+	}
+
+	data, err := ed.EncodePEM()
+	if err != nil {
+		t.Fatalf("EncodePEM() error = %v", err)
+	}
+	parsed, err := jwtpkg.ParseSigningKeyPEM("b-ed", data)
+	if err != nil {
+		t.Fatalf("ParseSigningKeyPEM() error = %v", err)
+	}
+	if parsed.Algorithm != jwtpkg.EdDSAAlgorithm {
+		t.Errorf("Algorithm = %v, want EdDSA", parsed.Algorithm)
+	}
+	if _, err := jwtpkg.ParseSigningKeyPEM("x", []byte("not a key")); err == nil {
+		t.Errorf("expected an error for invalid PEM data")
+	}
+}
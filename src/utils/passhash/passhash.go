@@ -0,0 +1,68 @@
+// Package passhash hashes passwords for storage and verifies them against
+// stored hashes. Hashes are self-describing: argon2id hashes use the PHC
+// string format, e.g. $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>, and
+// bcrypt hashes their own $2a$ format, so Verify accepts either no matter
+// which Hasher is configured.
+package passhash
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrMismatch      = errors.New("password does not match")
+	ErrUnknownFormat = errors.New("unknown password hash format")
+	ErrMalformedHash = errors.New("malformed password hash")
+)
+
+// Hasher hashes new passwords and tells which stored hashes are outdated.
+type Hasher interface {
+	Hash(password string) (string, error)
+	// NeedsRehash reports whether encoded was made with another algorithm
+	// or with weaker parameters than those of the Hasher. Hashes made with
+	// stronger parameters are kept, so that lowering the configuration does
+	// not weaken stored passwords.
+	NeedsRehash(encoded string) bool
+}
+
+// Verify returns nil if password hashes to encoded and ErrMismatch if it
+// does not.
+func Verify(password string, encoded string) error {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return verifyArgon2id(password, encoded)
+	case isBcrypt(encoded):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatch
+		}
+		return err
+	default:
+		return ErrUnknownFormat
+	}
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// Bcrypt hashes with bcrypt at Cost.
+type Bcrypt struct {
+	Cost int
+}
+
+func (b Bcrypt) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	return string(hashed), err
+}
+
+func (b Bcrypt) NeedsRehash(encoded string) bool {
+	if !isBcrypt(encoded) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < b.Cost
+}
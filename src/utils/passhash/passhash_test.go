@@ -0,0 +1,65 @@
+package passhash_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"tick_test/utils/passhash"
+)
+
+// small parameters keep the tests fast
+var weak = passhash.Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2id(t *testing.T) {
+	encoded, err := weak.Hash("correct horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$"), encoded)
+
+	assert.NoError(t, passhash.Verify("correct horse", encoded))
+	assert.ErrorIs(t, passhash.Verify("battery staple", encoded), passhash.ErrMismatch)
+
+	other, err := weak.Hash("correct horse")
+	require.NoError(t, err)
+	assert.NotEqual(t, encoded, other, "every hash gets its own salt")
+}
+
+func TestVerifyBcrypt(t *testing.T) {
+	encoded, _ := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+
+	assert.NoError(t, passhash.Verify("correct horse", string(encoded)))
+	assert.ErrorIs(t, passhash.Verify("battery staple", string(encoded)), passhash.ErrMismatch)
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	assert.ErrorIs(t, passhash.Verify("x", "!"), passhash.ErrUnknownFormat)
+	assert.ErrorIs(t, passhash.Verify("x", "$argon2id$v=16$m=64,t=1,p=1$c2FsdA$aGFzaA"), passhash.ErrMalformedHash)
+	assert.ErrorIs(t, passhash.Verify("x", "$argon2id$v=19$m=64,t=0,p=1$c2FsdA$aGFzaA"), passhash.ErrMalformedHash)
+	assert.ErrorIs(t, passhash.Verify("x", "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$!!"), passhash.ErrMalformedHash)
+}
+
+func TestNeedsRehash(t *testing.T) {
+	current, _ := weak.Hash("correct horse")
+	bcrypted, _ := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	stronger := weak
+	stronger.Memory = 128
+
+	assert.False(t, weak.NeedsRehash(current))
+	assert.True(t, stronger.NeedsRehash(current), "memory raised")
+	strongerHash, _ := stronger.Hash("correct horse")
+	assert.False(t, weak.NeedsRehash(strongerHash), "stronger hashes are kept")
+	moreLanes := weak
+	moreLanes.Parallelism = 2
+	assert.False(t, moreLanes.NeedsRehash(current), "lanes alone do not strengthen a hash")
+	assert.True(t, weak.NeedsRehash(string(bcrypted)), "bcrypt hashes move to argon2id")
+	assert.True(t, weak.NeedsRehash("!"))
+
+	assert.False(t, passhash.Bcrypt{Cost: bcrypt.MinCost}.NeedsRehash(string(bcrypted)))
+	assert.True(t, passhash.Bcrypt{Cost: bcrypt.DefaultCost}.NeedsRehash(string(bcrypted)), "cost raised")
+	costly, _ := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost+1)
+	assert.False(t, passhash.Bcrypt{Cost: bcrypt.MinCost}.NeedsRehash(string(costly)), "stronger hashes are kept")
+	assert.True(t, passhash.Bcrypt{Cost: bcrypt.MinCost}.NeedsRehash(current), "argon2id hashes move to bcrypt")
+}
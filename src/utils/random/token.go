@@ -0,0 +1,16 @@
+package random
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// Token returns n bytes from a cryptographically secure source, encoded as
+// unpadded base64url so that it can be passed in headers and URLs.
+func Token(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
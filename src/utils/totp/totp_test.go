@@ -0,0 +1,64 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tick_test/utils/totp"
+)
+
+// secret of the SHA1 test vectors in RFC 6238, appendix B
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		code, err := totp.Code(rfcSecret, totp.Counter(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tt.code, code, "time %d", tt.unix)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	counter, ok, err := totp.Verify(rfcSecret, "050471", now, 1)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, totp.Counter(now), counter)
+
+	_, ok, _ = totp.Verify(rfcSecret, "050471", now.Add(30*time.Second), 1)
+	assert.True(t, ok, "previous step is accepted")
+
+	_, ok, _ = totp.Verify(rfcSecret, "050471", now.Add(90*time.Second), 1)
+	assert.False(t, ok)
+
+	_, _, err = totp.Verify("not base32!", "050471", now, 1)
+	assert.ErrorIs(t, err, totp.ErrInvalidSecret)
+}
+
+func TestSecretAndURI(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	uri := totp.URI("Library", "john doe", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Library:john%20doe?"))
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=Library")
+}